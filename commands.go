@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"pdf_service_api/config"
+	v1 "pdf_service_api/controller/v1"
+	"pdf_service_api/models"
+	pg "pdf_service_api/postgres"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runMigrateCommand runs "migrate up", "migrate down [steps]" or "migrate status", so migrations can be applied
+// without starting the HTTP server. Down reverts one migration unless told otherwise.
+func runMigrateCommand(ctx context.Context, cfg config.Config, dbHandler pg.DatabaseHandler, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up | down [steps] | status")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := pg.MigrateUp(ctx, dbHandler)
+		for _, migration := range applied {
+			fmt.Printf("Applied migration %d %s\n", migration.Version, migration.Name)
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil {
+				return fmt.Errorf("invalid number of steps %q: %w", args[1], err)
+			}
+		}
+
+		reverted, err := pg.MigrateDown(ctx, dbHandler, steps)
+		for _, migration := range reverted {
+			fmt.Printf("Reverted migration %d %s\n", migration.Version, migration.Name)
+		}
+		return err
+	case "status":
+		statuses, err := pg.GetMigrationStatus(ctx, dbHandler)
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+			if status.Unknown {
+				state += " (unknown to this build)"
+			}
+			fmt.Printf("%04d %-32s %s\n", status.Version, status.Name, state)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", args[0])
+	}
+}
+
+// runImportCommand uploads every PDF below a directory for an owner, titled after its file name, together with the
+// meta extracted from it. Files that cannot be imported are reported and skipped.
+func runImportCommand(ctx context.Context, cfg config.Config, dbHandler pg.DatabaseHandler, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	owner := flags.String("owner", "", "the UUID of the owner of the imported documents")
+	ownerType := flags.Int("owner-type", -1, "the type of the owner of the imported documents, left empty when negative")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: import -owner <uuid> [-owner-type <type>] <dir>")
+	}
+
+	ownerUuid, err := uuid.Parse(*owner)
+	if err != nil {
+		return fmt.Errorf("invalid -owner %q: %w", *owner, err)
+	}
+
+	var documentOwnerType *int
+	if *ownerType >= 0 {
+		documentOwnerType = ownerType
+	}
+
+	blobStore, err := createBlobStore(cfg.BlobStore)
+	if err != nil {
+		return fmt.Errorf("failed to create blob store: %w", err)
+	}
+	documentRepository := pg.NewDocumentRepositoryWithBlobStore(dbHandler, blobStore)
+
+	imported, failed := 0, 0
+	err = filepath.WalkDir(flags.Arg(0), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(path), ".pdf") {
+			return nil
+		}
+
+		title := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		document := models.Document{Uuid: uuid.New(), DocumentTitle: &title, OwnerUUID: &ownerUuid, OwnerType: documentOwnerType}
+		if err := importDocument(ctx, documentRepository, document, path); err != nil {
+			fmt.Printf("Failed to import %s: %s\n", path, err)
+			failed++
+			return nil
+		}
+
+		fmt.Printf("Imported %s as %s\n", path, document.Uuid)
+		imported++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %d document(s), %d failed\n", imported, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d document(s) could not be imported", failed)
+	}
+
+	return nil
+}
+
+func importDocument(ctx context.Context, documentRepository models.DocumentRepository, document models.Document, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, len("%PDF-"))
+	if _, err := file.ReadAt(header, 0); err != nil || string(header) != "%PDF-" {
+		return errors.New("file is not a PDF document")
+	}
+
+	// The server always renders page images, so the meta references their thumbnails.
+	var meta *models.Meta
+	if extracted, err := v1.ExtractDocumentMeta(document.Uuid, file, info.Size(), true); err == nil {
+		meta = &extracted
+	} else {
+		fmt.Printf("Failed to extract meta for %s: %s\n", path, err)
+	}
+
+	return documentRepository.UploadDocumentWithDetails(ctx, document, io.NewSectionReader(file, 0, info.Size()), meta, nil)
+}
+
+// exportedDocument is the JSON written next to every exported PDF.
+type exportedDocument struct {
+	Document   models.Document    `json:"document"`
+	Meta       *models.Meta       `json:"meta,omitempty"`
+	Selections []models.Selection `json:"selections"`
+}
+
+// runExportCommand writes every document of an owner to a directory, as <documentUUID>.pdf holding its content and
+// <documentUUID>.json holding its details, meta and selections.
+func runExportCommand(ctx context.Context, cfg config.Config, dbHandler pg.DatabaseHandler, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	out := flags.String("out", "export", "the directory the documents are written to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: export [-out <dir>] <owner>")
+	}
+
+	ownerUuid, err := uuid.Parse(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid owner %q: %w", flags.Arg(0), err)
+	}
+
+	if err := os.MkdirAll(*out, 0o750); err != nil {
+		return err
+	}
+
+	blobStore, err := createBlobStore(cfg.BlobStore)
+	if err != nil {
+		return fmt.Errorf("failed to create blob store: %w", err)
+	}
+	documentRepository := pg.NewDocumentRepositoryWithBlobStore(dbHandler, blobStore)
+	metaRepository := pg.NewMetaRepository(dbHandler)
+	selectionRepository := pg.NewSelectionRepository(dbHandler)
+
+	exported := 0
+	err = pg.ForEachDocument(ctx, dbHandler, &ownerUuid, func(reference pg.DocumentReference) error {
+		document, content, err := documentRepository.GetDocumentContent(ctx, reference.Uuid, ownerUuid)
+		if err != nil {
+			return fmt.Errorf("failed to read document %s: %w", reference.Uuid, err)
+		}
+		defer content.Close()
+
+		if err := writeFile(filepath.Join(*out, reference.Uuid.String()+".pdf"), content); err != nil {
+			return err
+		}
+
+		export := exportedDocument{Document: document}
+		meta, err := metaRepository.GetMeta(ctx, reference.Uuid, ownerUuid)
+		switch {
+		case err == nil:
+			export.Meta = &meta
+		case !errors.Is(err, sql.ErrNoRows):
+			return fmt.Errorf("failed to read the meta of document %s: %w", reference.Uuid, err)
+		}
+
+		export.Selections, err = selectionRepository.GetSelectionsByDocumentUUID(ctx, reference.Uuid, ownerUuid, map[string]bool{})
+		if err != nil {
+			return fmt.Errorf("failed to read the selections of document %s: %w", reference.Uuid, err)
+		}
+
+		details, err := json.MarshalIndent(export, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		if err := writeFile(filepath.Join(*out, reference.Uuid.String()+".json"), bytes.NewReader(details)); err != nil {
+			return err
+		}
+
+		exported++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d document(s) to %s\n", exported, *out)
+	return nil
+}
+
+func writeFile(path string, content io.Reader) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, content); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// runReprocessCommand extracts the meta of every document, or of the documents of one owner, again and stores it in
+// place of the meta the document had. Documents without an owner have no meta and are skipped.
+func runReprocessCommand(ctx context.Context, cfg config.Config, dbHandler pg.DatabaseHandler, args []string) error {
+	flags := flag.NewFlagSet("reprocess", flag.ExitOnError)
+	owner := flags.String("owner", "", "only reprocess the documents of the owner with this UUID")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var ownerUuid *uuid.UUID
+	if *owner != "" {
+		parsed, err := uuid.Parse(*owner)
+		if err != nil {
+			return fmt.Errorf("invalid -owner %q: %w", *owner, err)
+		}
+		ownerUuid = &parsed
+	}
+
+	blobStore, err := createBlobStore(cfg.BlobStore)
+	if err != nil {
+		return fmt.Errorf("failed to create blob store: %w", err)
+	}
+	documentRepository := pg.NewDocumentRepositoryWithBlobStore(dbHandler, blobStore)
+	metaRepository := pg.NewMetaRepository(dbHandler)
+
+	reprocessed, failed := 0, 0
+	err = pg.ForEachDocument(ctx, dbHandler, ownerUuid, func(reference pg.DocumentReference) error {
+		if reference.OwnerUUID == nil {
+			return nil
+		}
+
+		if err := reprocessDocument(ctx, documentRepository, metaRepository, reference.Uuid, *reference.OwnerUUID); err != nil {
+			fmt.Printf("Failed to reprocess document %s: %s\n", reference.Uuid, err)
+			failed++
+			return nil
+		}
+
+		reprocessed++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Reprocessed %d document(s), %d failed\n", reprocessed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d document(s) could not be reprocessed", failed)
+	}
+
+	return nil
+}
+
+func reprocessDocument(ctx context.Context, documentRepository models.DocumentRepository, metaRepository models.MetaRepository, documentUuid, ownerUuid uuid.UUID) error {
+	_, content, err := documentRepository.GetDocumentContent(ctx, documentUuid, ownerUuid)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	// Blob store readers can be read at an offset directly; other content is small enough to be held in memory.
+	readerAt, ok := content.(io.ReaderAt)
+	if !ok {
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return err
+		}
+		readerAt = bytes.NewReader(data)
+	}
+
+	size, err := content.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	meta, err := v1.ExtractDocumentMeta(documentUuid, readerAt, size, true)
+	if err != nil {
+		return err
+	}
+
+	err = metaRepository.UpdateMeta(ctx, documentUuid, ownerUuid, meta)
+	if errors.Is(err, sql.ErrNoRows) {
+		return metaRepository.AddMeta(ctx, meta, ownerUuid)
+	}
+
+	return err
+}
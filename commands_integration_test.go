@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"pdf_service_api/config"
+	"pdf_service_api/models"
+	pg "pdf_service_api/postgres"
+	"pdf_service_api/testutil"
+	"testing"
+)
+
+func TestCommandsIntegration(t *testing.T) {
+	t.Parallel()
+	t.Run("Import the PDFs below a directory", importCommand)
+	t.Run("Export the documents of an owner", exportCommand)
+	t.Run("Reprocess documents held in the database and in the blob store", reprocessCommand)
+}
+
+// setupCommandDatabase starts a migrated database and returns it with a config keeping blobs in a temporary directory.
+func setupCommandDatabase(t *testing.T) (pg.DatabaseHandler, config.Config) {
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, "user", "password")
+	require.NoError(t, err)
+	t.Cleanup(testutil.CleanUp(ctx, *ctr))
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	cfg := config.Default()
+	cfg.BlobStore = config.BlobStoreConfig{Type: "filesystem", Path: t.TempDir()}
+	return dbHandle, cfg
+}
+
+func documentsOf(t *testing.T, dbHandle pg.DatabaseHandler, owner uuid.UUID) []pg.DocumentReference {
+	documents := make([]pg.DocumentReference, 0)
+	err := pg.ForEachDocument(context.Background(), dbHandle, &owner, func(document pg.DocumentReference) error {
+		documents = append(documents, document)
+		return nil
+	})
+	require.NoError(t, err)
+	return documents
+}
+
+func writeTestFile(t *testing.T, path string, content []byte) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+}
+
+func importCommand(t *testing.T) {
+	t.Parallel()
+	dbHandle, cfg := setupCommandDatabase(t)
+	ctx := context.Background()
+	owner := uuid.New()
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "first.pdf"), testutil.BuildTestPDF(testutil.LetterPage(), testutil.LetterPage()))
+	writeTestFile(t, filepath.Join(dir, "nested", "second.PDF"), testutil.BuildTestPDF(testutil.LetterPage()))
+	writeTestFile(t, filepath.Join(dir, "notes.txt"), []byte("not imported"))
+	writeTestFile(t, filepath.Join(dir, "broken.pdf"), []byte("THIS IS NOT A PDF"))
+
+	err := runImportCommand(ctx, cfg, dbHandle, []string{"-owner", owner.String(), "-owner-type", "1", dir})
+	require.Error(t, err, "the file that is not a PDF fails the command")
+	assert.Equal(t, "1 document(s) could not be imported", err.Error())
+
+	blobStore, err := createBlobStore(cfg.BlobStore)
+	require.NoError(t, err)
+	documentRepository := pg.NewDocumentRepositoryWithBlobStore(dbHandle, blobStore)
+	metaRepository := pg.NewMetaRepository(dbHandle)
+
+	pages := make(map[string]uint32)
+	for _, reference := range documentsOf(t, dbHandle, owner) {
+		document, err := documentRepository.GetDocumentByDocumentUUID(ctx, reference.Uuid, owner, map[string]bool{})
+		require.NoError(t, err)
+		require.NotNil(t, document.DocumentTitle)
+		require.NotNil(t, document.OwnerType)
+		assert.Equal(t, 1, *document.OwnerType)
+
+		meta, err := metaRepository.GetMeta(ctx, reference.Uuid, owner)
+		require.NoError(t, err)
+		require.NotNil(t, meta.NumberOfPages)
+		pages[*document.DocumentTitle] = *meta.NumberOfPages
+	}
+
+	assert.Equal(t, map[string]uint32{"first": 2, "second": 1}, pages, "every PDF is imported with its meta, titled after its file")
+}
+
+func exportCommand(t *testing.T) {
+	t.Parallel()
+	dbHandle, cfg := setupCommandDatabase(t)
+	ctx := context.Background()
+	owner, other := uuid.New(), uuid.New()
+
+	blobStore, err := createBlobStore(cfg.BlobStore)
+	require.NoError(t, err)
+	documentRepository := pg.NewDocumentRepositoryWithBlobStore(dbHandle, blobStore)
+
+	content := testutil.BuildTestPDF(testutil.LetterPage())
+	documents := []models.Document{{Uuid: uuid.New(), OwnerUUID: &owner}, {Uuid: uuid.New(), OwnerUUID: &owner}}
+	for _, document := range documents {
+		pageCount := uint32(1)
+		meta := models.Meta{DocumentUUID: document.Uuid, NumberOfPages: &pageCount}
+		require.NoError(t, documentRepository.UploadDocumentWithDetails(ctx, document, bytes.NewReader(content), &meta, nil))
+	}
+	otherDocument := models.Document{Uuid: uuid.New(), OwnerUUID: &other}
+	require.NoError(t, documentRepository.UploadDocumentFromReader(ctx, otherDocument, bytes.NewReader(content)))
+
+	out := filepath.Join(t.TempDir(), "export")
+	require.NoError(t, runExportCommand(ctx, cfg, dbHandle, []string{"-out", out, owner.String()}))
+
+	entries, err := os.ReadDir(out)
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	expected := make([]string, 0)
+	for _, document := range documents {
+		expected = append(expected, document.Uuid.String()+".pdf", document.Uuid.String()+".json")
+	}
+	assert.ElementsMatch(t, expected, names, "only the documents of the owner are exported, each as a PDF and its details")
+
+	for _, document := range documents {
+		exportedContent, err := os.ReadFile(filepath.Join(out, document.Uuid.String()+".pdf"))
+		require.NoError(t, err)
+		assert.Equal(t, content, exportedContent)
+
+		details, err := os.ReadFile(filepath.Join(out, document.Uuid.String()+".json"))
+		require.NoError(t, err)
+
+		var exported exportedDocument
+		require.NoError(t, json.Unmarshal(details, &exported))
+		assert.Equal(t, document.Uuid, exported.Document.Uuid)
+		require.NotNil(t, exported.Meta)
+		require.NotNil(t, exported.Meta.NumberOfPages)
+		assert.Equal(t, uint32(1), *exported.Meta.NumberOfPages)
+	}
+}
+
+func reprocessCommand(t *testing.T) {
+	t.Parallel()
+	dbHandle, cfg := setupCommandDatabase(t)
+	ctx := context.Background()
+	owner := uuid.New()
+
+	blobStore, err := createBlobStore(cfg.BlobStore)
+	require.NoError(t, err)
+	documentRepository := pg.NewDocumentRepositoryWithBlobStore(dbHandle, blobStore)
+	metaRepository := pg.NewMetaRepository(dbHandle)
+
+	// Content in the blob store is read at an offset directly, with stale meta that is replaced.
+	stored := models.Document{Uuid: uuid.New(), OwnerUUID: &owner}
+	stalePages := uint32(99)
+	staleMeta := models.Meta{DocumentUUID: stored.Uuid, NumberOfPages: &stalePages}
+	storedContent := testutil.BuildTestPDF(testutil.LetterPage(), testutil.LetterPage(), testutil.LetterPage())
+	require.NoError(t, documentRepository.UploadDocumentWithDetails(ctx, stored, bytes.NewReader(storedContent), &staleMeta, nil))
+
+	// Content held in the database is not an io.ReaderAt, so it is read into memory, and it has no meta yet.
+	inline := uuid.New()
+	err = dbHandle.WithConnection(func(db *sql.DB) error {
+		_, err := db.Exec(`INSERT INTO document_table ("Document_UUID", "Document_Base64", "Owner_UUID") VALUES ($1, $2, $3)`,
+			inline, base64.StdEncoding.EncodeToString(testutil.BuildTestPDF(testutil.LetterPage(), testutil.LetterPage())), owner)
+		return err
+	})
+	require.NoError(t, err)
+
+	// Documents of other owners are left alone when an owner is given.
+	other := uuid.New()
+	otherDocument := models.Document{Uuid: uuid.New(), OwnerUUID: &other}
+	require.NoError(t, documentRepository.UploadDocumentFromReader(ctx, otherDocument, bytes.NewReader(storedContent)))
+
+	require.NoError(t, runReprocessCommand(ctx, cfg, dbHandle, []string{"-owner", owner.String()}))
+
+	for documentUuid, expectedPages := range map[uuid.UUID]uint32{stored.Uuid: 3, inline: 2} {
+		meta, err := metaRepository.GetMeta(ctx, documentUuid, owner)
+		require.NoError(t, err)
+		require.NotNil(t, meta.NumberOfPages)
+		assert.Equal(t, expectedPages, *meta.NumberOfPages, documentUuid.String())
+		require.NotNil(t, meta.Pages)
+		assert.Len(t, *meta.Pages, int(expectedPages))
+	}
+
+	_, err = metaRepository.GetMeta(ctx, otherDocument.Uuid, other)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// A document whose content cannot be parsed is counted as a failure, and the others are still reprocessed.
+	broken := models.Document{Uuid: uuid.New(), OwnerUUID: &owner}
+	require.NoError(t, documentRepository.UploadDocumentFromReader(ctx, broken, bytes.NewReader([]byte("%PDF-1.4 THIS IS A TEST DOCUMENT"))))
+
+	err = runReprocessCommand(ctx, cfg, dbHandle, nil)
+	require.Error(t, err)
+	assert.Equal(t, "1 document(s) could not be reprocessed", err.Error())
+
+	meta, err := metaRepository.GetMeta(ctx, otherDocument.Uuid, other)
+	require.NoError(t, err, "without an owner every document is reprocessed")
+	require.NotNil(t, meta.NumberOfPages)
+	assert.Equal(t, uint32(3), *meta.NumberOfPages)
+}
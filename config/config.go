@@ -0,0 +1,537 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is the configuration of the service. It is built by Load from, in increasing order of precedence, the
+// defaults, a YAML or TOML configuration file, environment variables and command-line flags.
+type Config struct {
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	BlobStore BlobStoreConfig `yaml:"blobStore" toml:"blobStore"`
+	Auth      AuthConfig      `yaml:"auth" toml:"auth"`
+	Registry  RegistryConfig  `yaml:"registry" toml:"registry"`
+	Eureka    EurekaConfig    `yaml:"eureka" toml:"eureka"`
+	Consul    ConsulConfig    `yaml:"consul" toml:"consul"`
+	Render    RenderConfig    `yaml:"render" toml:"render"`
+	Limits    LimitsConfig    `yaml:"limits" toml:"limits"`
+	Features  FeaturesConfig  `yaml:"features" toml:"features"`
+}
+
+// ServerConfig configures the HTTP server. On shutdown it reports not ready and waits ShutdownDelay, so load balancers
+// stop routing to it, then waits up to ShutdownTimeout for requests in flight.
+type ServerConfig struct {
+	ListenAddress   string   `yaml:"listenAddress" toml:"listenAddress"`
+	ShutdownDelay   Duration `yaml:"shutdownDelay" toml:"shutdownDelay"`
+	ShutdownTimeout Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout"`
+}
+
+// DatabaseConfig configures the connection to Postgres. URL, when set, is used in place of the individual settings.
+// Zero pool settings keep the pool's defaults.
+type DatabaseConfig struct {
+	Host             string   `yaml:"host" toml:"host"`
+	Port             string   `yaml:"port" toml:"port"`
+	User             string   `yaml:"user" toml:"user"`
+	Password         string   `yaml:"password" toml:"password"`
+	Name             string   `yaml:"name" toml:"name"`
+	URL              string   `yaml:"url" toml:"url"`
+	MaxOpenConns     int      `yaml:"maxOpenConns" toml:"maxOpenConns"`
+	MaxIdleConns     int      `yaml:"maxIdleConns" toml:"maxIdleConns"`
+	ConnMaxLifetime  Duration `yaml:"connMaxLifetime" toml:"connMaxLifetime"`
+	ConnMaxIdleTime  Duration `yaml:"connMaxIdleTime" toml:"connMaxIdleTime"`
+	StatementTimeout Duration `yaml:"statementTimeout" toml:"statementTimeout"`
+}
+
+// BlobStoreConfig selects where document content and rendered page images are kept: "filesystem", below Path, or "s3".
+type BlobStoreConfig struct {
+	Type string   `yaml:"type" toml:"type"`
+	Path string   `yaml:"path" toml:"path"`
+	S3   S3Config `yaml:"s3" toml:"s3"`
+}
+
+// S3Config configures the S3 compatible blob store.
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint" toml:"endpoint"`
+	Bucket    string `yaml:"bucket" toml:"bucket"`
+	AccessKey string `yaml:"accessKey" toml:"accessKey"`
+	SecretKey string `yaml:"secretKey" toml:"secretKey"`
+	Region    string `yaml:"region" toml:"region"`
+	UseSSL    bool   `yaml:"useSSL" toml:"useSSL"`
+}
+
+// AuthConfig configures how requests are authenticated. With Disabled set, requests are trusted to name their owner.
+type AuthConfig struct {
+	Disabled      bool   `yaml:"disabled" toml:"disabled"`
+	JWTHMACSecret string `yaml:"jwtHMACSecret" toml:"jwtHMACSecret"`
+	JWTJWKSFile   string `yaml:"jwtJWKSFile" toml:"jwtJWKSFile"`
+	JWTJWKSURL    string `yaml:"jwtJWKSURL" toml:"jwtJWKSURL"`
+	JWTIssuer     string `yaml:"jwtIssuer" toml:"jwtIssuer"`
+	JWTAudience   string `yaml:"jwtAudience" toml:"jwtAudience"`
+}
+
+// RegistryConfig selects the service registry the instance is registered with: "eureka", "consul" or "none". Left
+// empty, Eureka is used when eureka.appIP is set and no registry otherwise; see Config.RegistryType.
+type RegistryConfig struct {
+	Type string `yaml:"type" toml:"type"`
+}
+
+// EurekaConfig configures the registration with Eureka. AppPort and ManagementPort default to the port of the listen
+// address, and zero durations to Eureka's defaults.
+type EurekaConfig struct {
+	ServiceURLs     []string `yaml:"serviceURLs" toml:"serviceURLs"`
+	AppIP           string   `yaml:"appIP" toml:"appIP"`
+	AppName         string   `yaml:"appName" toml:"appName"`
+	AppPort         int      `yaml:"appPort" toml:"appPort"`
+	InstanceID      string   `yaml:"instanceID" toml:"instanceID"`
+	ManagementPort  int      `yaml:"managementPort" toml:"managementPort"`
+	RenewalInterval Duration `yaml:"renewalInterval" toml:"renewalInterval"`
+	LeaseDuration   Duration `yaml:"leaseDuration" toml:"leaseDuration"`
+}
+
+// ConsulConfig configures the registration with the Consul agent at Address. ServicePort defaults to the port of the
+// listen address, and zero durations to the defaults of the consul package.
+type ConsulConfig struct {
+	Address                        string   `yaml:"address" toml:"address"`
+	Token                          string   `yaml:"token" toml:"token"`
+	ServiceName                    string   `yaml:"serviceName" toml:"serviceName"`
+	ServiceID                      string   `yaml:"serviceID" toml:"serviceID"`
+	ServiceAddress                 string   `yaml:"serviceAddress" toml:"serviceAddress"`
+	ServicePort                    int      `yaml:"servicePort" toml:"servicePort"`
+	Tags                           []string `yaml:"tags" toml:"tags"`
+	CheckTTL                       Duration `yaml:"checkTTL" toml:"checkTTL"`
+	DeregisterCriticalServiceAfter Duration `yaml:"deregisterCriticalServiceAfter" toml:"deregisterCriticalServiceAfter"`
+}
+
+// RenderConfig configures how page images are rendered. Command is the pdftoppm compatible rasteriser run for every
+// page rendered, so it must be installed alongside the service. Zero limits keep the defaults of the pdf package.
+type RenderConfig struct {
+	Command       string `yaml:"command" toml:"command"`
+	MaxConcurrent int    `yaml:"maxConcurrent" toml:"maxConcurrent"`
+	MaxPixels     int64  `yaml:"maxPixels" toml:"maxPixels"`
+}
+
+// LimitsConfig bounds the work done for a request. Zero values leave them unbounded, but uploads are limited to 64 MiB
+// unless configured otherwise.
+type LimitsConfig struct {
+	RequestTimeout Duration `yaml:"requestTimeout" toml:"requestTimeout"`
+	MaxUploadBytes int64    `yaml:"maxUploadBytes" toml:"maxUploadBytes"`
+}
+
+// FeaturesConfig turns optional behaviour of the service on or off.
+type FeaturesConfig struct {
+	AutoMigrate         bool `yaml:"autoMigrate" toml:"autoMigrate"`
+	MigrateBlobsOnStart bool `yaml:"migrateBlobsOnStart" toml:"migrateBlobsOnStart"`
+	ExtractMetaOnUpload bool `yaml:"extractMetaOnUpload" toml:"extractMetaOnUpload"`
+	DocumentSharing     bool `yaml:"documentSharing" toml:"documentSharing"`
+	Metrics             bool `yaml:"metrics" toml:"metrics"`
+}
+
+// Duration is a time.Duration written as a string such as "30s" or "5m" in configuration files.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Default returns the configuration used for everything that is not configured otherwise.
+func Default() Config {
+	return Config{
+		Server:    ServerConfig{ListenAddress: ":8080", ShutdownTimeout: Duration(30 * time.Second)},
+		BlobStore: BlobStoreConfig{Type: "filesystem", Path: "data/blobs"},
+		Eureka:    EurekaConfig{ServiceURLs: []string{"http://127.0.0.1:8761/eureka"}, AppName: "go-backend"},
+		Consul:    ConsulConfig{Address: "http://127.0.0.1:8500", ServiceName: "go-backend"},
+		Render:    RenderConfig{Command: "pdftoppm"},
+		Limits:    LimitsConfig{MaxUploadBytes: 64 << 20},
+		Features: FeaturesConfig{
+			AutoMigrate:         true,
+			MigrateBlobsOnStart: true,
+			ExtractMetaOnUpload: true,
+			DocumentSharing:     true,
+			Metrics:             true,
+		},
+	}
+}
+
+// setting is a configuration value that can be set by an environment variable and by the command-line flag named
+// after it, such as DATABASE_HOST and -database-host.
+type setting struct {
+	env   string
+	usage string
+	field func(config *Config) any
+}
+
+var settings = []setting{
+	{"LISTEN_ADDRESS", "the address the HTTP server listens on", func(c *Config) any { return &c.Server.ListenAddress }},
+	{"SHUTDOWN_DELAY", "how long the instance reports not ready before it stops accepting connections", func(c *Config) any { return &c.Server.ShutdownDelay }},
+	{"SHUTDOWN_TIMEOUT", "how long requests in flight are waited for on shutdown", func(c *Config) any { return &c.Server.ShutdownTimeout }},
+	{"DATABASE_HOST", "the host of the database", func(c *Config) any { return &c.Database.Host }},
+	{"DATABASE_PORT", "the port of the database", func(c *Config) any { return &c.Database.Port }},
+	{"DATABASE_USER", "the user logging in to the database", func(c *Config) any { return &c.Database.User }},
+	{"DATABASE_PASSWORD", "the password of the database user", func(c *Config) any { return &c.Database.Password }},
+	{"DATABASE_DB", "the name of the database", func(c *Config) any { return &c.Database.Name }},
+	{"DATABASE_URL", "a connection URL used in place of the other database settings", func(c *Config) any { return &c.Database.URL }},
+	{"DATABASE_MAX_OPEN_CONNS", "the maximum number of open database connections", func(c *Config) any { return &c.Database.MaxOpenConns }},
+	{"DATABASE_MAX_IDLE_CONNS", "the maximum number of idle database connections", func(c *Config) any { return &c.Database.MaxIdleConns }},
+	{"DATABASE_CONN_MAX_LIFETIME", "how long a database connection is reused", func(c *Config) any { return &c.Database.ConnMaxLifetime }},
+	{"DATABASE_CONN_MAX_IDLE_TIME", "how long a database connection is kept idle", func(c *Config) any { return &c.Database.ConnMaxIdleTime }},
+	{"DATABASE_STATEMENT_TIMEOUT", "how long a database statement may run", func(c *Config) any { return &c.Database.StatementTimeout }},
+	{"BLOB_STORE_TYPE", "where content is kept, filesystem or s3", func(c *Config) any { return &c.BlobStore.Type }},
+	{"BLOB_STORE_PATH", "the directory of the filesystem blob store", func(c *Config) any { return &c.BlobStore.Path }},
+	{"S3_ENDPOINT", "the endpoint of the S3 blob store", func(c *Config) any { return &c.BlobStore.S3.Endpoint }},
+	{"S3_BUCKET", "the bucket of the S3 blob store", func(c *Config) any { return &c.BlobStore.S3.Bucket }},
+	{"S3_ACCESS_KEY", "the access key of the S3 blob store", func(c *Config) any { return &c.BlobStore.S3.AccessKey }},
+	{"S3_SECRET_KEY", "the secret key of the S3 blob store", func(c *Config) any { return &c.BlobStore.S3.SecretKey }},
+	{"S3_REGION", "the region of the S3 blob store", func(c *Config) any { return &c.BlobStore.S3.Region }},
+	{"S3_USE_SSL", "whether the S3 blob store is reached over TLS", func(c *Config) any { return &c.BlobStore.S3.UseSSL }},
+	{"AUTH_DISABLED", "trust requests to name their owner instead of authenticating them", func(c *Config) any { return &c.Auth.Disabled }},
+	{"JWT_HMAC_SECRET", "the secret HS256 tokens are signed with", func(c *Config) any { return &c.Auth.JWTHMACSecret }},
+	{"JWT_JWKS_FILE", "a file holding the keys RS256 tokens are signed with", func(c *Config) any { return &c.Auth.JWTJWKSFile }},
+	{"JWT_JWKS_URL", "a URL serving the keys RS256 tokens are signed with", func(c *Config) any { return &c.Auth.JWTJWKSURL }},
+	{"JWT_ISSUER", "the issuer tokens must name", func(c *Config) any { return &c.Auth.JWTIssuer }},
+	{"JWT_AUDIENCE", "the audience tokens must name", func(c *Config) any { return &c.Auth.JWTAudience }},
+	{"REGISTRY_TYPE", "the service registry to register with, eureka, consul or none", func(c *Config) any { return &c.Registry.Type }},
+	{"EUREKA_SERVICE_URLS", "the comma separated URLs of the Eureka servers", func(c *Config) any { return &c.Eureka.ServiceURLs }},
+	{"EUREKA_APP_IP", "the IP address registered with Eureka", func(c *Config) any { return &c.Eureka.AppIP }},
+	{"EUREKA_APP_NAME", "the application name registered with Eureka", func(c *Config) any { return &c.Eureka.AppName }},
+	{"APP_PORT", "the port registered with Eureka", func(c *Config) any { return &c.Eureka.AppPort }},
+	{"EUREKA_INSTANCE_ID", "the instance ID registered with Eureka", func(c *Config) any { return &c.Eureka.InstanceID }},
+	{"EUREKA_MANAGEMENT_PORT", "the management port registered with Eureka", func(c *Config) any { return &c.Eureka.ManagementPort }},
+	{"EUREKA_RENEWAL_INTERVAL", "how often the Eureka lease is renewed", func(c *Config) any { return &c.Eureka.RenewalInterval }},
+	{"EUREKA_LEASE_DURATION", "how long Eureka keeps the instance without renewals", func(c *Config) any { return &c.Eureka.LeaseDuration }},
+	{"CONSUL_HTTP_ADDR", "the URL of the Consul agent", func(c *Config) any { return &c.Consul.Address }},
+	{"CONSUL_HTTP_TOKEN", "the ACL token sent to the Consul agent", func(c *Config) any { return &c.Consul.Token }},
+	{"CONSUL_SERVICE_NAME", "the service name registered with Consul", func(c *Config) any { return &c.Consul.ServiceName }},
+	{"CONSUL_SERVICE_ID", "the service ID registered with Consul", func(c *Config) any { return &c.Consul.ServiceID }},
+	{"CONSUL_SERVICE_ADDRESS", "the address registered with Consul", func(c *Config) any { return &c.Consul.ServiceAddress }},
+	{"CONSUL_SERVICE_PORT", "the port registered with Consul", func(c *Config) any { return &c.Consul.ServicePort }},
+	{"CONSUL_TAGS", "the comma separated tags registered with Consul", func(c *Config) any { return &c.Consul.Tags }},
+	{"CONSUL_CHECK_TTL", "how long the Consul health check stays passing without a report", func(c *Config) any { return &c.Consul.CheckTTL }},
+	{"CONSUL_DEREGISTER_CRITICAL_AFTER", "how long Consul keeps an instance whose check is critical", func(c *Config) any { return &c.Consul.DeregisterCriticalServiceAfter }},
+	{"RENDER_COMMAND", "the pdftoppm compatible command rendering page images", func(c *Config) any { return &c.Render.Command }},
+	{"RENDER_MAX_CONCURRENT", "the maximum number of page images rendered at once", func(c *Config) any { return &c.Render.MaxConcurrent }},
+	{"RENDER_MAX_PIXELS", "the largest page image rendered, in pixels", func(c *Config) any { return &c.Render.MaxPixels }},
+	{"REQUEST_TIMEOUT", "how long a request may take", func(c *Config) any { return &c.Limits.RequestTimeout }},
+	{"MAX_UPLOAD_BYTES", "the largest request body accepted", func(c *Config) any { return &c.Limits.MaxUploadBytes }},
+	{"DATABASE_AUTO_MIGRATE", "apply schema migrations when the server starts", func(c *Config) any { return &c.Features.AutoMigrate }},
+	{"MIGRATE_BLOBS_ON_START", "move legacy base64 documents into the blob store when the server starts", func(c *Config) any { return &c.Features.MigrateBlobsOnStart }},
+	{"EXTRACT_META_ON_UPLOAD", "extract the meta of uploaded documents", func(c *Config) any { return &c.Features.ExtractMetaOnUpload }},
+	{"DOCUMENT_SHARING", "serve the endpoints sharing documents", func(c *Config) any { return &c.Features.DocumentSharing }},
+	{"METRICS", "serve Prometheus metrics on /metrics", func(c *Config) any { return &c.Features.Metrics }},
+}
+
+// flagName returns the name of the command-line flag for an environment variable, such as database-host for
+// DATABASE_HOST.
+func flagName(env string) string {
+	return strings.ReplaceAll(strings.ToLower(env), "_", "-")
+}
+
+// Load builds the configuration from the defaults, the configuration file named by the -config flag or the
+// CONFIG_FILE environment variable, the environment variables and the flags in args, each overriding the ones before.
+// It returns the arguments following the flags. The configuration is not validated; see Validate.
+func Load(args []string, lookupEnv func(string) (string, bool)) (Config, []string, error) {
+	flags := flag.NewFlagSet("pdf_service_api", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	configFile := flags.String("config", "", "a YAML or TOML configuration file")
+
+	flagValues := make(map[string]string)
+	for _, s := range settings {
+		name := flagName(s.env)
+		flags.Func(name, s.usage+" ("+s.env+")", func(value string) error {
+			flagValues[name] = value
+			return nil
+		})
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	config := Default()
+	if *configFile == "" {
+		*configFile, _ = lookupEnv("CONFIG_FILE")
+	}
+
+	if *configFile != "" {
+		if err := readFile(*configFile, &config); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := lookupEnv(s.env); ok && value != "" {
+			if err := setValue(s.field(&config), value); err != nil {
+				return Config{}, nil, fmt.Errorf("environment variable %s: %w", s.env, err)
+			}
+		}
+	}
+
+	for _, s := range settings {
+		name := flagName(s.env)
+		if value, ok := flagValues[name]; ok {
+			if err := setValue(s.field(&config), value); err != nil {
+				return Config{}, nil, fmt.Errorf("flag -%s: %w", name, err)
+			}
+		}
+	}
+
+	return config, flags.Args(), nil
+}
+
+// Usage writes the flags Load accepts, with the environment variables they correspond to.
+func Usage(out io.Writer) {
+	fmt.Fprintln(out, "  -config string\n    \ta YAML or TOML configuration file (CONFIG_FILE)")
+	for _, s := range settings {
+		fmt.Fprintf(out, "  -%s value\n    \t%s (%s)\n", flagName(s.env), s.usage, s.env)
+	}
+}
+
+// readFile decodes a configuration file into config, choosing YAML or TOML by its extension. Keys that do not match a
+// setting are rejected, so misspelt settings are not silently ignored.
+func readFile(path string, config *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("configuration file %s: %w", path, err)
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(config); err != nil {
+			return fmt.Errorf("configuration file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("configuration file %s must end in .yaml, .yml or .toml", path)
+	}
+
+	return nil
+}
+
+func setValue(field any, value string) error {
+	switch field := field.(type) {
+	case *string:
+		*field = value
+	case *[]string:
+		*field = make([]string, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*field = append(*field, item)
+			}
+		}
+	case *int:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", value)
+		}
+		*field = parsed
+	case *int64:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", value)
+		}
+		*field = parsed
+	case *bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		*field = parsed
+	case *Duration:
+		if err := field.UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s or 5m", value)
+		}
+	default:
+		return fmt.Errorf("unsupported setting type %T", field)
+	}
+
+	return nil
+}
+
+// Validate reports every problem with the configuration at once, as one error per line.
+func (c Config) Validate() error {
+	var problems []error
+	problem := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	if _, port, err := net.SplitHostPort(c.Server.ListenAddress); err != nil {
+		problem("server.listenAddress %q must be a host and port such as :8080", c.Server.ListenAddress)
+	} else if _, err := strconv.Atoi(port); err != nil {
+		problem("server.listenAddress %q must end in a numeric port", c.Server.ListenAddress)
+	}
+
+	if c.Server.ShutdownDelay < 0 || c.Server.ShutdownTimeout < 0 {
+		problem("server.shutdownDelay and server.shutdownTimeout must not be negative")
+	}
+
+	if c.Database.URL == "" {
+		for _, required := range []struct{ name, value string }{
+			{"database.host", c.Database.Host},
+			{"database.port", c.Database.Port},
+			{"database.user", c.Database.User},
+			{"database.password", c.Database.Password},
+			{"database.name", c.Database.Name},
+		} {
+			if required.value == "" {
+				problem("%s must be set, or database.url given instead", required.name)
+			}
+		}
+	}
+
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 {
+		problem("database.maxOpenConns and database.maxIdleConns must not be negative")
+	}
+
+	if c.Database.ConnMaxLifetime < 0 || c.Database.ConnMaxIdleTime < 0 || c.Database.StatementTimeout < 0 {
+		problem("database durations must not be negative")
+	}
+
+	switch c.BlobStore.Type {
+	case "filesystem":
+		if c.BlobStore.Path == "" {
+			problem("blobStore.path must be set for the filesystem blob store")
+		}
+	case "s3":
+		if c.BlobStore.S3.Endpoint == "" || c.BlobStore.S3.Bucket == "" {
+			problem("blobStore.s3.endpoint and blobStore.s3.bucket must be set for the s3 blob store")
+		}
+	default:
+		problem("blobStore.type %q must be filesystem or s3", c.BlobStore.Type)
+	}
+
+	if c.Render.Command == "" {
+		problem("render.command must be set")
+	}
+
+	if c.Render.MaxConcurrent < 0 || c.Render.MaxPixels < 0 {
+		problem("render.maxConcurrent and render.maxPixels must not be negative")
+	}
+
+	if c.Eureka.AppPort < 0 || c.Eureka.AppPort > 65535 {
+		problem("eureka.appPort %d must be between 1 and 65535", c.Eureka.AppPort)
+	}
+
+	if c.Eureka.ManagementPort < 0 || c.Eureka.ManagementPort > 65535 {
+		problem("eureka.managementPort %d must be between 1 and 65535", c.Eureka.ManagementPort)
+	}
+
+	if c.Eureka.RenewalInterval < 0 || c.Eureka.LeaseDuration < 0 {
+		problem("eureka durations must not be negative")
+	}
+
+	switch c.RegistryType() {
+	case "none":
+	case "eureka":
+		if c.Eureka.AppIP == "" || c.Eureka.AppName == "" {
+			problem("eureka.appIP and eureka.appName must be set to register with eureka")
+		}
+
+		if len(c.Eureka.ServiceURLs) == 0 {
+			problem("eureka.serviceURLs must hold at least one URL to register with eureka")
+		}
+
+		for _, serviceURL := range c.Eureka.ServiceURLs {
+			if !isHTTPURL(serviceURL) {
+				problem("eureka.serviceURLs %q must be an http or https URL", serviceURL)
+			}
+		}
+	case "consul":
+		if !isHTTPURL(c.Consul.Address) {
+			problem("consul.address %q must be an http or https URL", c.Consul.Address)
+		}
+
+		if c.Consul.ServiceName == "" || c.Consul.ServiceAddress == "" {
+			problem("consul.serviceName and consul.serviceAddress must be set to register with consul")
+		}
+	default:
+		problem("registry.type %q must be eureka, consul or none", c.Registry.Type)
+	}
+
+	if c.Consul.ServicePort < 0 || c.Consul.ServicePort > 65535 {
+		problem("consul.servicePort %d must be between 1 and 65535", c.Consul.ServicePort)
+	}
+
+	if c.Consul.CheckTTL < 0 || c.Consul.DeregisterCriticalServiceAfter < 0 {
+		problem("consul durations must not be negative")
+	}
+
+	if c.Limits.RequestTimeout < 0 {
+		problem("limits.requestTimeout must not be negative")
+	}
+
+	if c.Limits.MaxUploadBytes < 0 {
+		problem("limits.maxUploadBytes must not be negative")
+	}
+
+	return errors.Join(problems...)
+}
+
+// RegistryType returns the service registry the instance is registered with. Without a configured type, Eureka is
+// used when eureka.appIP is set, as before registries could be chosen, and no registry otherwise.
+func (c Config) RegistryType() string {
+	switch {
+	case c.Registry.Type != "":
+		return c.Registry.Type
+	case c.Eureka.AppIP != "":
+		return "eureka"
+	default:
+		return "none"
+	}
+}
+
+// EurekaPort returns the port registered with Eureka: AppPort when it is set, and otherwise the port of the listen
+// address.
+func (c Config) EurekaPort() int {
+	return c.advertisedPort(c.Eureka.AppPort)
+}
+
+// ConsulPort returns the port registered with Consul: ServicePort when it is set, and otherwise the port of the listen
+// address.
+func (c Config) ConsulPort() int {
+	return c.advertisedPort(c.Consul.ServicePort)
+}
+
+func (c Config) advertisedPort(port int) int {
+	if port != 0 {
+		return port
+	}
+
+	_, listenPort, _ := net.SplitHostPort(c.Server.ListenAddress)
+	parsed, _ := strconv.Atoi(listenPort)
+	return parsed
+}
+
+func isHTTPURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,262 @@
+package consul
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCheckTTL                       = 15 * time.Second
+	defaultDeregisterCriticalServiceAfter = time.Minute
+	defaultRetryInterval                  = time.Second
+)
+
+// Config configures a Client. Address is the URL of the Consul agent, and ServiceName, ServiceAddress and ServicePort
+// describe the instance; they are required. ServiceID defaults to <ServiceName>-<ServiceAddress>-<ServicePort>.
+// The instance is registered with a TTL check that Run reports on every third of CheckTTL, as passing unless
+// HealthCheck returns an error. Consul removes the instance once the check has been critical for
+// DeregisterCriticalServiceAfter. Failed requests are retried after RetryInterval, doubling up to a third of CheckTTL.
+type Config struct {
+	Address                        string
+	Token                          string
+	ServiceName                    string
+	ServiceID                      string
+	ServiceAddress                 string
+	ServicePort                    int
+	Tags                           []string
+	Meta                           map[string]string
+	CheckTTL                       time.Duration
+	DeregisterCriticalServiceAfter time.Duration
+	RetryInterval                  time.Duration
+	HealthCheck                    func(ctx context.Context) error
+	HTTPClient                     *http.Client
+}
+
+// Client keeps an instance registered with a Consul agent through its HTTP API.
+type Client struct {
+	config Config
+
+	mutex      sync.Mutex
+	registered bool
+}
+
+// NewClient creates a client for config. The instance is not registered until Register or Run is called.
+func NewClient(config Config) (*Client, error) {
+	if config.Address == "" {
+		return nil, errors.New("the address of the consul agent must be configured")
+	}
+
+	if config.ServiceName == "" || config.ServiceAddress == "" || config.ServicePort <= 0 {
+		return nil, errors.New("the name, address and port of the service must be configured")
+	}
+
+	config.Address = strings.TrimSuffix(config.Address, "/")
+	if config.ServiceID == "" {
+		config.ServiceID = fmt.Sprintf("%s-%s-%d", config.ServiceName, config.ServiceAddress, config.ServicePort)
+	}
+
+	if config.CheckTTL <= 0 {
+		config.CheckTTL = defaultCheckTTL
+	}
+
+	if config.DeregisterCriticalServiceAfter <= 0 {
+		config.DeregisterCriticalServiceAfter = defaultDeregisterCriticalServiceAfter
+	}
+
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = defaultRetryInterval
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &Client{config: config}, nil
+}
+
+// ServiceID returns the ID the instance is registered under.
+func (c *Client) ServiceID() string {
+	return c.config.ServiceID
+}
+
+// Register registers the instance and its TTL check with the agent. Registering again replaces the registration.
+func (c *Client) Register(ctx context.Context) error {
+	body, err := json.Marshal(c.registration())
+	if err != nil {
+		return err
+	}
+
+	if err := c.send(ctx, "/v1/agent/service/register", body); err != nil {
+		return fmt.Errorf("failed to register with consul: %w", err)
+	}
+
+	c.setRegistered(true)
+	return nil
+}
+
+// Run registers the instance and reports the result of the health check to its TTL check until ctx is done. Failed
+// requests are retried with a growing delay, registering the instance again in case the agent lost it.
+func (c *Client) Run(ctx context.Context) {
+	interval := c.config.CheckTTL / 3
+	retryDelay := min(c.config.RetryInterval, interval)
+	for {
+		delay := interval
+		if err := c.report(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			fmt.Println(err.Error())
+			c.setRegistered(false)
+			delay, retryDelay = retryDelay, min(2*retryDelay, interval)
+		} else {
+			retryDelay = min(c.config.RetryInterval, interval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Deregister removes the instance and its check from the agent. It does nothing when the instance is not registered.
+func (c *Client) Deregister(ctx context.Context) error {
+	c.mutex.Lock()
+	registered := c.registered
+	c.registered = false
+	c.mutex.Unlock()
+
+	if !registered {
+		return nil
+	}
+
+	if err := c.send(ctx, "/v1/agent/service/deregister/"+url.PathEscape(c.config.ServiceID), nil); err != nil {
+		return fmt.Errorf("failed to deregister from consul: %w", err)
+	}
+
+	return nil
+}
+
+// report registers the instance when it is not registered, and updates its TTL check with the health check's result.
+func (c *Client) report(ctx context.Context) error {
+	c.mutex.Lock()
+	registered := c.registered
+	c.mutex.Unlock()
+
+	if !registered {
+		if err := c.Register(ctx); err != nil {
+			return err
+		}
+	}
+
+	update := checkUpdate{Status: "passing", Output: "healthy"}
+	if c.config.HealthCheck != nil {
+		if err := c.config.HealthCheck(ctx); err != nil {
+			update = checkUpdate{Status: "critical", Output: err.Error()}
+		}
+	}
+
+	body, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+
+	if err := c.send(ctx, "/v1/agent/check/update/"+url.PathEscape(c.checkID()), body); err != nil {
+		return fmt.Errorf("failed to update the consul check: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) setRegistered(registered bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.registered = registered
+}
+
+func (c *Client) checkID() string {
+	return "service:" + c.config.ServiceID
+}
+
+// send sends a PUT request to the agent, which is the method of every endpoint the client uses.
+func (c *Client) send(ctx context.Context, path string, body []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, c.config.Address+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.config.Token != "" {
+		request.Header.Set("X-Consul-Token", c.config.Token)
+	}
+
+	response, err := c.config.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("PUT %s returned %s: %s", path, response.Status, strings.TrimSpace(string(message)))
+	}
+
+	_, _ = io.Copy(io.Discard, response.Body)
+	return nil
+}
+
+// registration is the body of /v1/agent/service/register.
+type registration struct {
+	ID      string            `json:"ID"`
+	Name    string            `json:"Name"`
+	Address string            `json:"Address"`
+	Port    int               `json:"Port"`
+	Tags    []string          `json:"Tags,omitempty"`
+	Meta    map[string]string `json:"Meta,omitempty"`
+	Check   check             `json:"Check"`
+}
+
+type check struct {
+	CheckID                        string `json:"CheckID"`
+	Name                           string `json:"Name"`
+	TTL                            string `json:"TTL"`
+	DeregisterCriticalServiceAfter string `json:"DeregisterCriticalServiceAfter"`
+}
+
+// checkUpdate is the body of /v1/agent/check/update/:check_id.
+type checkUpdate struct {
+	Status string `json:"Status"`
+	Output string `json:"Output"`
+}
+
+func (c *Client) registration() registration {
+	return registration{
+		ID:      c.config.ServiceID,
+		Name:    c.config.ServiceName,
+		Address: c.config.ServiceAddress,
+		Port:    c.config.ServicePort,
+		Tags:    c.config.Tags,
+		Meta:    c.config.Meta,
+		Check: check{
+			CheckID:                        c.checkID(),
+			Name:                           c.config.ServiceName + " health",
+			TTL:                            c.config.CheckTTL.String(),
+			DeregisterCriticalServiceAfter: c.config.DeregisterCriticalServiceAfter.String(),
+		},
+	}
+}
@@ -0,0 +1,228 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"pdf_service_api/consul"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAgent is a Consul agent keeping services and the status of their checks in memory. failures makes the next
+// requests fail with a 500.
+type fakeAgent struct {
+	mutex    sync.Mutex
+	services map[string]map[string]any
+	checks   map[string]string
+	tokens   []string
+	failures int
+}
+
+func newFakeAgent(t *testing.T) (*fakeAgent, *httptest.Server) {
+	fake := &fakeAgent{services: make(map[string]map[string]any), checks: make(map[string]string)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeAgent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.tokens = append(f.tokens, r.Header.Get("X-Consul-Token"))
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if f.failures > 0 {
+		f.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	switch path := r.URL.Path; {
+	case path == "/v1/agent/service/register":
+		var service map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&service); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		f.services[service["ID"].(string)] = service
+		check := service["Check"].(map[string]any)
+		f.checks[check["CheckID"].(string)] = "critical"
+	case strings.HasPrefix(path, "/v1/agent/check/update/"):
+		checkID := strings.TrimPrefix(path, "/v1/agent/check/update/")
+		if _, ok := f.checks[checkID]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var update struct{ Status string }
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.checks[checkID] = update.Status
+	case strings.HasPrefix(path, "/v1/agent/service/deregister/"):
+		serviceID := strings.TrimPrefix(path, "/v1/agent/service/deregister/")
+		delete(f.services, serviceID)
+		delete(f.checks, "service:"+serviceID)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeAgent) service(id string) (map[string]any, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	service, ok := f.services[id]
+	return service, ok
+}
+
+func (f *fakeAgent) check(id string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.checks[id]
+}
+
+func (f *fakeAgent) restart() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.services = make(map[string]map[string]any)
+	f.checks = make(map[string]string)
+}
+
+func newClient(t *testing.T, config consul.Config) *consul.Client {
+	config.ServiceName = "pdf-service"
+	config.ServiceAddress = "10.0.0.1"
+	config.ServicePort = 8080
+	config.CheckTTL = 30 * time.Millisecond
+	config.RetryInterval = 2 * time.Millisecond
+
+	client, err := consul.NewClient(config)
+	require.NoError(t, err)
+	return client
+}
+
+func runClient(t *testing.T, client *consul.Client) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.Run(ctx)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestNewClientRequiresService(t *testing.T) {
+	_, err := consul.NewClient(consul.Config{ServiceName: "pdf-service", ServiceAddress: "10.0.0.1", ServicePort: 8080})
+	assert.Error(t, err, "the agent address is required")
+
+	_, err = consul.NewClient(consul.Config{Address: "http://localhost:8500", ServiceName: "pdf-service"})
+	assert.Error(t, err, "the service must be described")
+}
+
+func TestRegisterSendsService(t *testing.T) {
+	fake, server := newFakeAgent(t)
+	client := newClient(t, consul.Config{
+		Address: server.URL + "/",
+		Token:   "secret",
+		Tags:    []string{"pdf"},
+		Meta:    map[string]string{"version": "1.2.3"},
+	})
+
+	require.NoError(t, client.Register(context.Background()))
+
+	assert.Equal(t, "pdf-service-10.0.0.1-8080", client.ServiceID())
+	service, ok := fake.service(client.ServiceID())
+	require.True(t, ok)
+	assert.Equal(t, "pdf-service", service["Name"])
+	assert.Equal(t, "10.0.0.1", service["Address"])
+	assert.Equal(t, float64(8080), service["Port"])
+	assert.Equal(t, []any{"pdf"}, service["Tags"])
+	assert.Equal(t, map[string]any{"version": "1.2.3"}, service["Meta"])
+	assert.Equal(t, "30ms", service["Check"].(map[string]any)["TTL"])
+	assert.Equal(t, []string{"secret"}, fake.tokens)
+}
+
+func TestRunReportsHealthCheck(t *testing.T) {
+	fake, server := newFakeAgent(t)
+
+	var mutex sync.Mutex
+	var healthErr error
+	client := newClient(t, consul.Config{
+		Address: server.URL,
+		HealthCheck: func(ctx context.Context) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return healthErr
+		},
+	})
+	runClient(t, client)
+
+	checkID := "service:" + client.ServiceID()
+	require.Eventually(t, func() bool { return fake.check(checkID) == "passing" }, 2*time.Second, 2*time.Millisecond)
+
+	mutex.Lock()
+	healthErr = errors.New("the database cannot be reached")
+	mutex.Unlock()
+	require.Eventually(t, func() bool { return fake.check(checkID) == "critical" }, 2*time.Second, 2*time.Millisecond)
+
+	mutex.Lock()
+	healthErr = nil
+	mutex.Unlock()
+	require.Eventually(t, func() bool { return fake.check(checkID) == "passing" }, 2*time.Second, 2*time.Millisecond)
+}
+
+func TestRunRegistersAgainAfterAgentRestart(t *testing.T) {
+	fake, server := newFakeAgent(t)
+	client := newClient(t, consul.Config{Address: server.URL})
+	runClient(t, client)
+
+	checkID := "service:" + client.ServiceID()
+	require.Eventually(t, func() bool { return fake.check(checkID) == "passing" }, 2*time.Second, 2*time.Millisecond)
+
+	fake.restart()
+	require.Eventually(t, func() bool { return fake.check(checkID) == "passing" }, 2*time.Second, 2*time.Millisecond)
+	_, ok := fake.service(client.ServiceID())
+	assert.True(t, ok)
+}
+
+func TestRunRetriesFailedRequests(t *testing.T) {
+	fake, server := newFakeAgent(t)
+	fake.failures = 3
+	client := newClient(t, consul.Config{Address: server.URL})
+	runClient(t, client)
+
+	require.Eventually(t, func() bool { return fake.check("service:"+client.ServiceID()) == "passing" }, 2*time.Second, 2*time.Millisecond)
+}
+
+func TestDeregister(t *testing.T) {
+	fake, server := newFakeAgent(t)
+	client := newClient(t, consul.Config{Address: server.URL})
+
+	require.NoError(t, client.Deregister(context.Background()), "deregistering an unregistered service does nothing")
+	assert.Empty(t, fake.tokens)
+
+	require.NoError(t, client.Register(context.Background()))
+	require.NoError(t, client.Deregister(context.Background()))
+
+	_, ok := fake.service(client.ServiceID())
+	assert.False(t, ok)
+}
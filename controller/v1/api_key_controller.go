@@ -0,0 +1,173 @@
+package v1
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"pdf_service_api/models"
+	"slices"
+)
+
+// apiKeyPrefixLength is the number of characters of a key stored in the clear, so owners can tell their keys apart.
+const apiKeyPrefixLength = len(apiKeyPrefix) + 4
+
+// APIKeyController manages the API keys services use to call the API on behalf of an owner.
+type APIKeyController struct {
+	APIKeyRepository models.APIKeyRepository
+}
+
+// CreateAPIKey handles the HTTP POST request to create an API key for the caller.
+// It expects a CreateAPIKeyRequest naming the key and the permissions it is given, which must be taken from
+// models.APIKeyPermissions. The full key is only returned in this response; afterwards only its prefix is shown.
+//
+// @Summary Create an API key
+// @Description Creates an API key acting for the caller with the given permissions. The key is only shown once.
+// @Tags keys
+// @Accept json
+// @Produce json
+// @Param ownerUUID query string false "The UUID of the owner of the key, only read when authentication is disabled"
+// @Param request body CreateAPIKeyRequest true "The name and permissions of the key"
+// @Success 200 {object} CreateAPIKeyResponse "The created key"
+// @Failure 400 {object} object{error=string} "Bad Request: Missing name or unknown permission."
+// @Failure 403 {object} object{error=string} "Forbidden: API keys cannot manage API keys."
+// @Failure 500 {object} object{error=string} "Internal Server Error: An unexpected error occurred on the server."
+// @Security BearerAuth
+// @Router /keys [post]
+func (t APIKeyController) CreateAPIKey(c *gin.Context) {
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request := CreateAPIKeyRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if request.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name was not present"})
+		return
+	}
+
+	if len(request.Permissions) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "permissions were not present"})
+		return
+	}
+
+	for _, permission := range request.Permissions {
+		if !slices.Contains(models.APIKeyPermissions, permission) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown permission " + permission})
+			return
+		}
+	}
+
+	key, err := GenerateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var ownerType *int
+	if identity, ok := IdentityFromContext(c); ok {
+		ownerType = identity.OwnerType
+	}
+
+	permissions := slices.Compact(slices.Sorted(slices.Values(request.Permissions)))
+	apiKey := models.APIKey{
+		Uuid:        uuid.New(),
+		OwnerUUID:   ownerUid,
+		OwnerType:   ownerType,
+		Name:        request.Name,
+		Prefix:      key[:apiKeyPrefixLength],
+		Permissions: permissions,
+	}
+
+	if err := t.APIKeyRepository.CreateAPIKey(c.Request.Context(), apiKey, HashAPIKey(key)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, CreateAPIKeyResponse{APIKey: apiKey, Key: key})
+}
+
+// GetAPIKeys handles the HTTP GET request to list the caller's API keys, newest first, without the keys themselves.
+//
+// @Summary List API keys
+// @Description Lists the caller's API keys. Only the prefix of each key is returned.
+// @Tags keys
+// @Produce json
+// @Param ownerUUID query string false "The UUID of the owner of the keys, only read when authentication is disabled"
+// @Success 200 {object} object{apiKeys=[]models.APIKey} "The caller's keys"
+// @Failure 400 {object} object{error=string} "Bad Request: Invalid or missing owner UUID."
+// @Failure 403 {object} object{error=string} "Forbidden: API keys cannot manage API keys."
+// @Failure 500 {object} object{error=string} "Internal Server Error: An unexpected error occurred on the server."
+// @Security BearerAuth
+// @Router /keys [get]
+func (t APIKeyController) GetAPIKeys(c *gin.Context) {
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	keys, err := t.APIKeyRepository.GetAPIKeys(c.Request.Context(), ownerUid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"apiKeys": keys})
+}
+
+// RevokeAPIKey handles the HTTP DELETE request to revoke one of the caller's API keys. Requests made with the key
+// are rejected as soon as it has been revoked.
+//
+// @Summary Revoke an API key
+// @Description Revokes one of the caller's API keys.
+// @Tags keys
+// @Produce json
+// @Param keyUUID path string true "The UUID of the key to revoke"
+// @Param ownerUUID query string false "The UUID of the owner of the key, only read when authentication is disabled"
+// @Success 200 {object} map[string]bool "Successful revocation"
+// @Failure 400 {object} object{error=string} "Bad Request: Invalid UUID format or missing owner UUID."
+// @Failure 403 {object} object{error=string} "Forbidden: API keys cannot manage API keys."
+// @Failure 404 {object} object{error=string} "Not Found: The caller has no key with the given UUID."
+// @Failure 500 {object} object{error=string} "Internal Server Error: An unexpected error occurred on the server."
+// @Security BearerAuth
+// @Router /keys/{keyUUID} [delete]
+func (t APIKeyController) RevokeAPIKey(c *gin.Context) {
+	keyUid, err := uuid.Parse(c.Param("keyUUID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := t.APIKeyRepository.RevokeAPIKey(c.Request.Context(), keyUid, ownerUid); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key with keyUUID " + keyUid.String() + " was not found."})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+func (t APIKeyController) SetupRouter(c *gin.RouterGroup) {
+	c.Use(requirePermission(models.PermissionKeysManage))
+	c.POST("/", t.CreateAPIKey)
+	c.GET("/", t.GetAPIKeys)
+	c.DELETE("/:keyUUID", t.RevokeAPIKey)
+}
@@ -0,0 +1,409 @@
+package v1
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"pdf_service_api/models"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const identityContextKey = "pdf_service_api/identity"
+
+// apiKeyHeader is the header API keys are sent in, as an alternative to a bearer token.
+const apiKeyHeader = "X-API-Key"
+
+// apiKeyPrefix starts every API key, making keys easy to recognise, for example by secret scanners.
+const apiKeyPrefix = "pdfsvc_"
+
+// jwksRefreshInterval limits how often a JWKS URL is fetched again when a token names a key that is not known yet.
+const jwksRefreshInterval = time.Minute
+
+// Identity is the authenticated caller of a request, taken from the claims of its bearer token or from its API key.
+// Permissions is nil for bearer tokens, which hold every permission.
+type Identity struct {
+	OwnerUUID   uuid.UUID
+	OwnerType   *int
+	Permissions []string
+}
+
+// HasPermission reports whether the identity holds permission.
+func (i Identity) HasPermission(permission string) bool {
+	return i.Permissions == nil || slices.Contains(i.Permissions, permission)
+}
+
+// JWTConfig configures the middleware created by NewJWTMiddleware.
+// HMACSecret enables HS256 tokens and JWKSFile or JWKSURL enable RS256 tokens; at least one must be set.
+// OwnerClaim and OwnerTypeClaim default to "sub" and "owner_type".
+type JWTConfig struct {
+	HMACSecret     []byte
+	JWKSFile       string
+	JWKSURL        string
+	Issuer         string
+	Audience       string
+	OwnerClaim     string
+	OwnerTypeClaim string
+	HTTPClient     *http.Client
+}
+
+// NewJWTMiddleware creates a gin middleware that rejects requests without a valid bearer token, and stores the
+// identity derived from the token's claims for the handlers. Tokens must carry an expiry, and the owner claim must
+// hold the owner's UUID. Requests already authenticated by an earlier middleware, such as the one returned by
+// NewAPIKeyMiddleware, are passed through.
+func NewJWTMiddleware(config JWTConfig) (gin.HandlerFunc, error) {
+	if config.OwnerClaim == "" {
+		config.OwnerClaim = "sub"
+	}
+
+	if config.OwnerTypeClaim == "" {
+		config.OwnerTypeClaim = "owner_type"
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	methods := make([]string, 0)
+	if len(config.HMACSecret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+
+	var keys *jwksKeySet
+	switch {
+	case config.JWKSFile != "" && config.JWKSURL != "":
+		return nil, errors.New("only one of a JWKS file or a JWKS URL can be configured")
+	case config.JWKSFile != "":
+		keys = &jwksKeySet{load: func() ([]byte, error) { return os.ReadFile(config.JWKSFile) }}
+	case config.JWKSURL != "":
+		keys = &jwksKeySet{load: fetchJWKS(config.HTTPClient, config.JWKSURL), refreshable: true}
+	}
+
+	if keys != nil {
+		if err := keys.refresh(); err != nil {
+			return nil, fmt.Errorf("failed to load JWKS: %w", err)
+		}
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	if len(methods) == 0 {
+		return nil, errors.New("an HMAC secret, JWKS file or JWKS URL must be configured")
+	}
+
+	keyFunc := func(token *jwt.Token) (any, error) {
+		switch token.Method.Alg() {
+		case jwt.SigningMethodHS256.Alg():
+			return config.HMACSecret, nil
+		case jwt.SigningMethodRS256.Alg():
+			kid, _ := token.Header["kid"].(string)
+			return keys.key(kid)
+		default:
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+	}
+
+	options := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+	parser := jwt.NewParser(options...)
+
+	return func(c *gin.Context) {
+		if _, ok := IdentityFromContext(c); ok {
+			c.Next()
+			return
+		}
+
+		tokenString, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || tokenString == "" {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "A bearer token is required"})
+			return
+		}
+
+		claims := jwt.MapClaims{}
+		if _, err := parser.ParseWithClaims(tokenString, claims, keyFunc); err != nil {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		identity, err := identityFromClaims(claims, config.OwnerClaim, config.OwnerTypeClaim)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(identityContextKey, identity)
+		c.Next()
+	}, nil
+}
+
+// NewAPIKeyMiddleware creates a gin middleware that authenticates requests carrying an X-API-Key header, storing the
+// key's owner and permissions as the identity for the handlers. Requests with an unknown key are rejected, while
+// requests without the header are passed on, so the middleware is placed before NewJWTMiddleware to accept either.
+func NewAPIKeyMiddleware(repository models.APIKeyRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(apiKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		apiKey, err := repository.AuthenticateAPIKey(c.Request.Context(), HashAPIKey(key))
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "The API key is not valid"})
+			default:
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		permissions := apiKey.Permissions
+		if permissions == nil {
+			permissions = make([]string, 0)
+		}
+
+		c.Set(identityContextKey, Identity{OwnerUUID: apiKey.OwnerUUID, OwnerType: apiKey.OwnerType, Permissions: permissions})
+		c.Next()
+	}
+}
+
+// requirePermission creates a gin middleware that rejects callers without permission with a 403 Forbidden.
+// Requests without an identity, which are only accepted when authentication is disabled, are passed on.
+func requirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if identity, ok := IdentityFromContext(c); ok && !identity.HasPermission(permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "The " + permission + " permission is required"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// GenerateAPIKey returns a new random API key.
+func GenerateAPIKey() (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(random), nil
+}
+
+// HashAPIKey returns the hash an API key is stored and looked up by. Keys are long and random, so a single SHA-256
+// round is enough and keeps the lookup on every request cheap.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IdentityFromContext returns the identity stored by the authentication middleware, if any.
+func IdentityFromContext(c *gin.Context) (Identity, bool) {
+	value, exists := c.Get(identityContextKey)
+	if !exists {
+		return Identity{}, false
+	}
+
+	identity, ok := value.(Identity)
+	return identity, ok
+}
+
+// requestOwner returns the UUID of the owner a request acts for. When the router authenticates requests this is
+// always the caller's identity; the ownerUUID query parameter is only read when authentication is disabled.
+func requestOwner(c *gin.Context) (uuid.UUID, error) {
+	if identity, ok := IdentityFromContext(c); ok {
+		return identity.OwnerUUID, nil
+	}
+
+	ownerUidStr, isPresent := c.GetQuery("ownerUUID")
+	if !isPresent {
+		return uuid.Nil, errors.New("Required OwnerUuid is missing")
+	}
+
+	return uuid.Parse(ownerUidStr)
+}
+
+// uploadOwner returns the owner and owner type to store with a new document, preferring the caller's identity over
+// the values supplied by the client.
+func uploadOwner(c *gin.Context, ownerUUID *uuid.UUID, ownerType *int) (*uuid.UUID, *int) {
+	if identity, ok := IdentityFromContext(c); ok {
+		return &identity.OwnerUUID, identity.OwnerType
+	}
+
+	return ownerUUID, ownerType
+}
+
+func identityFromClaims(claims jwt.MapClaims, ownerClaim, ownerTypeClaim string) (Identity, error) {
+	owner, ok := claims[ownerClaim].(string)
+	if !ok {
+		return Identity{}, fmt.Errorf("token is missing the %s claim", ownerClaim)
+	}
+
+	ownerUUID, err := uuid.Parse(owner)
+	if err != nil {
+		return Identity{}, fmt.Errorf("the %s claim is not a UUID", ownerClaim)
+	}
+
+	identity := Identity{OwnerUUID: ownerUUID}
+	if value, present := claims[ownerTypeClaim]; present {
+		number, ok := value.(float64)
+		if !ok || number != float64(int(number)) {
+			return Identity{}, fmt.Errorf("the %s claim is not an integer", ownerTypeClaim)
+		}
+
+		ownerType := int(number)
+		identity.OwnerType = &ownerType
+	}
+
+	return identity, nil
+}
+
+// jwksKeySet holds the RSA keys of a JSON Web Key Set. Keys loaded from a URL are fetched again when a token names
+// a key that is not known, so keys can be rotated without restarting the service.
+type jwksKeySet struct {
+	load        func() ([]byte, error)
+	refreshable bool
+
+	mutex       sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	lastRefresh time.Time
+}
+
+func (s *jwksKeySet) key(kid string) (*rsa.PublicKey, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+
+	if s.refreshable && time.Since(s.lastRefresh) > jwksRefreshInterval {
+		if err := s.refreshLocked(); err != nil {
+			return nil, err
+		}
+
+		if key, ok := s.lookup(kid); ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no key found for kid %q", kid)
+}
+
+// lookup finds the key with the given id. Tokens without a kid can only be verified when the set holds a single key.
+func (s *jwksKeySet) lookup(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (s *jwksKeySet) refresh() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.refreshLocked()
+}
+
+func (s *jwksKeySet) refreshLocked() error {
+	s.lastRefresh = time.Now()
+
+	data, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	s.keys = keys
+	return nil
+}
+
+func fetchJWKS(client *http.Client, url string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		response, err := client.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetching %s returned %s", url, response.Status)
+		}
+
+		return io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	}
+}
+
+// parseJWKS reads the RSA signing keys of a JSON Web Key Set, ignoring keys of other types.
+func parseJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+	set := struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}{}
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %q has an invalid modulus: %w", key.Kid, err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return nil, fmt.Errorf("key %q has an invalid exponent: %w", key.Kid, err)
+		}
+
+		keys[key.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("the key set contains no RSA signing keys")
+	}
+
+	return keys, nil
+}
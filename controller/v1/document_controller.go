@@ -1,35 +1,73 @@
 package v1
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"io"
+	"mime"
 	"net/http"
+	"os"
+	"path/filepath"
 	"pdf_service_api/models"
+	"pdf_service_api/pdf"
 	"slices"
 	"strconv"
+	"strings"
+	"time"
 )
 
+const mimePDF = "application/pdf"
+
+// The default resolution of page images, in dots per inch, and the resolution used for thumbnails.
+const (
+	defaultRenderDPI = 96
+	thumbnailDPI     = 24
+)
+
+// renderDPIs are the resolutions page images can be rendered at. Only a few are accepted, so each page has a bounded
+// number of cached images.
+var renderDPIs = []int{thumbnailDPI, 72, defaultRenderDPI, 150, 300}
+
+// pdfHeader is the signature every PDF file starts with.
+var pdfHeader = []byte("%PDF-")
+
 // DocumentController injects the dependencies required for the controller implementations to operate.
+// MetaRepository is optional; when it is set, metadata is extracted from every uploaded PDF and stored alongside it.
+// GrantRepository is optional too; the endpoints sharing documents are only registered when it is set. Page images are
+// only rendered, and referenced as thumbnails in metadata, when Renderer is set.
 type DocumentController struct {
-	DocumentRepository models.DocumentRepository
+	DocumentRepository  models.DocumentRepository
+	MetaRepository      models.MetaRepository
+	PageWordsRepository models.PageWordsRepository
+	PageImageRepository models.PageImageRepository
+	GrantRepository     models.GrantRepository
+	Renderer            *pdf.Renderer
 }
 
 // GetDocumentHandler
 // @Summary Get documents
 // @Description Retrieves document details. Documents can be fetched either by their unique Document UUID or by an Owner UUID.
 // @Description Optional exclusion parameters can be used to omit specific fields from the response.
+// @Description Documents shared with the owner can be fetched by their Document UUID, and are included in the owner's list when shared is true.
 // @Tags documents
 // @Accept json
 // @Produce json
 // @Param documentUUID query string false "The unique identifier of the document to retrieve. If provided"
-// @Param ownerUUID query string true "The unique identifier of the owner whose documents are to be retrieved."
+// @Param ownerUUID query string false "The unique identifier of the owner whose documents are to be retrieved, only read when authentication is disabled"
+// @Param shared query bool false "When listing by owner, also include the documents shared with the owner."
 // @Param exclude query []string false "Fields to exclude from the response. Allowed values: `documentTitle`, `timeCreated`, `ownerUUID`, `ownerType`, `pdfBase64`." collectionFormat(multi)
 // @Success 200 {object} object{documents=[]models.Document} "Successfully retrieved document(s)."
 // @Failure 400 {object} object{error=string} "Bad Request: Invalid UUID format or no valid parameters specified."
 // @Failure 404 {object} object{error=string} "Not Found: No document(s) found for the given UUID."
 // @Failure 500 {object} object{error=string} "Internal Server Error: An unexpected error occurred on the server."
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /documents [get]
 func (t DocumentController) GetDocumentHandler(c *gin.Context) {
 	exclude := make(map[string]bool)
@@ -75,15 +113,19 @@ func (t DocumentController) GetDocumentHandler(c *gin.Context) {
 		offset = int8(number)
 	}
 
-	documentUidStr, isDocumentUuidPresent := c.GetQuery("documentUUID")
-	ownerUidStr, isOwnerUuidPresent := c.GetQuery("ownerUUID")
+	includeShared := false
+	if value, present := c.GetQuery("shared"); present {
+		shared, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "shared must be true or false"})
+			return
+		}
 
-	if !isOwnerUuidPresent {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Required OwnerUuid is missing"})
-		return
+		includeShared = shared
 	}
 
-	ownerUid, err := uuid.Parse(ownerUidStr)
+	documentUidStr, isDocumentUuidPresent := c.GetQuery("documentUUID")
+	ownerUid, err := requestOwner(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -96,7 +138,7 @@ func (t DocumentController) GetDocumentHandler(c *gin.Context) {
 			return
 		}
 
-		document, err := t.DocumentRepository.GetDocumentByDocumentUUID(documentUid, ownerUid, exclude)
+		document, err := t.DocumentRepository.GetDocumentByDocumentUUID(c.Request.Context(), documentUid, ownerUid, exclude)
 		if err != nil {
 			switch {
 			case errors.Is(err, sql.ErrNoRows):
@@ -112,7 +154,7 @@ func (t DocumentController) GetDocumentHandler(c *gin.Context) {
 		return
 	}
 
-	documents, err := t.DocumentRepository.GetDocumentByOwnerUUID(ownerUid, limit, offset, exclude)
+	documents, err := t.DocumentRepository.GetDocumentByOwnerUUID(c.Request.Context(), ownerUid, limit, offset, includeShared, exclude)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -129,23 +171,51 @@ func (t DocumentController) GetDocumentHandler(c *gin.Context) {
 }
 
 // UploadDocumentHandler handles the HTTP POST request to upload a new document.
-// It expects a JSON request body conforming to the CreateRequest struct,
-// which should contain the document's base64 encoded string.
+// The request body can be supplied in one of three forms, selected by the Content-Type header:
+//
+//   - application/json: a CreateRequest containing the document's base64 encoded string.
+//   - multipart/form-data: a "file" part holding the PDF, with optional documentTitle, ownerUUID and ownerType fields.
+//   - application/pdf: the raw PDF bytes as the body, with optional documentTitle, ownerUUID and ownerType query parameters.
+//
+// When the request is authenticated, the owner and owner type are taken from the caller's token and any values
+// supplied by the client are ignored.
+//
+// Binary uploads are streamed into the repository rather than being buffered into a base64 string first.
+// When the controller has a MetaRepository, the page count and page size are extracted from the uploaded PDF
+// and stored as the document's metadata, which clients can later adjust through the meta endpoints.
 //
 // Upon successful upload, it returns a 200 OK status with the UUID of the
 // newly created document. If there's an error during request binding or
 // document upload, it returns a 400 Bad Request status with an error message.
 //
 // @Summary Upload a new document
-// @Description Uploads a document by receiving its base64 encoded string in the request body.
+// @Description Uploads a document either as a JSON body containing its base64 encoded string, as a multipart form
+// @Description containing a "file" part, or as a raw application/pdf body. Metadata is extracted from the PDF automatically.
 // @Tags documents
 // @Accept  json
+// @Accept  mpfd
+// @Accept  application/pdf
 // @Produce  json
-// @Param   request body v1.CreateRequest true "Document upload request"
+// @Param   request body v1.CreateRequest false "Document upload request, used when the Content-Type is application/json"
+// @Param   file formData file false "The PDF file, used when the Content-Type is multipart/form-data"
+// @Param   documentTitle formData string false "The title of the document, used when the Content-Type is multipart/form-data"
+// @Param   ownerUUID formData string false "The UUID of the document owner, used when the Content-Type is multipart/form-data and authentication is disabled"
+// @Param   ownerType formData integer false "The type of the document owner, used when the Content-Type is multipart/form-data and authentication is disabled"
 // @Success 200 {object} map[string]string "Successful upload, returns the document UUID"
 // @Failure 400 "Bad request, typically due to invalid input or upload failure"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /documents [post]
 func (t DocumentController) UploadDocumentHandler(c *gin.Context) {
+	switch c.ContentType() {
+	case gin.MIMEMultipartPOSTForm:
+		t.uploadMultipartDocument(c)
+		return
+	case mimePDF:
+		t.uploadRawDocument(c)
+		return
+	}
+
 	body := &CreateRequest{}
 
 	err := c.ShouldBindJSON(body)
@@ -154,24 +224,264 @@ func (t DocumentController) UploadDocumentHandler(c *gin.Context) {
 		return
 	}
 
+	ownerUUID, ownerType := uploadOwner(c, body.OwnerUUID, body.OwnerType)
 	newModel := models.Document{
 		Uuid:          uuid.New(),
 		PdfBase64:     &body.DocumentBase64String,
 		DocumentTitle: body.DocumentTitle,
-		OwnerUUID:     body.OwnerUUID,
-		OwnerType:     body.OwnerType,
+		OwnerUUID:     ownerUUID,
+		OwnerType:     ownerType,
 		SelectionData: nil,
 	}
 
-	err = t.DocumentRepository.UploadDocument(newModel)
+	err = t.DocumentRepository.UploadDocument(c.Request.Context(), newModel)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	if t.MetaRepository != nil && newModel.OwnerUUID != nil {
+		if content, err := base64.StdEncoding.DecodeString(body.DocumentBase64String); err == nil {
+			t.extractMeta(c.Request.Context(), newModel.Uuid, *newModel.OwnerUUID, bytes.NewReader(content), int64(len(content)))
+		}
+	}
+
 	c.JSON(200, gin.H{"documentUUID": newModel.Uuid})
 }
 
+// uploadMultipartDocument reads the PDF from the "file" part of a multipart form, and the document
+// details from the remaining form fields.
+func (t DocumentController) uploadMultipartDocument(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file was not present: " + err.Error()})
+		return
+	}
+
+	details, err := parseUploadDetails(c.GetPostForm)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	if details.DocumentTitle == nil && fileHeader.Filename != "" {
+		title := strings.TrimSuffix(fileHeader.Filename, filepath.Ext(fileHeader.Filename))
+		details.DocumentTitle = &title
+	}
+
+	t.uploadDocumentContent(c, details, file, fileHeader.Size)
+}
+
+// uploadRawDocument reads the PDF directly from the request body, and the document details from the query parameters.
+// The body is spooled to a temporary file so the PDF can be both stored and parsed without holding it in memory.
+func (t DocumentController) uploadRawDocument(c *gin.Context) {
+	details, err := parseUploadDetails(c.GetQuery)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := os.CreateTemp("", "upload-*.pdf")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	size, err := io.Copy(file, c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t.uploadDocumentContent(c, details, file, size)
+}
+
+func (t DocumentController) uploadDocumentContent(c *gin.Context, details UploadDetails, content io.ReaderAt, size int64) {
+	header := make([]byte, len(pdfHeader))
+	if _, err := content.ReadAt(header, 0); err != nil || !bytes.Equal(header, pdfHeader) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "uploaded content is not a PDF document"})
+		return
+	}
+
+	ownerUUID, ownerType := uploadOwner(c, details.OwnerUUID, details.OwnerType)
+	newModel := models.Document{
+		Uuid:          uuid.New(),
+		DocumentTitle: details.DocumentTitle,
+		OwnerUUID:     ownerUUID,
+		OwnerType:     ownerType,
+		SelectionData: nil,
+	}
+
+	err := t.DocumentRepository.UploadDocumentFromReader(c.Request.Context(), newModel, io.NewSectionReader(content, 0, size))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if t.MetaRepository != nil && newModel.OwnerUUID != nil {
+		t.extractMeta(c.Request.Context(), newModel.Uuid, *newModel.OwnerUUID, content, size)
+	}
+
+	c.JSON(200, gin.H{"documentUUID": newModel.Uuid})
+}
+
+// extractMeta parses an uploaded PDF and stores its metadata. Failures are logged rather than returned,
+// as the document itself has already been stored and documents that cannot be parsed are still valid uploads.
+// Metadata belongs to the document's owner, so it is only extracted for documents that have one.
+func (t DocumentController) extractMeta(ctx context.Context, documentUuid, ownerUuid uuid.UUID, content io.ReaderAt, size int64) {
+	meta, err := ExtractDocumentMeta(documentUuid, content, size, t.Renderer != nil)
+	if err != nil {
+		fmt.Printf("Failed to extract meta for document %s: %s\n", documentUuid, err)
+		return
+	}
+
+	if err := t.MetaRepository.AddMeta(ctx, meta, ownerUuid); err != nil {
+		fmt.Printf("Failed to store meta for document %s: %s\n", documentUuid, err)
+	}
+}
+
+// ExtractDocumentMeta extracts the metadata of a PDF. When thumbnails is set, the metadata references the thumbnails of
+// its pages served by this API, so it must only be set where page images are rendered.
+func ExtractDocumentMeta(documentUuid uuid.UUID, content io.ReaderAt, size int64, thumbnails bool) (models.Meta, error) {
+	document, err := pdf.Open(content, size)
+	if err != nil {
+		return models.Meta{}, err
+	}
+
+	meta, err := pdf.ExtractMeta(documentUuid, document)
+	if err != nil {
+		return models.Meta{}, err
+	}
+
+	if thumbnails {
+		setThumbnails(&meta)
+	}
+
+	return meta, nil
+}
+
+// setThumbnails references the thumbnail of every page of the document in its metadata.
+func setThumbnails(meta *models.Meta) {
+	if meta.NumberOfPages == nil {
+		return
+	}
+
+	images := make(map[uint32]string, *meta.NumberOfPages)
+	for page := uint32(1); page <= *meta.NumberOfPages; page++ {
+		images[page] = thumbnailPath(meta.DocumentUUID, page)
+	}
+	meta.Images = &images
+}
+
+// CreateDocumentWithDetailsHandler handles the HTTP POST request to create a document together with its metadata and
+// initial selections. It expects a CreateDocumentWithDetailsRequest, containing the document as a base64 encoded
+// string like UploadDocumentHandler, and optionally its metadata and selections.
+//
+// The document, metadata and selections are created in a single transaction: when any of them cannot be stored,
+// none of them are. When no metadata is given and the controller has a MetaRepository, it is extracted from the PDF.
+// Metadata and selections belong to the document's owner, so they can only be given for a document with an owner.
+//
+// Upon success, it returns a 200 OK status with the UUIDs of the document and of its selections. If the request is
+// invalid or the document cannot be stored, it returns a 400 Bad Request status with an error message.
+//
+// @Summary Create a document with metadata and selections
+// @Description Creates a document along with its metadata and initial selections atomically. Metadata is extracted
+// @Description from the PDF when it is not given.
+// @Tags documents
+// @Accept  json
+// @Produce  json
+// @Param   request body v1.CreateDocumentWithDetailsRequest true "The document, its metadata and its selections"
+// @Success 200 {object} v1.CreateDocumentWithDetailsResponse "Successful creation, returns the document and selection UUIDs"
+// @Failure 400 {object} object{error=string} "Bad Request: Invalid input, or the document could not be stored."
+// @Failure 403 {object} object{error=string} "Forbidden: The caller cannot write documents, metadata and selections."
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /documents/composite [post]
+func (t DocumentController) CreateDocumentWithDetailsHandler(c *gin.Context) {
+	body := &CreateDocumentWithDetailsRequest{}
+	if err := c.ShouldBindJSON(body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	content, err := base64.StdEncoding.DecodeString(body.DocumentBase64String)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "documentBase64String is not valid base64: " + err.Error()})
+		return
+	}
+
+	if !bytes.HasPrefix(content, pdfHeader) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "uploaded content is not a PDF document"})
+		return
+	}
+
+	ownerUUID, ownerType := uploadOwner(c, body.OwnerUUID, body.OwnerType)
+	if ownerUUID == nil && (body.Meta != nil || len(body.Selections) > 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ownerUUID is required to create meta or selections"})
+		return
+	}
+
+	document := models.Document{
+		Uuid:          uuid.New(),
+		DocumentTitle: body.DocumentTitle,
+		OwnerUUID:     ownerUUID,
+		OwnerType:     ownerType,
+	}
+
+	var meta *models.Meta
+	switch {
+	case body.Meta != nil:
+		meta = &models.Meta{
+			DocumentUUID:  document.Uuid,
+			NumberOfPages: body.Meta.NumberOfPages,
+			Height:        body.Meta.Height,
+			Width:         body.Meta.Width,
+			Pages:         body.Meta.Pages,
+		}
+		if t.Renderer != nil {
+			setThumbnails(meta)
+		}
+	case t.MetaRepository != nil && ownerUUID != nil:
+		extracted, err := ExtractDocumentMeta(document.Uuid, bytes.NewReader(content), int64(len(content)), t.Renderer != nil)
+		if err != nil {
+			fmt.Printf("Failed to extract meta for document %s: %s\n", document.Uuid, err)
+			break
+		}
+		meta = &extracted
+	}
+
+	selections := make([]models.Selection, len(body.Selections))
+	selectionUUIDs := make([]uuid.UUID, len(body.Selections))
+	for i, selection := range body.Selections {
+		isComplete := selection.IsComplete
+		selections[i] = models.Selection{
+			Uuid:            uuid.New(),
+			DocumentUUID:    &document.Uuid,
+			IsComplete:      &isComplete,
+			Settings:        selection.Settings,
+			SelectionBounds: selection.SelectionBounds,
+		}
+		selectionUUIDs[i] = selections[i].Uuid
+	}
+
+	err = t.DocumentRepository.UploadDocumentWithDetails(c.Request.Context(), document, bytes.NewReader(content), meta, selections)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, CreateDocumentWithDetailsResponse{DocumentUUID: document.Uuid, SelectionUUIDs: selectionUUIDs})
+}
+
 // DeleteDocumentHandler handles the HTTP DELETE request to delete a document by its UUID.
 // It expects the document's UUID as a query parameter named "documentUUID".
 //
@@ -186,24 +496,20 @@ func (t DocumentController) UploadDocumentHandler(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param   documentUUID query string true "The UUID of the document to delete"
-// @Param   ownerUUID query string true "The UUID of the owner of the document that is getting deleted"
+// @Param   ownerUUID query string false "The UUID of the owner of the document that is getting deleted, only read when authentication is disabled"
 // @Success 200 {object} map[string]bool "Successful deletion"
 // @Failure 400 "Bad request, typically due to missing/invalid UUID or deletion failure"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /documents [delete]
 func (t DocumentController) DeleteDocumentHandler(c *gin.Context) {
-	ownerUuidStr, isPresent := c.GetQuery("ownerUUID")
-	if !isPresent {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ownerUUID was not present"})
-		return
-	}
-
 	documentUuidStr, isPresent := c.GetQuery("documentUUID")
 	if !isPresent {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "documentUUID was not present"})
 		return
 	}
 
-	ownerUuid, err := uuid.Parse(ownerUuidStr)
+	ownerUuid, err := requestOwner(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -215,7 +521,7 @@ func (t DocumentController) DeleteDocumentHandler(c *gin.Context) {
 		return
 	}
 
-	err = t.DocumentRepository.DeleteDocumentById(documentUuid, ownerUuid)
+	err = t.DocumentRepository.DeleteDocumentById(c.Request.Context(), documentUuid, ownerUuid)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -225,9 +531,326 @@ func (t DocumentController) DeleteDocumentHandler(c *gin.Context) {
 	return
 }
 
+// GetDocumentContentHandler handles the HTTP GET request to download a document's raw PDF bytes.
+// It expects the document's UUID as a path parameter, and only returns the document when it can be read by the
+// authenticated owner.
+//
+// The response honours the Range header, so clients such as PDF.js can lazily load parts of large documents.
+//
+// @Summary Download a document's PDF content
+// @Description Streams the raw PDF bytes of a document. Supports Range requests with 206 Partial Content responses.
+// @Tags documents
+// @Produce application/pdf
+// @Param documentUUID path string true "The UUID of the document to download"
+// @Param ownerUUID query string false "The UUID of the owner of the document, only read when authentication is disabled"
+// @Param Range header string false "The byte range to return, for example bytes=0-1023"
+// @Success 200 {file} file "The full PDF document"
+// @Success 206 {file} file "The requested range of the PDF document"
+// @Failure 400 {object} object{error=string} "Bad Request: Invalid UUID format or missing owner UUID."
+// @Failure 404 {object} object{error=string} "Not Found: No document found for the given UUID."
+// @Failure 416 "Requested Range Not Satisfiable"
+// @Failure 500 {object} object{error=string} "Internal Server Error: An unexpected error occurred on the server."
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /documents/{documentUUID}/content [get]
+func (t DocumentController) GetDocumentContentHandler(c *gin.Context) {
+	documentUid, err := uuid.Parse(c.Param("documentUUID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	document, content, err := t.DocumentRepository.GetDocumentContent(c.Request.Context(), documentUid, ownerUid)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Document with documentUUID " + documentUid.String() + " was not found."})
+			return
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	defer content.Close()
+
+	fileName := documentUid.String() + ".pdf"
+	if document.DocumentTitle != nil && *document.DocumentTitle != "" {
+		fileName = *document.DocumentTitle + ".pdf"
+	}
+
+	var modTime time.Time
+	if document.TimeCreated != nil {
+		modTime = *document.TimeCreated
+	}
+
+	c.Header("Content-Type", mimePDF)
+	// FormatMediaType returns an empty string for titles it cannot encode, in which case the filename is left out.
+	disposition := mime.FormatMediaType("inline", map[string]string{"filename": fileName})
+	if disposition == "" {
+		disposition = "inline"
+	}
+	c.Header("Content-Disposition", disposition)
+	http.ServeContent(c.Writer, c.Request, fileName, modTime, content)
+}
+
+// GetPageWordsHandler handles the HTTP GET request to list the words on a page of a document.
+// It expects the document's UUID and the page number, counted from 1, as path parameters, and only returns words
+// of documents the authenticated owner can read.
+//
+// Words are extracted from the stored PDF the first time a page is requested and stored, so later requests
+// are answered from the database. Bounding boxes are in PDF points measured from the bottom left corner of the page,
+// the same coordinates used by selection bounds.
+//
+// @Summary List the words on a page of a document
+// @Description Returns every word on a page with its bounding box, in reading order, so clients can snap selections to words.
+// @Tags documents
+// @Produce json
+// @Param documentUUID path string true "The UUID of the document"
+// @Param pageNumber path int true "The page number, counted from 1"
+// @Param ownerUUID query string false "The UUID of the owner of the document, only read when authentication is disabled"
+// @Success 200 {object} models.PageWords "The words on the page"
+// @Failure 400 {object} object{error=string} "Bad Request: Invalid UUID format, page number or missing owner UUID."
+// @Failure 404 {object} object{error=string} "Not Found: No document or page found for the given UUID and page number."
+// @Failure 422 {object} object{error=string} "Unprocessable Entity: The stored document could not be parsed."
+// @Failure 500 {object} object{error=string} "Internal Server Error: An unexpected error occurred on the server."
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /documents/{documentUUID}/pages/{pageNumber}/words [get]
+func (t DocumentController) GetPageWordsHandler(c *gin.Context) {
+	documentUid, err := uuid.Parse(c.Param("documentUUID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := strconv.Atoi(c.Param("pageNumber"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Page number must be a positive integer"})
+		return
+	}
+
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if t.PageWordsRepository != nil {
+		words, err := t.PageWordsRepository.GetPageWords(c.Request.Context(), documentUid, ownerUid, page)
+		if err == nil {
+			c.JSON(http.StatusOK, words)
+			return
+		}
+
+		if !errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	_, content, err := t.DocumentRepository.GetDocumentContent(c.Request.Context(), documentUid, ownerUid)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Document with documentUUID " + documentUid.String() + " was not found."})
+			return
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	defer content.Close()
+
+	document, err := pdf.OpenReadSeeker(content)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	numPages, err := document.NumPages()
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	if page > numPages {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Page %d was not found, the document has %d pages.", page, numPages)})
+		return
+	}
+
+	textBoxes, err := document.Words(page)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	words := models.PageWords{DocumentUUID: documentUid, PageNumber: page, Words: textBoxes}
+	if t.PageWordsRepository != nil {
+		if err := t.PageWordsRepository.SavePageWords(c.Request.Context(), words); err != nil {
+			fmt.Printf("Failed to store words for page %d of document %s: %s\n", page, documentUid, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, words)
+}
+
+// GetPageImageHandler handles the HTTP GET request to render a page of a document as an image.
+// It expects the document's UUID and the page number, counted from 1, as path parameters, and only renders
+// documents the authenticated owner can read.
+//
+// The optional "dpi" query parameter sets the resolution, one of 24, 72, 96, 150 or 300 with a default of 96, and the
+// optional "format" query parameter selects "png", the default, or "jpeg". Pages are rendered by the Renderer the first
+// time they are requested at a resolution and format, and the image is cached for later requests when a page image
+// repository is configured. Pages whose image would be too large are refused with a 422. The thumbnails referenced
+// by a document's meta are served by this endpoint.
+//
+// @Summary Render a page of a document as an image
+// @Description Renders a page server-side and returns it as a PNG or JPEG image. Rendered images are cached.
+// @Tags documents
+// @Produce png
+// @Produce jpeg
+// @Param documentUUID path string true "The UUID of the document"
+// @Param pageNumber path int true "The page number, counted from 1"
+// @Param ownerUUID query string false "The UUID of the owner of the document, only read when authentication is disabled"
+// @Param dpi query int false "The resolution in dots per inch" Enums(24, 72, 96, 150, 300) default(96)
+// @Param format query string false "The image format" Enums(png, jpeg) default(png)
+// @Success 200 {file} file "The rendered page"
+// @Failure 400 {object} object{error=string} "Bad Request: Invalid UUID format, page number, resolution, format or missing owner UUID."
+// @Failure 404 {object} object{error=string} "Not Found: No document or page found for the given UUID and page number."
+// @Failure 422 {object} object{error=string} "Unprocessable Entity: The stored document could not be rendered, or the image of the page would be too large."
+// @Failure 500 {object} object{error=string} "Internal Server Error: An unexpected error occurred on the server."
+// @Failure 503 {object} object{error=string} "Service Unavailable: The page could not be rendered before the request timed out."
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /documents/{documentUUID}/pages/{pageNumber}/image [get]
+func (t DocumentController) GetPageImageHandler(c *gin.Context) {
+	documentUid, err := uuid.Parse(c.Param("documentUUID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := strconv.Atoi(c.Param("pageNumber"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Page number must be a positive integer"})
+		return
+	}
+
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dpi, err := strconv.Atoi(c.DefaultQuery("dpi", strconv.Itoa(defaultRenderDPI)))
+	if err != nil || !slices.Contains(renderDPIs, dpi) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("dpi must be one of %v", renderDPIs)})
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", pdf.ImageFormatPNG))
+	if format == "jpg" {
+		format = pdf.ImageFormatJPEG
+	}
+
+	contentType, err := pdf.ImageContentType(format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if t.PageImageRepository != nil {
+		_, cached, err := t.PageImageRepository.GetPageImage(c.Request.Context(), documentUid, ownerUid, page, dpi, format)
+		if err == nil {
+			defer cached.Close()
+			c.Header("Content-Type", contentType)
+			http.ServeContent(c.Writer, c.Request, "", time.Time{}, cached)
+			return
+		}
+
+		if !errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	_, content, err := t.DocumentRepository.GetDocumentContent(c.Request.Context(), documentUid, ownerUid)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Document with documentUUID " + documentUid.String() + " was not found."})
+			return
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	defer content.Close()
+
+	img, err := t.Renderer.RenderPage(c.Request.Context(), content, page, float64(dpi))
+	if err != nil {
+		switch {
+		case errors.Is(err, pdf.ErrPageOutOfRange):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, pdf.ErrRendererUnavailable):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "The page could not be rendered in time, try again later."})
+			return
+		default:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	encoded := &bytes.Buffer{}
+	if err := pdf.EncodeImage(encoded, img, format); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if t.PageImageRepository != nil {
+		image := models.PageImage{DocumentUUID: documentUid, PageNumber: page, Dpi: dpi, Format: format}
+		if err := t.PageImageRepository.SavePageImage(c.Request.Context(), image, bytes.NewReader(encoded.Bytes())); err != nil {
+			fmt.Printf("Failed to store image of page %d of document %s: %s\n", page, documentUid, err)
+		}
+	}
+
+	c.Data(http.StatusOK, contentType, encoded.Bytes())
+}
+
+// thumbnailPath returns the path of the endpoint serving the thumbnail of a page of a document.
+func thumbnailPath(documentUuid uuid.UUID, page uint32) string {
+	return fmt.Sprintf("/api/v1/documents/%s/pages/%d/image?dpi=%d&format=%s", documentUuid, page, thumbnailDPI, pdf.ImageFormatPNG)
+}
+
 func (t DocumentController) SetupRouter(c *gin.RouterGroup) {
-	c.POST("/", t.UploadDocumentHandler)
-	c.PUT("/", t.UploadDocumentHandler)
-	c.GET("/", t.GetDocumentHandler)
-	c.DELETE("/", t.DeleteDocumentHandler)
+	read := requirePermission(models.PermissionDocumentsRead)
+	write := requirePermission(models.PermissionDocumentsWrite)
+	c.POST("/", write, t.UploadDocumentHandler)
+	c.PUT("/", write, t.UploadDocumentHandler)
+	c.POST("/composite", write, requirePermission(models.PermissionMetaWrite), requirePermission(models.PermissionSelectionsWrite), t.CreateDocumentWithDetailsHandler)
+	c.GET("/", read, t.GetDocumentHandler)
+	c.DELETE("/", write, t.DeleteDocumentHandler)
+	c.GET("/:documentUUID/content", read, t.GetDocumentContentHandler)
+	c.GET("/:documentUUID/pages/:pageNumber/words", read, t.GetPageWordsHandler)
+
+	if t.Renderer != nil {
+		c.GET("/:documentUUID/pages/:pageNumber/image", read, t.GetPageImageHandler)
+	}
+
+	if t.GrantRepository != nil {
+		share := requirePermission(models.PermissionDocumentsShare)
+		c.POST("/:documentUUID/grants", share, t.GrantAccessHandler)
+		c.GET("/:documentUUID/grants", share, t.GetGrantsHandler)
+		c.DELETE("/:documentUUID/grants/:granteeUUID", share, t.RevokeAccessHandler)
+	}
 }
@@ -0,0 +1,175 @@
+package v1
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"pdf_service_api/models"
+)
+
+// GrantAccessHandler handles the HTTP POST request to share a document with another owner.
+// It expects the document's UUID as a path parameter and a GrantAccessRequest as the body. Sharing a document again
+// with the same grantee replaces the grantee's role.
+//
+// Only the document's owner, or a grantee with the owner role, can share it; for anyone else the document is
+// reported as not found.
+//
+// @Summary Share a document
+// @Description Grants another owner the viewer, editor or owner role on a document.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param documentUUID path string true "The UUID of the document to share"
+// @Param ownerUUID query string false "The UUID of the owner of the document, only read when authentication is disabled"
+// @Param request body GrantAccessRequest true "The grantee and its role"
+// @Success 200 {object} models.Grant "The grant that was stored"
+// @Failure 400 {object} object{error=string} "Bad Request: Invalid UUID format, missing grantee or unknown role."
+// @Failure 404 {object} object{error=string} "Not Found: No document the caller can share was found for the given UUID."
+// @Failure 500 {object} object{error=string} "Internal Server Error: An unexpected error occurred on the server."
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /documents/{documentUUID}/grants [post]
+func (t DocumentController) GrantAccessHandler(c *gin.Context) {
+	documentUid, err := uuid.Parse(c.Param("documentUUID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request := GrantAccessRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if request.GranteeUUID == nil || *request.GranteeUUID == uuid.Nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "granteeUUID was not present"})
+		return
+	}
+
+	if *request.GranteeUUID == ownerUid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A document cannot be shared with the caller"})
+		return
+	}
+
+	switch request.Role {
+	case models.RoleViewer, models.RoleEditor, models.RoleOwner:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be one of viewer, editor or owner"})
+		return
+	}
+
+	grant := models.Grant{
+		DocumentUUID: documentUid,
+		GranteeUUID:  *request.GranteeUUID,
+		GranteeType:  request.GranteeType,
+		Role:         request.Role,
+	}
+
+	if err := t.GrantRepository.GrantAccess(c.Request.Context(), grant, ownerUid); err != nil {
+		grantError(c, documentUid, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, grant)
+}
+
+// GetGrantsHandler handles the HTTP GET request to list who a document is shared with.
+// Only the document's owner, or a grantee with the owner role, can list its grants.
+//
+// @Summary List the grants of a document
+// @Description Lists the owners a document is shared with and their roles.
+// @Tags documents
+// @Produce json
+// @Param documentUUID path string true "The UUID of the document"
+// @Param ownerUUID query string false "The UUID of the owner of the document, only read when authentication is disabled"
+// @Success 200 {object} object{grants=[]models.Grant} "The grants of the document"
+// @Failure 400 {object} object{error=string} "Bad Request: Invalid UUID format or missing owner UUID."
+// @Failure 404 {object} object{error=string} "Not Found: No document the caller can share was found for the given UUID."
+// @Failure 500 {object} object{error=string} "Internal Server Error: An unexpected error occurred on the server."
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /documents/{documentUUID}/grants [get]
+func (t DocumentController) GetGrantsHandler(c *gin.Context) {
+	documentUid, err := uuid.Parse(c.Param("documentUUID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	grants, err := t.GrantRepository.GetGrants(c.Request.Context(), documentUid, ownerUid)
+	if err != nil {
+		grantError(c, documentUid, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"grants": grants})
+}
+
+// RevokeAccessHandler handles the HTTP DELETE request to stop sharing a document with a grantee.
+// Only the document's owner, or a grantee with the owner role, can revoke grants.
+//
+// @Summary Revoke a grant
+// @Description Stops sharing a document with a grantee.
+// @Tags documents
+// @Produce json
+// @Param documentUUID path string true "The UUID of the document"
+// @Param granteeUUID path string true "The UUID of the grantee to revoke"
+// @Param ownerUUID query string false "The UUID of the owner of the document, only read when authentication is disabled"
+// @Success 200 {object} map[string]bool "Successful revocation"
+// @Failure 400 {object} object{error=string} "Bad Request: Invalid UUID format or missing owner UUID."
+// @Failure 404 {object} object{error=string} "Not Found: The document is not shared with the grantee, or the caller cannot share it."
+// @Failure 500 {object} object{error=string} "Internal Server Error: An unexpected error occurred on the server."
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /documents/{documentUUID}/grants/{granteeUUID} [delete]
+func (t DocumentController) RevokeAccessHandler(c *gin.Context) {
+	documentUid, err := uuid.Parse(c.Param("documentUUID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	granteeUid, err := uuid.Parse(c.Param("granteeUUID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := t.GrantRepository.RevokeAccess(c.Request.Context(), documentUid, granteeUid, ownerUid); err != nil {
+		grantError(c, documentUid, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// grantError responds to a failed repository call, reporting documents the caller cannot share as not found.
+func grantError(c *gin.Context, documentUid uuid.UUID, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Grant of document with documentUUID " + documentUid.String() + " was not found."})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -3,6 +3,7 @@ package v1
 import (
 	"github.com/google/uuid"
 	"pdf_service_api/models"
+	"strconv"
 )
 
 type GetDocumentRequest struct {
@@ -17,6 +18,76 @@ type CreateRequest struct {
 	OwnerType            *int       `json:"ownerType"`
 }
 
+// CreateDocumentWithDetailsRequest creates a document together with its metadata and initial selections.
+// When Meta is left out, it is extracted from the document as for a plain upload.
+type CreateDocumentWithDetailsRequest struct {
+	DocumentBase64String string                     `json:"documentBase64String"`
+	DocumentTitle        *string                    `json:"documentTitle"`
+	OwnerUUID            *uuid.UUID                 `json:"ownerUUID"`
+	OwnerType            *int                       `json:"ownerType"`
+	Meta                 *DocumentMetaRequest       `json:"meta"`
+	Selections           []DocumentSelectionRequest `json:"selections"`
+}
+
+// DocumentMetaRequest is the metadata of a document created with CreateDocumentWithDetailsRequest.
+type DocumentMetaRequest struct {
+	NumberOfPages *uint32            `json:"numberOfPages" example:"31"`
+	Height        *float32           `json:"height" example:"1080"`
+	Width         *float32           `json:"width" example:"1920"`
+	Pages         *[]models.PageMeta `json:"pages"`
+}
+
+// DocumentSelectionRequest is an initial selection of a document created with CreateDocumentWithDetailsRequest.
+type DocumentSelectionRequest struct {
+	IsComplete      bool                              `json:"isComplete,omitempty"`
+	Settings        *string                           `json:"settings,omitempty"`
+	SelectionBounds *map[int][]models.SelectionBounds `json:"selectionBounds,omitempty"`
+}
+
+// CreateDocumentWithDetailsResponse holds the UUIDs of a document created with CreateDocumentWithDetailsRequest and
+// of its selections, in the order they were given.
+type CreateDocumentWithDetailsResponse struct {
+	DocumentUUID   uuid.UUID   `json:"documentUUID" example:"ba3ca973-5052-4030-a528-39b49736d8ad"`
+	SelectionUUIDs []uuid.UUID `json:"selectionUUIDs"`
+}
+
+// UploadDetails holds the document fields that accompany a binary upload,
+// taken from either the multipart form fields or the query parameters.
+type UploadDetails struct {
+	DocumentTitle *string
+	OwnerUUID     *uuid.UUID
+	OwnerType     *int
+}
+
+// parseUploadDetails reads the documentTitle, ownerUUID and ownerType values using the supplied lookup function.
+func parseUploadDetails(lookup func(key string) (string, bool)) (UploadDetails, error) {
+	details := UploadDetails{}
+
+	if value, isPresent := lookup("documentTitle"); isPresent && value != "" {
+		details.DocumentTitle = &value
+	}
+
+	if value, isPresent := lookup("ownerUUID"); isPresent && value != "" {
+		ownerUUID, err := uuid.Parse(value)
+		if err != nil {
+			return details, err
+		}
+
+		details.OwnerUUID = &ownerUUID
+	}
+
+	if value, isPresent := lookup("ownerType"); isPresent && value != "" {
+		ownerType, err := strconv.Atoi(value)
+		if err != nil {
+			return details, err
+		}
+
+		details.OwnerType = &ownerType
+	}
+
+	return details, nil
+}
+
 type AddNewSelectionRequest struct {
 	DocumentUUID    *uuid.UUID                        `json:"documentUUID,omitempty"`
 	IsComplete      bool                              `json:"isComplete,omitempty"`
@@ -24,11 +95,18 @@ type AddNewSelectionRequest struct {
 	SelectionBounds *map[int][]models.SelectionBounds `json:"selectionBounds,omitempty"`
 }
 
+type UpdateSelectionRequest struct {
+	IsComplete      *bool                             `json:"isComplete,omitempty"`
+	Settings        *string                           `json:"settings,omitempty"`
+	SelectionBounds map[int]*[]models.SelectionBounds `json:"selectionBounds,omitempty"`
+}
+
 type AddMetaRequest struct {
+	UUID          uuid.UUID
 	NumberOfPages *uint32
 	Height        *float32
 	Width         *float32
-	Images        *map[uint32]string
+	Pages         *[]models.PageMeta
 }
 
 type UpdateMetaRequest struct {
@@ -36,9 +114,47 @@ type UpdateMetaRequest struct {
 	NumberOfPages *uint32
 	Height        *float32
 	Width         *float32
-	Images        *map[uint32]string
+	Pages         *[]models.PageMeta
 }
 
 type DeleteMetaRequest struct {
 	UUID uuid.UUID
 }
+
+type ExtractSelectionResponse struct {
+	SelectionUUID uuid.UUID                  `json:"selectionUUID"`
+	Extraction    models.SelectionExtraction `json:"extraction"`
+}
+
+// GrantAccessRequest shares a document with a grantee in one of the roles viewer, editor or owner.
+type GrantAccessRequest struct {
+	GranteeUUID *uuid.UUID `json:"granteeUUID" example:"34906041-2d68-45a2-9671-9f0ba89f31a9"`
+	GranteeType *int       `json:"granteeType" example:"1"`
+	Role        string     `json:"role" example:"viewer"`
+}
+
+// CreateAPIKeyRequest creates an API key with a name to recognise it by and the permissions it is given.
+type CreateAPIKeyRequest struct {
+	Name        string   `json:"name" example:"batch pipeline"`
+	Permissions []string `json:"permissions" example:"documents:read,selections:write"`
+}
+
+// CreateAPIKeyResponse holds a newly created API key. Key is the only time the full key is returned.
+type CreateAPIKeyResponse struct {
+	APIKey models.APIKey `json:"apiKey"`
+	Key    string        `json:"key" example:"pdfsvc_q8V1d0mXbU2m3Lw0H8mJ2hY7Ck5Nf9QxJt4s6Rp1ZaE"`
+}
+
+// ReadinessResponse reports whether the instance is ready to serve requests, with the result of every readiness check.
+// Status is "ready", "not ready" or "shutting down"; checks are not run while shutting down.
+type ReadinessResponse struct {
+	Status string                       `json:"status" example:"ready"`
+	Checks map[string]CheckResultStatus `json:"checks,omitempty"`
+}
+
+// CheckResultStatus is the result of one readiness check. Status is "up" or "down", with the reason in Error.
+type CheckResultStatus struct {
+	Status     string `json:"status" example:"up"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs" example:"3"`
+}
@@ -0,0 +1,125 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultCheckTimeout bounds a readiness check when the HealthController has no Timeout.
+const defaultCheckTimeout = 5 * time.Second
+
+// ReadinessCheck is a named check the instance must pass to be ready, such as reaching the database.
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HealthController serves the liveness and readiness endpoints. Checks run concurrently, each bounded by Timeout.
+// Once MarkNotReady is called, for example when the instance starts shutting down, it is reported not ready without
+// running the checks.
+type HealthController struct {
+	Checks  []ReadinessCheck
+	Timeout time.Duration
+
+	notReady atomic.Bool
+}
+
+// MarkNotReady makes the readiness endpoint fail from now on, so load balancers stop routing to the instance.
+func (t *HealthController) MarkNotReady() {
+	t.notReady.Store(true)
+}
+
+// Readiness runs the readiness checks and reports their results.
+func (t *HealthController) Readiness(ctx context.Context) ReadinessResponse {
+	if t.notReady.Load() {
+		return ReadinessResponse{Status: "shutting down"}
+	}
+
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+
+	results := make(map[string]CheckResultStatus, len(t.Checks))
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range t.Checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			result := CheckResultStatus{Status: "up"}
+			if err := check.Check(checkCtx); err != nil {
+				result = CheckResultStatus{Status: "down", Error: err.Error()}
+			}
+			result.DurationMs = time.Since(start).Milliseconds()
+
+			mutex.Lock()
+			results[check.Name] = result
+			mutex.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	response := ReadinessResponse{Status: "ready", Checks: results}
+	for _, result := range results {
+		if result.Status != "up" {
+			response.Status = "not ready"
+		}
+	}
+
+	return response
+}
+
+// Ready returns an error naming every failing readiness check, or nil when the instance is ready. It is the health
+// check reported to service registries.
+func (t *HealthController) Ready(ctx context.Context) error {
+	response := t.Readiness(ctx)
+	if response.Status == "ready" {
+		return nil
+	}
+
+	problems := []error{errors.New(response.Status)}
+	for name, result := range response.Checks {
+		if result.Status != "up" {
+			problems = append(problems, fmt.Errorf("%s: %s", name, result.Error))
+		}
+	}
+
+	return errors.Join(problems...)
+}
+
+// LivenessHandler reports that the process is running and serving requests. It does not check any dependency, so a
+// failing dependency does not get the process restarted.
+func (t *HealthController) LivenessHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// ReadinessHandler runs the readiness checks and reports the result of every check, with a 200 OK when the instance is
+// ready and a 503 Service Unavailable when a check fails or the instance is shutting down.
+func (t *HealthController) ReadinessHandler(c *gin.Context) {
+	response := t.Readiness(c.Request.Context())
+	if response.Status != "ready" {
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetupRouter registers /healthz and /readyz. They are registered outside /api/v1/, next to /ping, so probes need no
+// credentials.
+func (t *HealthController) SetupRouter(c *gin.RouterGroup) {
+	c.GET("/healthz", t.LivenessHandler)
+	c.GET("/readyz", t.ReadinessHandler)
+}
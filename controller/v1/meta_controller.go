@@ -1,6 +1,8 @@
 package v1
 
 import (
+	"database/sql"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"net/http"
@@ -13,25 +15,37 @@ type MetaController struct {
 
 // AddMeta handles the HTTP POST request to add new metadata.
 // It expects a JSON request body conforming to the AddMetaRequest struct,
-// which should contain the NumberOfPages, Height, Width, and Images for the new metadata.
+// which should contain the UUID of the document the metadata describes, and the
+// NumberOfPages, Height, Width, and Pages for the new metadata.
+// Images are not accepted from clients; they reference the page thumbnails rendered by
+// the documents endpoint and are set when a document is uploaded.
 //
-// A new UUID will be generated for the metadata.
 // Upon successful creation, it returns a 200 OK status with the UUID of the
-// newly created metadata. If there's an error during request binding or
-// metadata creation, it returns a 400 Bad Request or 500 Internal Server Error
-// status with an error message.
+// document. If there's an error during request binding, it returns a 400 Bad Request.
+// When the document does not exist or cannot be accessed by the caller, it returns a 404 Not Found,
+// and otherwise a 500 Internal Server Error status with an error message.
 //
 // @Summary Add new metadata
-// @Description Creates new metadata with a generated UUID.
+// @Description Creates the metadata of a document the caller can edit.
 // @Tags meta
 // @Accept  json
 // @Produce  json
 // @Param   request body v1.AddMetaRequest true "Metadata creation request"
+// @Param   ownerUUID query string false "The UUID of the document's owner, only read when authentication is disabled"
 // @Success 200 {object} map[string]uuid.UUID "Successful creation, returns the metadata UUID"
 // @Failure 400 "Bad request, typically due to invalid input"
+// @Failure 404 "Document not found"
 // @Failure 500 "Internal server error, typically due to database issues"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /meta [post]
 func (t MetaController) AddMeta(c *gin.Context) {
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	body := &AddMetaRequest{}
 	if err := c.ShouldBindJSON(body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -39,15 +53,15 @@ func (t MetaController) AddMeta(c *gin.Context) {
 	}
 
 	model := models.Meta{
-		DocumentUUID:  uuid.New(),
+		DocumentUUID:  body.UUID,
 		NumberOfPages: body.NumberOfPages,
 		Height:        body.Height,
 		Width:         body.Width,
-		Images:        body.Images,
+		Pages:         body.Pages,
 	}
 
-	if err := t.MetaRepository.AddMeta(model); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := t.MetaRepository.AddMeta(c.Request.Context(), model, ownerUid); err != nil {
+		metaError(c, model.DocumentUUID, err)
 		return
 	}
 
@@ -57,12 +71,14 @@ func (t MetaController) AddMeta(c *gin.Context) {
 // UpdateMeta handles the HTTP PUT request to update existing metadata.
 // It expects a JSON request body conforming to the UpdateMetaRequest struct,
 // which should contain the UUID of the metadata to be updated, and the fields
-// to be modified (NumberOfPages, Height, Width, Images). Note that these fields
+// to be modified (NumberOfPages, Height, Width, Pages). Note that these fields
 // are pointers in the `models.Meta` struct, allowing for partial updates.
+// Images are managed by the service and cannot be changed.
 //
 // Upon successful update, it returns a 200 OK status with an empty JSON object.
-// If there's an error during request binding or metadata update, it returns
-// a 400 Bad Request or 500 Internal Server Error status with an error message.
+// If there's an error during request binding, it returns a 400 Bad Request. When the metadata
+// does not exist or its document cannot be accessed by the caller, it returns a 404 Not Found, and
+// otherwise a 500 Internal Server Error status with an error message.
 //
 // @Summary Update existing metadata
 // @Description Updates specific fields of an existing metadata entry.
@@ -70,11 +86,21 @@ func (t MetaController) AddMeta(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param   request body UpdateMetaRequest true "Metadata update request"
+// @Param   ownerUUID query string false "The UUID of the document's owner, only read when authentication is disabled"
 // @Success 200 "Successful update"
 // @Failure 400 "Bad request, typically due to invalid input"
+// @Failure 404 "Metadata not found"
 // @Failure 500 "Internal server error, typically due to database issues"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /meta [put]
 func (t MetaController) UpdateMeta(c *gin.Context) {
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	if id, isPresent := c.GetQuery("documentUUID"); isPresent {
 		uid, err := uuid.Parse(id)
 		if err != nil {
@@ -92,11 +118,11 @@ func (t MetaController) UpdateMeta(c *gin.Context) {
 			NumberOfPages: body.NumberOfPages,
 			Height:        body.Height,
 			Width:         body.Width,
-			Images:        body.Images,
+			Pages:         body.Pages,
 		}
 
-		if err := t.MetaRepository.UpdateMeta(uid, model); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err := t.MetaRepository.UpdateMeta(c.Request.Context(), uid, ownerUid, model); err != nil {
+			metaError(c, uid, err)
 			return
 		}
 
@@ -113,8 +139,9 @@ func (t MetaController) UpdateMeta(c *gin.Context) {
 // which should contain the UUID of the metadata to be deleted.
 //
 // Upon successful deletion, it returns a 200 OK status with an empty JSON object.
-// If there's an error during request binding or metadata deletion, it returns
-// a 400 Bad Request or 500 Internal Server Error status with an error message.
+// If there's an error during request binding, it returns a 400 Bad Request. When the metadata
+// does not exist or its document cannot be accessed by the caller, it returns a 404 Not Found, and
+// otherwise a 500 Internal Server Error status with an error message.
 //
 // @Summary Delete metadata by UUID
 // @Description Deletes metadata based on the provided UUID in the request body.
@@ -122,11 +149,21 @@ func (t MetaController) UpdateMeta(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param   request body v1.DeleteMetaRequest true "Metadata deletion request"
+// @Param   ownerUUID query string false "The UUID of the document's owner, only read when authentication is disabled"
 // @Success 200 {object} map[string]bool "Successful deletion"
 // @Failure 400 "Bad request, typically due to invalid input"
+// @Failure 404 "Metadata not found"
 // @Failure 500 "Internal server error, typically due to database issues"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /meta [delete]
 func (t MetaController) DeleteMeta(c *gin.Context) {
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	body := &DeleteMetaRequest{}
 	if err := c.ShouldBindJSON(body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -137,8 +174,8 @@ func (t MetaController) DeleteMeta(c *gin.Context) {
 		DocumentUUID: body.UUID,
 	}
 
-	if err := t.MetaRepository.DeleteMeta(model); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := t.MetaRepository.DeleteMeta(c.Request.Context(), model, ownerUid); err != nil {
+		metaError(c, model.DocumentUUID, err)
 		return
 	}
 
@@ -148,9 +185,11 @@ func (t MetaController) DeleteMeta(c *gin.Context) {
 // GetMeta handles the HTTP GET request to retrieve metadata by its UUID.
 // It expects the metadata's UUID as a query parameter named "id".
 //
-// Upon successful retrieval, it returns a 200 OK status with the metadata object.
-// If the UUID is missing, invalid, or if an error occurs during retrieval, it returns
-// a 400 Bad Request or 500 Internal Server Error status with an appropriate error message.
+// Upon successful retrieval, it returns a 200 OK status with the metadata object. Its Images map
+// page numbers to the paths of their rendered thumbnails.
+// If the UUID is missing or invalid, it returns a 400 Bad Request. When the metadata does not
+// exist or its document cannot be accessed by the caller, it returns a 404 Not Found, and otherwise a
+// 500 Internal Server Error status with an appropriate error message.
 //
 // @Summary Get metadata by UUID
 // @Description Retrieves metadata associated with a given UUID.
@@ -158,11 +197,21 @@ func (t MetaController) DeleteMeta(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param   documentUUID query string true "The UUID of the metadata to retrieve"
+// @Param   ownerUUID query string false "The UUID of the document's owner, only read when authentication is disabled"
 // @Success 200 {object} models.Meta "Successful retrieval of metadata"
 // @Failure 400 "Bad request, typically due to missing/invalid UUID"
+// @Failure 404 "Metadata not found"
 // @Failure 500 "Internal server error, typically due to database issues"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /meta [get]
 func (t MetaController) GetMeta(c *gin.Context) {
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	if id, isPresent := c.GetQuery("documentUUID"); isPresent {
 		uid, err := uuid.Parse(id)
 		if err != nil {
@@ -170,9 +219,9 @@ func (t MetaController) GetMeta(c *gin.Context) {
 			return
 		}
 
-		data, err := t.MetaRepository.GetMeta(uid)
+		data, err := t.MetaRepository.GetMeta(c.Request.Context(), uid, ownerUid)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			metaError(c, uid, err)
 			return
 		}
 
@@ -183,9 +232,22 @@ func (t MetaController) GetMeta(c *gin.Context) {
 	c.JSON(http.StatusBadRequest, gin.H{"Error": "No param specified."})
 }
 
+// metaError responds to a failed repository call, reporting metadata that does not exist or cannot be accessed by the
+// caller as not found.
+func metaError(c *gin.Context, documentUid uuid.UUID, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Meta with documentUUID " + documentUid.String() + " was not found."})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 func (t MetaController) SetupRouter(c *gin.RouterGroup) {
-	c.GET("/", t.GetMeta)
-	c.POST("/", t.AddMeta)
-	c.PUT("/", t.UpdateMeta)
-	c.DELETE("/", t.DeleteMeta)
+	read := requirePermission(models.PermissionMetaRead)
+	write := requirePermission(models.PermissionMetaWrite)
+	c.GET("/", read, t.GetMeta)
+	c.POST("/", write, t.AddMeta)
+	c.PUT("/", write, t.UpdateMeta)
+	c.DELETE("/", write, t.DeleteMeta)
 }
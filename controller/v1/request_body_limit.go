@@ -0,0 +1,15 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// LimitRequestBody creates a gin middleware that fails reading a request body once it exceeds limit bytes, so
+// oversized uploads are rejected by the handler reading them instead of being held in memory.
+func LimitRequestBody(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
@@ -0,0 +1,19 @@
+package v1
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+// RequestTimeout creates a gin middleware that gives every request a deadline. Handlers pass the request's context
+// to the repositories, so their queries are cancelled once the deadline passes or the client disconnects.
+func RequestTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
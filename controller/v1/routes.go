@@ -4,10 +4,13 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(documentController *DocumentController, selectionController *SelectionController, metaController *MetaController) *gin.Engine {
+// SetupRouter registers the controllers' routes under /api/v1/. The middleware, such as the ones returned by
+// NewAPIKeyMiddleware and NewJWTMiddleware, is applied to every API route; /ping stays public. Load balancers and
+// orchestrators should probe /healthz and /readyz, registered by HealthController, instead of /ping.
+func SetupRouter(documentController *DocumentController, selectionController *SelectionController, metaController *MetaController, apiKeyController *APIKeyController, middleware ...gin.HandlerFunc) *gin.Engine {
 	router := gin.Default()
 	router.GET("/ping", OnPing)
-	apiV1Group := router.Group("/api/v1/")
+	apiV1Group := router.Group("/api/v1/", middleware...)
 
 	if documentController != nil {
 		documentGroup := apiV1Group.Group("/documents")
@@ -24,5 +27,10 @@ func SetupRouter(documentController *DocumentController, selectionController *Se
 		metaController.SetupRouter(metaGroup)
 	}
 
+	if apiKeyController != nil {
+		keyGroup := apiV1Group.Group("/keys")
+		apiKeyController.SetupRouter(keyGroup)
+	}
+
 	return router
 }
@@ -1,14 +1,26 @@
 package v1
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"net/http"
 	"pdf_service_api/models"
+	"pdf_service_api/pdf"
+	"slices"
+	"sort"
 )
 
+// selectionFields are the selection fields that can be chosen with the fields and exclude query parameters.
+var selectionFields = []string{"documentUUID", "isComplete", "settings", "selectionBounds", "extraction"}
+
 type SelectionController struct {
 	SelectionRepository models.SelectionRepository
+	DocumentRepository  models.DocumentRepository
 }
 
 // GetSelection handles the HTTP GET request to retrieve selections based on either
@@ -18,32 +30,70 @@ type SelectionController struct {
 // If "documentUUID" is provided, it fetches all selections associated with that document.
 // If "selectionUUID" is provided, it fetches selections matching that specific selection UUID.
 //
+// Selections are returned in full, including their completion state, settings and stored
+// extraction result. The optional "exclude" query parameter leaves out the listed fields, and
+// the optional "fields" query parameter returns only the listed fields; selectionUUID is
+// always returned.
+//
+// Only selections of documents the caller can read are returned. A selection or document
+// that does not exist or cannot be accessed by the caller gives a 404 Not Found.
+//
 // Upon successful retrieval, it returns a 200 OK status with a JSON array of selections.
 // If no parameter is specified, the UUID is invalid, or an error occurs during retrieval,
 // it returns a 400 Bad Request or 500 Internal Server Error status with an error message.
 //
 // @Summary Get selections by document or selection UUID
 // @Description Retrieves selections based on either a document's UUID or a specific selection's UUID.
+// @Description Optional fields and exclusion parameters can be used to choose which fields are returned.
 // @Tags selections
 // @Accept  json
 // @Produce  json
 // @Param   documentUUID query string false "The UUID of the document to retrieve selections for"
 // @Param   selectionUUID query string false "The UUID of the specific selection to retrieve"
+// @Param   fields query []string false "Fields to include in the response. Allowed values: `documentUUID`, `isComplete`, `settings`, `selectionBounds`, `extraction`." collectionFormat(multi)
+// @Param   exclude query []string false "Fields to exclude from the response. Allowed values: `documentUUID`, `isComplete`, `settings`, `selectionBounds`, `extraction`." collectionFormat(multi)
+// @Param   ownerUUID query string false "The UUID of the document's owner, only read when authentication is disabled"
 // @Success 200 {object} map[string][]models.Selection "Successful retrieval of selections"
 // @Failure 400 "Bad request, typically due to missing/invalid UUID parameter"
+// @Failure 404 "Selection or document not found"
 // @Failure 500 "Internal server error, typically due to database issues"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /selections [get]
 func (t SelectionController) GetSelection(c *gin.Context) {
-	getSelection := func(id string, passedServiceGetFunction func(uid uuid.UUID) ([]models.Selection, error)) {
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	exclude := make(map[string]bool)
+	if values, present := c.GetQueryArray("fields"); present {
+		for _, field := range selectionFields {
+			if !slices.Contains(values, field) {
+				exclude[field] = true
+			}
+		}
+	}
+
+	if values, present := c.GetQueryArray("exclude"); present {
+		for _, field := range selectionFields {
+			if slices.Contains(values, field) {
+				exclude[field] = true
+			}
+		}
+	}
+
+	getSelection := func(param string, id string, passedServiceGetFunction func(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID, excludes map[string]bool) ([]models.Selection, error)) {
 		uid, err := uuid.Parse(id)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		results, err := passedServiceGetFunction(uid)
+		results, err := passedServiceGetFunction(c.Request.Context(), uid, ownerUid, exclude)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			selectionError(c, param, uid, err)
 			return
 		}
 
@@ -51,12 +101,12 @@ func (t SelectionController) GetSelection(c *gin.Context) {
 	}
 
 	if id, isPresent := c.GetQuery("documentUUID"); isPresent {
-		getSelection(id, t.SelectionRepository.GetSelectionsByDocumentUUID)
+		getSelection("documentUUID", id, t.SelectionRepository.GetSelectionsByDocumentUUID)
 		return
 	}
 
 	if id, isPresent := c.GetQuery("selectionUUID"); isPresent {
-		getSelection(id, t.SelectionRepository.GetSelectionsBySelectionUUID)
+		getSelection("selectionUUID", id, t.SelectionRepository.GetSelectionsBySelectionUUID)
 		return
 	}
 
@@ -71,6 +121,9 @@ func (t SelectionController) GetSelection(c *gin.Context) {
 // If "selectionUUID" is provided, it deletes the specific selection.
 // If "documentUUID" is provided, it deletes all selections belonging to that document.
 //
+// Only selections of documents the caller can edit can be deleted. A selection or document
+// that does not exist or cannot be accessed by the caller gives a 404 Not Found.
+//
 // Upon successful deletion, it returns a 200 OK status with a success message.
 // If no parameter is specified, the UUID is invalid, or an error occurs during deletion,
 // it returns a 400 Bad Request or 500 Internal Server Error status with an error message.
@@ -82,21 +135,31 @@ func (t SelectionController) GetSelection(c *gin.Context) {
 // @Produce  json
 // @Param   selectionUUID query string false "The UUID of the specific selection to delete"
 // @Param   documentUUID query string false "The UUID of the document whose selections are to be deleted"
+// @Param   ownerUUID query string false "The UUID of the document's owner, only read when authentication is disabled"
 // @Success 200 {object} map[string]bool "Successful deletion"
 // @Failure 400 "Bad request, typically due to missing/invalid UUID parameter"
+// @Failure 404 "Selection or document not found"
 // @Failure 500 "Internal server error, typically due to database issues"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /selections [delete]
 func (t SelectionController) DeleteSelection(c *gin.Context) {
-	handleDeletion := func(id string, serviceFunction func(uid uuid.UUID) error) {
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	handleDeletion := func(param string, id string, serviceFunction func(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID) error) {
 		uid, err := uuid.Parse(id)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		err = serviceFunction(uid)
+		err = serviceFunction(c.Request.Context(), uid, ownerUid)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			selectionError(c, param, uid, err)
 			return
 		}
 
@@ -104,12 +167,12 @@ func (t SelectionController) DeleteSelection(c *gin.Context) {
 	}
 
 	if id, isPresent := c.GetQuery("selectionUUID"); isPresent {
-		handleDeletion(id, t.SelectionRepository.DeleteSelectionBySelectionUUID)
+		handleDeletion("selectionUUID", id, t.SelectionRepository.DeleteSelectionBySelectionUUID)
 		return
 	}
 
 	if id, isPresent := c.GetQuery("documentUUID"); isPresent {
-		handleDeletion(id, t.SelectionRepository.DeleteSelectionByDocumentUUID)
+		handleDeletion("documentUUID", id, t.SelectionRepository.DeleteSelectionByDocumentUUID)
 		return
 	}
 
@@ -123,9 +186,10 @@ func (t SelectionController) DeleteSelection(c *gin.Context) {
 //
 // A new UUID will be generated for the selection.
 // Upon successful creation, it returns a 200 OK status with the UUID of the
-// newly created selection. If there's an error during request binding or
-// selection creation, it returns a 400 Bad Request or 500 Internal Server Error
-// status with an error message.
+// newly created selection. If there's an error during request binding, it returns
+// a 400 Bad Request. When the document does not exist or cannot be accessed by the caller,
+// it returns a 404 Not Found, and otherwise a 500 Internal Server Error status with
+// an error message.
 //
 // @Summary Add a new selection
 // @Description Creates a new selection associated with a document.
@@ -133,11 +197,21 @@ func (t SelectionController) DeleteSelection(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param   request body v1.AddNewSelectionRequest true "Selection creation request"
+// @Param   ownerUUID query string false "The UUID of the document's owner, only read when authentication is disabled"
 // @Success 200 {object} map[string]uuid.UUID "Successful creation, returns the selection UUID"
 // @Failure 400 "Bad request, typically due to invalid input"
+// @Failure 404 "Document not found"
 // @Failure 500 "Internal server error, typically due to database issues"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /selections [post]
 func (t SelectionController) AddSelection(c *gin.Context) {
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	reqBody := &AddNewSelectionRequest{}
 
 	if err := c.ShouldBindJSON(reqBody); err != nil {
@@ -145,25 +219,243 @@ func (t SelectionController) AddSelection(c *gin.Context) {
 		return
 	}
 
+	if reqBody.DocumentUUID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "documentUUID was not present"})
+		return
+	}
+
 	toCreate := models.Selection{
 		Uuid:            uuid.New(),
 		DocumentUUID:    reqBody.DocumentUUID,
-		IsComplete:      reqBody.IsComplete,
+		IsComplete:      &reqBody.IsComplete,
 		Settings:        reqBody.Settings,
 		SelectionBounds: reqBody.SelectionBounds,
 	}
 
-	err := t.SelectionRepository.AddNewSelection(toCreate)
+	err = t.SelectionRepository.AddNewSelection(c.Request.Context(), toCreate, ownerUid)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		selectionError(c, "documentUUID", *reqBody.DocumentUUID, err)
 		return
 	}
 
 	c.JSON(200, gin.H{"selectionUUID": toCreate.Uuid.String()})
 }
 
+// UpdateSelection handles the HTTP PATCH request to partially update a selection.
+// It expects the selection UUID as a path parameter and a JSON request body conforming to the
+// UpdateSelectionRequest struct. Fields that are left out are not changed, so the selection keeps its UUID.
+//
+// SelectionBounds is merged page by page: each page in the request replaces that page's bounds, a page
+// set to null or an empty list has its bounds removed, and pages not in the request are left as they are.
+// Changing the bounds clears any stored extraction result.
+//
+// Upon successful update, it returns a 200 OK status with the updated selection. It returns a 400 Bad Request
+// for an invalid UUID, body or settings, a 404 Not Found when the selection does not exist or its document
+// cannot be accessed by the caller, and a 500 Internal Server Error otherwise.
+//
+// @Summary Partially update a selection
+// @Description Updates the completion state, settings or bounds of a selection without changing its UUID.
+// @Tags selections
+// @Accept  json
+// @Produce  json
+// @Param   selectionUUID path string true "The UUID of the selection to update"
+// @Param   request body v1.UpdateSelectionRequest true "Selection update request"
+// @Param   ownerUUID query string false "The UUID of the document's owner, only read when authentication is disabled"
+// @Success 200 {object} map[string]models.Selection "Successful update, returns the updated selection"
+// @Failure 400 "Bad request, typically due to invalid input"
+// @Failure 404 "Selection not found"
+// @Failure 500 "Internal server error, typically due to database issues"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /selections/{selectionUUID} [patch]
+func (t SelectionController) UpdateSelection(c *gin.Context) {
+	selectionUid, err := uuid.Parse(c.Param("selectionUUID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reqBody := &UpdateSelectionRequest{}
+	if err := c.ShouldBindJSON(reqBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if reqBody.IsComplete == nil && reqBody.Settings == nil && len(reqBody.SelectionBounds) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update."})
+		return
+	}
+
+	if reqBody.Settings != nil && !json.Valid([]byte(*reqBody.Settings)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Settings must be valid JSON."})
+		return
+	}
+
+	update := models.SelectionUpdate{
+		IsComplete:      reqBody.IsComplete,
+		Settings:        reqBody.Settings,
+		SelectionBounds: reqBody.SelectionBounds,
+	}
+
+	if err := t.SelectionRepository.UpdateSelection(c.Request.Context(), selectionUid, ownerUid, update); err != nil {
+		selectionError(c, "selectionUUID", selectionUid, err)
+		return
+	}
+
+	selections, err := t.SelectionRepository.GetSelectionsBySelectionUUID(c.Request.Context(), selectionUid, ownerUid, nil)
+	if err != nil {
+		selectionError(c, "selectionUUID", selectionUid, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"selection": selections[0]})
+}
+
+// ExtractSelection handles the HTTP POST request to extract the text inside a selection's bounds.
+// It expects the selection UUID as a path parameter, and only extracts from documents that can be edited by the
+// authenticated owner.
+//
+// The stored PDF of the selection's document is read and, for every page of the selection,
+// the text inside each bounds rectangle is extracted using the rectangle's extract_method:
+// "text" (the default, also used for "None"), "words" or "lines". Bounds are in PDF points
+// measured from the bottom left corner of the page, and pages are numbered from 1.
+//
+// The result is stored against the selection and returned with a 200 OK status. It returns
+// a 400 Bad Request for invalid parameters, unknown methods or pages outside the document,
+// a 404 Not Found when the selection or document does not exist, a 422 Unprocessable Entity
+// when the stored document cannot be parsed, and a 500 Internal Server Error otherwise.
+//
+// @Summary Extract the text inside a selection
+// @Description Extracts the text inside each bounds rectangle of a selection from the stored PDF, stores the result and returns it.
+// @Tags selections
+// @Produce  json
+// @Param   selectionUUID path string true "The UUID of the selection to extract"
+// @Param   ownerUUID query string false "The UUID of the document's owner, only read when authentication is disabled"
+// @Success 200 {object} v1.ExtractSelectionResponse "Successful extraction"
+// @Failure 400 "Bad request, typically due to invalid parameters, extraction methods or pages"
+// @Failure 404 "Selection or document not found"
+// @Failure 422 "The stored document could not be parsed"
+// @Failure 500 "Internal server error, typically due to database issues"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /selections/{selectionUUID}/extract [post]
+func (t SelectionController) ExtractSelection(c *gin.Context) {
+	selectionUid, err := uuid.Parse(c.Param("selectionUUID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ownerUid, err := requestOwner(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	selections, err := t.SelectionRepository.GetSelectionsBySelectionUUID(c.Request.Context(), selectionUid, ownerUid, nil)
+	if err != nil {
+		selectionError(c, "selectionUUID", selectionUid, err)
+		return
+	}
+
+	selection := selections[0]
+	if selection.DocumentUUID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Selection is not attached to a document."})
+		return
+	}
+
+	pages := make([]int, 0)
+	if selection.SelectionBounds != nil {
+		for page, boundsOnPage := range *selection.SelectionBounds {
+			for _, bounds := range boundsOnPage {
+				if _, err := pdf.ExtractionMethod(bounds); err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+			}
+
+			pages = append(pages, page)
+		}
+	}
+	sort.Ints(pages)
+
+	_, content, err := t.DocumentRepository.GetDocumentContent(c.Request.Context(), *selection.DocumentUUID, ownerUid)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Document with documentUUID " + selection.DocumentUUID.String() + " was not found."})
+			return
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	defer content.Close()
+
+	document, err := pdf.OpenReadSeeker(content)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	numPages, err := document.NumPages()
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	extraction := make(models.SelectionExtraction, len(pages))
+	for _, page := range pages {
+		if page < 1 || page > numPages {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Page %d is outside the document, which has %d pages.", page, numPages)})
+			return
+		}
+
+		results := make([]models.ExtractedBounds, 0, len((*selection.SelectionBounds)[page]))
+		for _, bounds := range (*selection.SelectionBounds)[page] {
+			result, err := document.ExtractBounds(page, bounds)
+			if err != nil {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+				return
+			}
+
+			results = append(results, result)
+		}
+
+		extraction[page] = results
+	}
+
+	if err := t.SelectionRepository.SaveExtraction(c.Request.Context(), selectionUid, ownerUid, extraction); err != nil {
+		selectionError(c, "selectionUUID", selectionUid, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ExtractSelectionResponse{SelectionUUID: selectionUid, Extraction: extraction})
+}
+
+// selectionError responds to a failed repository call, reporting selections and documents that do not exist or
+// cannot be accessed by the caller as not found. param names the UUID the request was made with.
+func selectionError(c *gin.Context, param string, uid uuid.UUID, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Selection with " + param + " " + uid.String() + " was not found."})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 func (t SelectionController) SetupRouter(c *gin.RouterGroup) {
-	c.DELETE("/", t.DeleteSelection)
-	c.POST("/", t.AddSelection)
-	c.GET("/", t.GetSelection)
+	read := requirePermission(models.PermissionSelectionsRead)
+	write := requirePermission(models.PermissionSelectionsWrite)
+	c.DELETE("/", write, t.DeleteSelection)
+	c.POST("/", write, t.AddSelection)
+	c.GET("/", read, t.GetSelection)
+	c.PATCH("/:selectionUUID", write, t.UpdateSelection)
+	c.POST("/:selectionUUID/extract", write, t.ExtractSelection)
 }
@@ -1,22 +1,32 @@
 package integration
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"image/png"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
 	v1 "pdf_service_api/controller/v1"
+	"pdf_service_api/models"
+	"pdf_service_api/pdf"
 	"pdf_service_api/postgres"
+	"pdf_service_api/storage"
 	"pdf_service_api/testutil"
 	"strings"
 	"testing"
+	"time"
 )
 
 var dbUser = "user"
@@ -39,7 +49,25 @@ func TestDocumentIntegration(t *testing.T) {
 	t.Run("Get document with nonexistent document uuid", getDocumentWithNonexistentDocumentUUID)
 	t.Run("Upload a new document", uploadDocument)
 	t.Run("Upload a new document with document title", uploadDocumentWithTitle)
+	t.Run("Upload a new document as a multipart form", uploadDocumentMultipart)
+	t.Run("Upload a new document as a raw pdf body", uploadDocumentRawPdf)
+	t.Run("Upload a raw body that is not a pdf", uploadDocumentRawNotPdf)
 	t.Run("Delete existing document", deleteDocument)
+	t.Run("Get document content", getDocumentContent)
+	t.Run("Get document content with a range header", getDocumentContentWithRange)
+	t.Run("Get document content with a different owner uuid", getDocumentContentWithWrongOwner)
+	t.Run("Upload and download a document using a blob store", uploadAndDownloadWithBlobStore)
+	t.Run("Upload a document and extract its meta", uploadDocumentExtractsMeta)
+	t.Run("Get the words on a page and store them", getPageWords)
+	t.Run("Get the words on a page with a different owner uuid", getPageWordsWithWrongOwner)
+	t.Run("Get the words on a page outside the document", getPageWordsOutOfRange)
+	t.Run("Render a page image and cache it", getPageImage)
+	t.Run("Render a page image with invalid parameters", getPageImageInvalidParameters)
+	t.Run("Upload a document and reference its thumbnails in meta", uploadDocumentReferencesThumbnails)
+	t.Run("Upload a document without a renderer and reference no thumbnails", uploadDocumentWithoutRendererReferencesNoThumbnails)
+	t.Run("Upload and get documents with bearer tokens", authenticatedUploadAndGet)
+	t.Run("Create a document with meta and selections", createDocumentWithDetails)
+	t.Run("Create a document with an invalid selection", createDocumentWithDetailsInvalidSelection)
 }
 
 func databaseConnection(t *testing.T) {
@@ -88,7 +116,7 @@ func getDocumentWithDocumentUUID(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
-	router := v1.SetupRouter(documentCtrl, nil, nil)
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
@@ -116,7 +144,7 @@ func getDocumentWithDocumentUUIDExcludeBase64(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
-	router := v1.SetupRouter(documentCtrl, nil, nil)
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
@@ -143,7 +171,7 @@ func getDocumentWithNoOwnerUuid(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
-	router := v1.SetupRouter(documentCtrl, nil, nil)
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
 
 	request := &v1.GetDocumentRequest{DocumentUUID: &documentTestUUID}
 	requestJSON, _ := json.Marshal(request)
@@ -175,7 +203,7 @@ func getDocumentWithNonexistentDocumentUUID(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
-	router := v1.SetupRouter(documentCtrl, nil, nil)
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
 
 	request := &v1.GetDocumentRequest{DocumentUUID: &documentTestUUID}
 	requestJSON, _ := json.Marshal(request)
@@ -205,7 +233,7 @@ func getDocumentWithOwnerUUID(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
-	router := v1.SetupRouter(documentCtrl, nil, nil)
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
@@ -232,7 +260,7 @@ func getDocumentWithOwnerUUIDWithLimit1AndOffset0(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
-	router := v1.SetupRouter(documentCtrl, nil, nil)
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
@@ -259,7 +287,7 @@ func getDocumentWithOwnerUUIDWithLimit1AndOffset1(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
-	router := v1.SetupRouter(documentCtrl, nil, nil)
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
@@ -286,7 +314,7 @@ func getDocumentWithOwnerUUIDWithLimit1AndOffset2(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
-	router := v1.SetupRouter(documentCtrl, nil, nil)
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
@@ -313,7 +341,7 @@ func getDocumentWithOwnerUUIDWithLimit1AndOffset10(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
-	router := v1.SetupRouter(documentCtrl, nil, nil)
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
@@ -340,7 +368,7 @@ func getDocumentWithOwnerUUIDWithLimit2AndOffset0(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
-	router := v1.SetupRouter(documentCtrl, nil, nil)
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
@@ -367,7 +395,7 @@ func getDocumentWithOwnerUUIDWithLimit2AndOffset1(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
-	router := v1.SetupRouter(documentCtrl, nil, nil)
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
@@ -394,7 +422,7 @@ func getDocumentWithOwnerUUIDWithExcludes(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
-	router := v1.SetupRouter(documentCtrl, nil, nil)
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
@@ -423,7 +451,7 @@ func uploadDocument(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
-	router := v1.SetupRouter(documentCtrl, nil, nil)
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
 	request := &v1.CreateRequest{DocumentBase64String: "THIS IS A TEST DOCUMENT"}
 	requestJSON, _ := json.Marshal(request)
 
@@ -454,7 +482,7 @@ func uploadDocumentWithTitle(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
-	router := v1.SetupRouter(documentCtrl, nil, nil)
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
 	request := &v1.CreateRequest{DocumentTitle: func() *string { v := "Document Title"; return &v }(), DocumentBase64String: "THIS IS A TEST DOCUMENT"}
 	requestJSON, _ := json.Marshal(request)
 
@@ -482,6 +510,131 @@ func uploadDocumentWithTitle(t *testing.T) {
 	assert.NotEqual(t, uuid.Nil, response.DocumentUUID)
 }
 
+func uploadDocumentMultipart(t *testing.T) {
+	t.Parallel()
+	ownerTestUUID := uuid.MustParse("ea167a48-c1b3-46c4-911b-090e807132fc")
+
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, dbUser, dbPassword)
+	require.NoError(t, err)
+
+	connectionString, err := ctr.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
+	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	require.NoError(t, writer.WriteField("ownerUUID", ownerTestUUID.String()))
+	require.NoError(t, writer.WriteField("ownerType", "1"))
+	part, err := writer.CreateFormFile("file", "Multipart Title.pdf")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("%PDF-1.4 THIS IS A TEST DOCUMENT"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	request := httptest.NewRequest("POST", "/api/v1/documents/", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+
+	response := UploadResponse{}
+	err = json.NewDecoder(w.Body).Decode(&response)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, w.Code, "Response should be 200")
+	assert.NotEqual(t, uuid.Nil, response.DocumentUUID)
+
+	err = dbHandle.WithConnection(func(db *sql.DB) error {
+		row := db.QueryRow(`SELECT "Document_Title", "Owner_UUID" FROM document_table WHERE "Document_UUID" = $1`, response.DocumentUUID)
+
+		var title, owner string
+		if err := row.Scan(&title, &owner); err != nil {
+			return err
+		}
+
+		assert.Equal(t, "Multipart Title", title)
+		assert.Equal(t, ownerTestUUID.String(), owner)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func uploadDocumentRawPdf(t *testing.T) {
+	t.Parallel()
+	ownerTestUUID := uuid.MustParse("ea167a48-c1b3-46c4-911b-090e807132fc")
+
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, dbUser, dbPassword)
+	require.NoError(t, err)
+
+	connectionString, err := ctr.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
+	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
+
+	request := httptest.NewRequest(
+		"POST",
+		"/api/v1/documents/?documentTitle=Raw%20Title&ownerUUID="+ownerTestUUID.String(),
+		strings.NewReader("%PDF-1.4 THIS IS A TEST DOCUMENT"),
+	)
+	request.Header.Set("Content-Type", "application/pdf")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+
+	response := UploadResponse{}
+	err = json.NewDecoder(w.Body).Decode(&response)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, w.Code, "Response should be 200")
+	assert.NotEqual(t, uuid.Nil, response.DocumentUUID)
+
+	err = dbHandle.WithConnection(func(db *sql.DB) error {
+		row := db.QueryRow(`SELECT "Document_Title", "Document_Base64" FROM document_table WHERE "Document_UUID" = $1`, response.DocumentUUID)
+
+		var title, pdfBase64 string
+		if err := row.Scan(&title, &pdfBase64); err != nil {
+			return err
+		}
+
+		assert.Equal(t, "Raw Title", title)
+		assert.Equal(t, "JVBERi0xLjQgVEhJUyBJUyBBIFRFU1QgRE9DVU1FTlQ=", pdfBase64)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func uploadDocumentRawNotPdf(t *testing.T) {
+	t.Parallel()
+	expectedResponse := `{"error":"uploaded content is not a PDF document"}`
+
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, dbUser, dbPassword)
+	require.NoError(t, err)
+
+	connectionString, err := ctr.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
+	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
+
+	request := httptest.NewRequest("POST", "/api/v1/documents/", strings.NewReader("THIS IS A TEST DOCUMENT"))
+	request.Header.Set("Content-Type", "application/pdf")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, expectedResponse, w.Body.String())
+}
+
 type DeleteResponse struct {
 	Success bool `json:"success"`
 }
@@ -497,7 +650,7 @@ func deleteDocument(t *testing.T) {
 	require.NoError(t, err)
 
 	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
-	router := v1.SetupRouter(documentCtrl, nil, nil)
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
 		"DELETE",
@@ -515,3 +668,624 @@ func deleteDocument(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code, "Response should be 200")
 	assert.True(t, response.Success)
 }
+
+func getDocumentContent(t *testing.T) {
+	t.Parallel()
+	documentTestUUID := "b66fd223-515f-4503-80cc-2bdaa50ef474"
+	ownerTestUUID := "ea167a48-c1b3-46c4-911b-090e807132fc"
+
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgresWithInitFileName(ctx, dbUser, dbPassword, "OneDocumentTableEntryWithPdfContent")
+	require.NoError(t, err)
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(
+		"GET",
+		fmt.Sprintf("/api/v1/documents/%s/content?ownerUUID=%s", documentTestUUID, ownerTestUUID),
+		nil,
+	))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Equal(t, "32", w.Header().Get("Content-Length"))
+	assert.Equal(t, `inline; filename="Fake Title.pdf"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "%PDF-1.4 THIS IS A TEST DOCUMENT", w.Body.String())
+}
+
+func getDocumentContentWithRange(t *testing.T) {
+	t.Parallel()
+	documentTestUUID := "b66fd223-515f-4503-80cc-2bdaa50ef474"
+	ownerTestUUID := "ea167a48-c1b3-46c4-911b-090e807132fc"
+
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgresWithInitFileName(ctx, dbUser, dbPassword, "OneDocumentTableEntryWithPdfContent")
+	require.NoError(t, err)
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
+
+	request := httptest.NewRequest(
+		"GET",
+		fmt.Sprintf("/api/v1/documents/%s/content?ownerUUID=%s", documentTestUUID, ownerTestUUID),
+		nil,
+	)
+	request.Header.Set("Range", "bytes=0-7")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "bytes 0-7/32", w.Header().Get("Content-Range"))
+	assert.Equal(t, "8", w.Header().Get("Content-Length"))
+	assert.Equal(t, "%PDF-1.4", w.Body.String())
+}
+
+func getDocumentContentWithWrongOwner(t *testing.T) {
+	t.Parallel()
+	documentTestUUID := "b66fd223-515f-4503-80cc-2bdaa50ef474"
+	expectedResponse := fmt.Sprintf(`{"error":"Document with documentUUID %s was not found."}`, documentTestUUID)
+
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgresWithInitFileName(ctx, dbUser, dbPassword, "OneDocumentTableEntryWithPdfContent")
+	require.NoError(t, err)
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(
+		"GET",
+		fmt.Sprintf("/api/v1/documents/%s/content?ownerUUID=%s", documentTestUUID, uuid.New().String()),
+		nil,
+	))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, expectedResponse, w.Body.String())
+}
+
+func uploadAndDownloadWithBlobStore(t *testing.T) {
+	t.Parallel()
+	ownerTestUUID := "ea167a48-c1b3-46c4-911b-090e807132fc"
+
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, dbUser, dbPassword)
+	require.NoError(t, err)
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	blobStore, err := storage.NewFilesystemBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepositoryWithBlobStore(dbHandle, blobStore)}
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
+
+	request := httptest.NewRequest(
+		"POST",
+		"/api/v1/documents/?ownerUUID="+ownerTestUUID,
+		strings.NewReader("%PDF-1.4 THIS IS A TEST DOCUMENT"),
+	)
+	request.Header.Set("Content-Type", "application/pdf")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	response := UploadResponse{}
+	err = json.NewDecoder(w.Body).Decode(&response)
+	require.NoError(t, err)
+
+	err = dbHandle.WithConnection(func(db *sql.DB) error {
+		row := db.QueryRow(`SELECT "Document_Base64", "Size_Bytes" FROM document_table WHERE "Document_UUID" = $1`, response.DocumentUUID)
+
+		var (
+			pdfBase64 *string
+			size      int64
+		)
+		if err := row.Scan(&pdfBase64, &size); err != nil {
+			return err
+		}
+
+		assert.Nil(t, pdfBase64, "Content should not be stored in the database")
+		assert.EqualValues(t, 32, size)
+		return nil
+	})
+	require.NoError(t, err)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(
+		"GET",
+		fmt.Sprintf("/api/v1/documents/%s/content?ownerUUID=%s", response.DocumentUUID, ownerTestUUID),
+		nil,
+	))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "%PDF-1.4 THIS IS A TEST DOCUMENT", w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(
+		"GET",
+		fmt.Sprintf("/api/v1/documents/?documentUUID=%s&ownerUUID=%s&exclude=timeCreated", response.DocumentUUID, ownerTestUUID),
+		nil,
+	))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"pdfBase64":"JVBERi0xLjQgVEhJUyBJUyBBIFRFU1QgRE9DVU1FTlQ="`)
+}
+
+func uploadDocumentExtractsMeta(t *testing.T) {
+	t.Parallel()
+	ownerTestUUID := "ea167a48-c1b3-46c4-911b-090e807132fc"
+
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, dbUser, dbPassword)
+	require.NoError(t, err)
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	documentCtrl := &v1.DocumentController{
+		DocumentRepository: postgres.NewDocumentRepository(dbHandle),
+		MetaRepository:     postgres.NewMetaRepository(dbHandle),
+	}
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
+
+	content := testutil.BuildTestPDF(testutil.LetterPage(), testutil.TestPage{MediaBox: [4]float64{0, 0, 842, 595}})
+	request := httptest.NewRequest("POST", "/api/v1/documents/?ownerUUID="+ownerTestUUID, bytes.NewReader(content))
+	request.Header.Set("Content-Type", "application/pdf")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	response := UploadResponse{}
+	err = json.NewDecoder(w.Body).Decode(&response)
+	require.NoError(t, err)
+
+	err = dbHandle.WithConnection(func(db *sql.DB) error {
+		row := db.QueryRow(`SELECT "Number_Of_Pages", "Height", "Width" FROM documentmeta_table WHERE "Document_UUID" = $1`, response.DocumentUUID)
+
+		var (
+			noPages int32
+			height  float32
+			width   float32
+		)
+		if err := row.Scan(&noPages, &height, &width); err != nil {
+			return err
+		}
+
+		assert.EqualValues(t, 2, noPages)
+		assert.EqualValues(t, 792, height)
+		assert.EqualValues(t, 612, width)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+// setupPageWords uploads a one page document containing a line of text and returns the router, database handle and
+// the UUID of the uploaded document.
+func setupPageWords(t *testing.T, ownerUUID string) (http.Handler, postgres.DatabaseHandler, uuid.UUID) {
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, dbUser, dbPassword)
+	require.NoError(t, err)
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	documentCtrl := &v1.DocumentController{
+		DocumentRepository:  postgres.NewDocumentRepository(dbHandle),
+		PageWordsRepository: postgres.NewPageWordsRepository(dbHandle),
+		Renderer:            pdf.NewRenderer(pdf.RendererConfig{}),
+	}
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
+
+	content := testutil.BuildTestPDF(testutil.LetterPage(testutil.TestText{X: 100, Y: 700, Size: 10, S: "Invoice number 42"}))
+	request := httptest.NewRequest("POST", "/api/v1/documents/?ownerUUID="+ownerUUID, bytes.NewReader(content))
+	request.Header.Set("Content-Type", "application/pdf")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	response := UploadResponse{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+
+	return router, dbHandle, response.DocumentUUID
+}
+
+func getPageWords(t *testing.T) {
+	t.Parallel()
+	ownerTestUUID := "ea167a48-c1b3-46c4-911b-090e807132fc"
+	router, dbHandle, documentUUID := setupPageWords(t, ownerTestUUID)
+
+	expectedWords := []models.TextBox{
+		{Text: "Invoice", X1: 100, Y1: 700, X2: 135, Y2: 710},
+		{Text: "number", X1: 140, Y1: 700, X2: 170, Y2: 710},
+		{Text: "42", X1: 175, Y1: 700, X2: 185, Y2: 710},
+	}
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/documents/"+documentUUID.String()+"/pages/1/words?ownerUUID="+ownerTestUUID, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		words := models.PageWords{}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&words))
+		assert.Equal(t, documentUUID, words.DocumentUUID)
+		assert.Equal(t, 1, words.PageNumber)
+		assert.Equal(t, expectedWords, words.Words)
+	}
+
+	err := dbHandle.WithConnection(func(db *sql.DB) error {
+		var count int
+		if err := db.QueryRow(`SELECT count(*) FROM document_page_words_table WHERE "Document_UUID" = $1`, documentUUID).Scan(&count); err != nil {
+			return err
+		}
+
+		assert.Equal(t, 1, count, "The words of a page should be stored once per document")
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func getPageWordsWithWrongOwner(t *testing.T) {
+	t.Parallel()
+	ownerTestUUID := "ea167a48-c1b3-46c4-911b-090e807132fc"
+	router, _, documentUUID := setupPageWords(t, ownerTestUUID)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/documents/"+documentUUID.String()+"/pages/1/words?ownerUUID="+ownerTestUUID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/documents/"+documentUUID.String()+"/pages/1/words?ownerUUID="+uuid.NewString(), nil))
+	assert.Equal(t, http.StatusNotFound, w.Code, "Stored words should not be returned to other owners")
+}
+
+func getPageWordsOutOfRange(t *testing.T) {
+	t.Parallel()
+	ownerTestUUID := "ea167a48-c1b3-46c4-911b-090e807132fc"
+	router, _, documentUUID := setupPageWords(t, ownerTestUUID)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/documents/"+documentUUID.String()+"/pages/2/words?ownerUUID="+ownerTestUUID, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/documents/"+documentUUID.String()+"/pages/0/words?ownerUUID="+ownerTestUUID, nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// requireRenderer skips tests rendering page images when the rasteriser is not installed.
+func requireRenderer(t *testing.T) {
+	if _, err := exec.LookPath("pdftoppm"); err != nil {
+		t.Skip("pdftoppm is not installed")
+	}
+}
+
+func getPageImage(t *testing.T) {
+	t.Parallel()
+	requireRenderer(t)
+	ownerTestUUID := "ea167a48-c1b3-46c4-911b-090e807132fc"
+
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, dbUser, dbPassword)
+	require.NoError(t, err)
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	blobStore, err := storage.NewFilesystemBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	documentCtrl := &v1.DocumentController{
+		DocumentRepository:  postgres.NewDocumentRepositoryWithBlobStore(dbHandle, blobStore),
+		PageImageRepository: postgres.NewPageImageRepository(dbHandle, blobStore),
+		Renderer:            pdf.NewRenderer(pdf.RendererConfig{}),
+	}
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
+
+	content := testutil.BuildTestPDF(testutil.LetterPage(testutil.TestText{X: 100, Y: 700, Size: 30, S: "Hello"}))
+	request := httptest.NewRequest("POST", "/api/v1/documents/?ownerUUID="+ownerTestUUID, bytes.NewReader(content))
+	request.Header.Set("Content-Type", "application/pdf")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	response := UploadResponse{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	imageUrl := "/api/v1/documents/" + response.DocumentUUID.String() + "/pages/1/image?dpi=72&ownerUUID=" + ownerTestUUID
+
+	var rendered []byte
+	for i := 0; i < 2; i++ {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", imageUrl, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+
+		img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, 612, img.Bounds().Dx())
+		assert.Equal(t, 792, img.Bounds().Dy())
+
+		if rendered != nil {
+			assert.Equal(t, rendered, w.Body.Bytes(), "The second request should be served from the cache")
+		}
+		rendered = w.Body.Bytes()
+	}
+
+	var storageKey string
+	err = dbHandle.WithConnection(func(db *sql.DB) error {
+		return db.QueryRow(`SELECT "Storage_Key" FROM document_page_image_table WHERE "Document_UUID" = $1 AND "Page_Number" = 1 AND "Dpi" = 72 AND "Format" = 'png'`, response.DocumentUUID).Scan(&storageKey)
+	})
+	require.NoError(t, err)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/documents/"+response.DocumentUUID.String()+"/pages/1/image?dpi=72&ownerUUID="+uuid.NewString(), nil))
+	assert.Equal(t, http.StatusNotFound, w.Code, "Cached images should not be returned to other owners")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/v1/documents/?documentUUID="+response.DocumentUUID.String()+"&ownerUUID="+ownerTestUUID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	_, err = blobStore.Open(ctx, storageKey)
+	assert.ErrorIs(t, err, models.ErrBlobNotFound, "Rendered images should be removed with their document")
+}
+
+func getPageImageInvalidParameters(t *testing.T) {
+	t.Parallel()
+	requireRenderer(t)
+	ownerTestUUID := "ea167a48-c1b3-46c4-911b-090e807132fc"
+	router, _, documentUUID := setupPageWords(t, ownerTestUUID)
+	pageUrl := "/api/v1/documents/" + documentUUID.String() + "/pages/"
+
+	tests := map[string]int{
+		"1/image?format=gif&ownerUUID=" + ownerTestUUID: http.StatusBadRequest,
+		"1/image?dpi=5000&ownerUUID=" + ownerTestUUID:   http.StatusBadRequest,
+		"1/image?dpi=97&ownerUUID=" + ownerTestUUID:     http.StatusBadRequest,
+		"1/image":                            http.StatusBadRequest,
+		"2/image?ownerUUID=" + ownerTestUUID: http.StatusNotFound,
+		"1/image?format=jpg&ownerUUID=" + ownerTestUUID: http.StatusOK,
+	}
+
+	for path, expectedStatus := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", pageUrl+path, nil))
+		assert.Equal(t, expectedStatus, w.Code, path)
+	}
+}
+
+func uploadDocumentReferencesThumbnails(t *testing.T) {
+	t.Parallel()
+	ownerTestUUID := "ea167a48-c1b3-46c4-911b-090e807132fc"
+
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, dbUser, dbPassword)
+	require.NoError(t, err)
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	metaRepository := postgres.NewMetaRepository(dbHandle)
+	documentCtrl := &v1.DocumentController{
+		DocumentRepository: postgres.NewDocumentRepository(dbHandle),
+		MetaRepository:     metaRepository,
+		Renderer:           pdf.NewRenderer(pdf.RendererConfig{}),
+	}
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
+
+	content := testutil.BuildTestPDF(testutil.LetterPage(), testutil.LetterPage())
+	request := httptest.NewRequest("POST", "/api/v1/documents/?ownerUUID="+ownerTestUUID, bytes.NewReader(content))
+	request.Header.Set("Content-Type", "application/pdf")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	response := UploadResponse{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+
+	meta, err := metaRepository.GetMeta(context.Background(), response.DocumentUUID, uuid.MustParse(ownerTestUUID))
+	require.NoError(t, err)
+	require.NotNil(t, meta.Images)
+
+	assert.Equal(t, map[uint32]string{
+		1: "/api/v1/documents/" + response.DocumentUUID.String() + "/pages/1/image?dpi=24&format=png",
+		2: "/api/v1/documents/" + response.DocumentUUID.String() + "/pages/2/image?dpi=24&format=png",
+	}, *meta.Images)
+}
+
+func uploadDocumentWithoutRendererReferencesNoThumbnails(t *testing.T) {
+	t.Parallel()
+	ownerTestUUID := "ea167a48-c1b3-46c4-911b-090e807132fc"
+
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, dbUser, dbPassword)
+	require.NoError(t, err)
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	metaRepository := postgres.NewMetaRepository(dbHandle)
+	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle), MetaRepository: metaRepository}
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
+
+	content := testutil.BuildTestPDF(testutil.LetterPage(), testutil.LetterPage())
+	request := httptest.NewRequest("POST", "/api/v1/documents/?ownerUUID="+ownerTestUUID, bytes.NewReader(content))
+	request.Header.Set("Content-Type", "application/pdf")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	response := UploadResponse{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+
+	meta, err := metaRepository.GetMeta(context.Background(), response.DocumentUUID, uuid.MustParse(ownerTestUUID))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, *meta.NumberOfPages)
+	assert.Nil(t, meta.Images, "Thumbnails cannot be served without a renderer")
+}
+
+func bearerToken(t *testing.T, secret []byte, owner uuid.UUID, ownerType int) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":        owner.String(),
+		"owner_type": ownerType,
+		"exp":        time.Now().Add(time.Hour).Unix(),
+	}).SignedString(secret)
+	require.NoError(t, err)
+	return token
+}
+
+func authenticatedUploadAndGet(t *testing.T) {
+	t.Parallel()
+	secret := []byte("integration-secret")
+	owner := uuid.New()
+	otherOwner := uuid.New()
+
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, dbUser, dbPassword)
+	require.NoError(t, err)
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	middleware, err := v1.NewJWTMiddleware(v1.JWTConfig{HMACSecret: secret})
+	require.NoError(t, err)
+
+	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil, middleware)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/documents/?ownerUUID="+owner.String(), nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// The owner in the request body is ignored in favour of the token's subject.
+	requestJSON, _ := json.Marshal(&v1.CreateRequest{DocumentBase64String: "THIS IS A TEST DOCUMENT", OwnerUUID: &otherOwner})
+	uploadRequest := httptest.NewRequest("POST", "/api/v1/documents/", bytes.NewReader(requestJSON))
+	uploadRequest.Header.Set("Authorization", "Bearer "+bearerToken(t, secret, owner, 3))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, uploadRequest)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	response := UploadResponse{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+
+	getRequest := httptest.NewRequest("GET", "/api/v1/documents/?documentUUID="+response.DocumentUUID.String(), nil)
+	getRequest.Header.Set("Authorization", "Bearer "+bearerToken(t, secret, owner, 3))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, getRequest)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	documents := struct {
+		Documents []models.Document `json:"documents"`
+	}{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&documents))
+	require.Len(t, documents.Documents, 1)
+	assert.Equal(t, owner, *documents.Documents[0].OwnerUUID)
+	assert.Equal(t, 3, *documents.Documents[0].OwnerType)
+
+	// Another owner cannot read the document, even when naming the real owner in the query string.
+	getRequest = httptest.NewRequest("GET", "/api/v1/documents/?documentUUID="+response.DocumentUUID.String()+"&ownerUUID="+owner.String(), nil)
+	getRequest.Header.Set("Authorization", "Bearer "+bearerToken(t, secret, otherOwner, 1))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, getRequest)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func createDocumentWithDetails(t *testing.T) {
+	t.Parallel()
+	ownerTestUUID := uuid.New()
+
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, dbUser, dbPassword)
+	require.NoError(t, err)
+	t.Cleanup(testutil.CleanUp(ctx, *ctr))
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	documentCtrl := &v1.DocumentController{
+		DocumentRepository: postgres.NewDocumentRepository(dbHandle),
+		MetaRepository:     postgres.NewMetaRepository(dbHandle),
+	}
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
+
+	content := base64.StdEncoding.EncodeToString(testutil.BuildTestPDF(testutil.LetterPage(), testutil.LetterPage()))
+	body := `{"documentBase64String": "` + content + `", "documentTitle": "Composite", "ownerUUID": "` + ownerTestUUID.String() + `",
+"selections": [{"isComplete": true, "selectionBounds": {"1": [{"x1": 1, "x2": 2, "y1": 3, "y2": 4}]}}, {}]}`
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/documents/composite", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	response := v1.CreateDocumentWithDetailsResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.SelectionUUIDs, 2)
+
+	meta, err := postgres.NewMetaRepository(dbHandle).GetMeta(ctx, response.DocumentUUID, ownerTestUUID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, *meta.NumberOfPages, "meta is extracted when it is not given")
+
+	selections, err := postgres.NewSelectionRepository(dbHandle).GetSelectionsByDocumentUUID(ctx, response.DocumentUUID, ownerTestUUID, map[string]bool{})
+	require.NoError(t, err)
+	selectionUUIDs := make([]uuid.UUID, 0, len(selections))
+	for _, selection := range selections {
+		selectionUUIDs = append(selectionUUIDs, selection.Uuid)
+	}
+	assert.ElementsMatch(t, response.SelectionUUIDs, selectionUUIDs)
+}
+
+func createDocumentWithDetailsInvalidSelection(t *testing.T) {
+	t.Parallel()
+	ownerTestUUID := uuid.New()
+
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, dbUser, dbPassword)
+	require.NoError(t, err)
+	t.Cleanup(testutil.CleanUp(ctx, *ctr))
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle)}
+	router := v1.SetupRouter(documentCtrl, nil, nil, nil)
+
+	content := base64.StdEncoding.EncodeToString(testutil.BuildTestPDF(testutil.LetterPage()))
+	body := `{"documentBase64String": "` + content + `", "ownerUUID": "` + ownerTestUUID.String() + `",
+"meta": {"numberOfPages": 1}, "selections": [{}, {"settings": "not json"}]}`
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/documents/composite", strings.NewReader(body)))
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	documents, err := postgres.NewDocumentRepository(dbHandle).GetDocumentByOwnerUUID(ctx, ownerTestUUID, 10, 0, false, map[string]bool{})
+	require.NoError(t, err)
+	assert.Empty(t, documents, "no document is created when one of its selections cannot be")
+
+	body = `{"documentBase64String": "` + content + `", "selections": [{}]}`
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/documents/composite", strings.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code, "selections need a document owner")
+
+	body = `{"documentBase64String": "` + base64.StdEncoding.EncodeToString([]byte("not a pdf")) + `"}`
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/documents/composite", strings.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
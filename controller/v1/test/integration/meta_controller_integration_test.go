@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -17,11 +18,16 @@ import (
 	"testing"
 )
 
+// metaOwnerUUID owns the document of the OneDocumentTableEntryTwoSelectionsAndMetaData fixture.
+const metaOwnerUUID = "ea167a48-c1b3-46c4-911b-090e807132fc"
+
 func TestMetaIntegration(t *testing.T) {
 	t.Parallel()
 	t.Run("get meta using a present uuid", getMetaPresentUUID)
 	t.Run("update meta using a present uuid", updateMetaPresentUUID)
-	t.Run("update meta using a present uuid with new images", updateImageMetaPresentUUID)
+	t.Run("update meta using a present uuid ignores client images", updateImageMetaPresentUUID)
+	t.Run("get meta returns the pages of an uploaded document", getMetaReturnsPages)
+	t.Run("access meta of another owner's document", accessMetaOfAnotherOwner)
 }
 
 func getMetaPresentUUID(t *testing.T) {
@@ -44,12 +50,12 @@ func getMetaPresentUUID(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	metaCtrl := &v1.MetaController{MetaRepository: postgres.NewMetaRepository(dbHandle)}
-	router := v1.SetupRouter(nil, nil, metaCtrl)
+	router := v1.SetupRouter(nil, nil, metaCtrl, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
 		"GET",
-		"/api/v1/meta/?documentUUID="+expectedObj.DocumentUUID.String(),
+		"/api/v1/meta/?documentUUID="+expectedObj.DocumentUUID.String()+"&ownerUUID="+metaOwnerUUID,
 		nil,
 	))
 
@@ -70,7 +76,7 @@ func updateMetaPresentUUID(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	metaCtrl := &v1.MetaController{MetaRepository: postgres.NewMetaRepository(dbHandle)}
-	router := v1.SetupRouter(nil, nil, metaCtrl)
+	router := v1.SetupRouter(nil, nil, metaCtrl, nil)
 
 	newData := models.Meta{
 		DocumentUUID:  uuid.MustParse(testUUID),
@@ -86,7 +92,7 @@ func updateMetaPresentUUID(t *testing.T) {
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
 		"PUT",
-		"/api/v1/meta/?documentUUID="+testUUID,
+		"/api/v1/meta/?documentUUID="+testUUID+"&ownerUUID="+metaOwnerUUID,
 		strings.NewReader(string(requestJSON)),
 	))
 
@@ -122,7 +128,6 @@ func updateMetaPresentUUID(t *testing.T) {
 func updateImageMetaPresentUUID(t *testing.T) {
 	t.Parallel()
 	testUUID := "b66fd223-515f-4503-80cc-2bdaa50ef474"
-	expectedStr := `{"0":"Image0","1":"Image1","2":"Image2"}`
 
 	ctx := context.Background()
 	ctr, err := testutil.CreateTestContainerPostgresWithInitFileName(ctx, dbUser, dbPassword, "OneDocumentTableEntryTwoSelectionsAndMetaData")
@@ -133,7 +138,7 @@ func updateImageMetaPresentUUID(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	metaCtrl := &v1.MetaController{MetaRepository: postgres.NewMetaRepository(dbHandle)}
-	router := v1.SetupRouter(nil, nil, metaCtrl)
+	router := v1.SetupRouter(nil, nil, metaCtrl, nil)
 
 	strArr := make(map[uint32]string, 0)
 	strArr[0] = "Image0"
@@ -154,7 +159,7 @@ func updateImageMetaPresentUUID(t *testing.T) {
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
 		"PUT",
-		"/api/v1/meta/?documentUUID="+testUUID,
+		"/api/v1/meta/?documentUUID="+testUUID+"&ownerUUID="+metaOwnerUUID,
 		strings.NewReader(string(requestJSON)),
 	))
 
@@ -168,7 +173,7 @@ func updateImageMetaPresentUUID(t *testing.T) {
 			noPages int32
 			height  float32
 			width   float32
-			images  string
+			images  sql.NullString
 		)
 
 		err := row.Scan(&uid, &noPages, &height, &width, &images)
@@ -183,7 +188,7 @@ func updateImageMetaPresentUUID(t *testing.T) {
 		assert.EqualValues(t, height, 1920)
 		assert.NotNil(t, width)
 		assert.EqualValues(t, width, 1080)
-		assert.Equal(t, images, expectedStr)
+		assert.False(t, images.Valid, "Images reference rendered thumbnails and should not be set by clients")
 
 		return nil
 	})
@@ -192,3 +197,98 @@ func updateImageMetaPresentUUID(t *testing.T) {
 	}
 
 }
+
+func getMetaReturnsPages(t *testing.T) {
+	t.Parallel()
+	ownerTestUUID := "ea167a48-c1b3-46c4-911b-090e807132fc"
+
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, dbUser, dbPassword)
+	require.NoError(t, err)
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	metaRepository := postgres.NewMetaRepository(dbHandle)
+	documentCtrl := &v1.DocumentController{DocumentRepository: postgres.NewDocumentRepository(dbHandle), MetaRepository: metaRepository}
+	metaCtrl := &v1.MetaController{MetaRepository: metaRepository}
+	router := v1.SetupRouter(documentCtrl, nil, metaCtrl, nil)
+
+	content := testutil.BuildTestPDF(
+		testutil.LetterPage(),
+		testutil.TestPage{MediaBox: [4]float64{0, 0, 612, 792}, CropBox: &[4]float64{10, 20, 510, 720}, Rotate: 270, UserUnit: 2},
+	)
+	request := httptest.NewRequest("POST", "/api/v1/documents/?ownerUUID="+ownerTestUUID, bytes.NewReader(content))
+	request.Header.Set("Content-Type", "application/pdf")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	response := UploadResponse{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/meta/?documentUUID="+response.DocumentUUID.String()+"&ownerUUID="+ownerTestUUID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	meta := models.Meta{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&meta))
+	require.NotNil(t, meta.Pages)
+	require.Len(t, *meta.Pages, 2)
+
+	assert.Equal(t, models.PageMeta{
+		PageNumber: 1,
+		MediaBox:   [4]float64{0, 0, 612, 792},
+		CropBox:    [4]float64{0, 0, 612, 792},
+		Rotation:   0,
+		UserUnit:   1,
+	}, (*meta.Pages)[0])
+	assert.Equal(t, models.PageMeta{
+		PageNumber: 2,
+		MediaBox:   [4]float64{0, 0, 612, 792},
+		CropBox:    [4]float64{10, 20, 510, 720},
+		Rotation:   270,
+		UserUnit:   2,
+	}, (*meta.Pages)[1])
+}
+
+func accessMetaOfAnotherOwner(t *testing.T) {
+	t.Parallel()
+	testUUID := "b66fd223-515f-4503-80cc-2bdaa50ef474"
+	otherOwnerUUID := uuid.NewString()
+
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgresWithInitFileName(ctx, dbUser, dbPassword, "OneDocumentTableEntryTwoSelectionsAndMetaData")
+	require.NoError(t, err)
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	metaCtrl := &v1.MetaController{MetaRepository: postgres.NewMetaRepository(dbHandle)}
+	router := v1.SetupRouter(nil, nil, metaCtrl, nil)
+
+	requests := map[string]*http.Request{
+		"get":    httptest.NewRequest("GET", "/api/v1/meta/?documentUUID="+testUUID+"&ownerUUID="+otherOwnerUUID, nil),
+		"add":    httptest.NewRequest("POST", "/api/v1/meta/?ownerUUID="+otherOwnerUUID, strings.NewReader(`{"UUID": "`+testUUID+`", "NumberOfPages": 1}`)),
+		"update": httptest.NewRequest("PUT", "/api/v1/meta/?documentUUID="+testUUID+"&ownerUUID="+otherOwnerUUID, strings.NewReader(`{"NumberOfPages": 1}`)),
+		"delete": httptest.NewRequest("DELETE", "/api/v1/meta/?ownerUUID="+otherOwnerUUID, strings.NewReader(`{"UUID": "`+testUUID+`"}`)),
+	}
+
+	for name, request := range requests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusNotFound, w.Code, name)
+		assert.Equal(t, `{"error":"Meta with documentUUID `+testUUID+` was not found."}`, w.Body.String(), name)
+	}
+
+	// The owner still sees the metadata, unchanged.
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/meta/?documentUUID="+testUUID+"&ownerUUID="+metaOwnerUUID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	meta := models.Meta{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&meta))
+	require.NotNil(t, meta.NumberOfPages)
+	assert.EqualValues(t, 31, *meta.NumberOfPages)
+}
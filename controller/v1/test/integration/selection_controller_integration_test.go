@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -12,12 +13,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	v1 "pdf_service_api/controller/v1"
+	"pdf_service_api/models"
 	"pdf_service_api/postgres"
 	"pdf_service_api/testutil"
 	"strings"
 	"testing"
 )
 
+// selectionOwnerUUID owns the document of the OneDocumentTableEntryAndTwoSelections fixture.
+const selectionOwnerUUID = "ea167a48-c1b3-46c4-911b-090e807132fc"
+
 func TestSelectionsIntegration(t *testing.T) {
 	t.Parallel()
 	t.Run("Get selection from a present document uuid", getSelectionsFromPresentDocumentUUID)
@@ -28,12 +33,23 @@ func TestSelectionsIntegration(t *testing.T) {
 	t.Run("Delete selections by present document uuid", deleteSelectionsByDocumentUUID)
 	t.Run("Delete selections by nonexistent selection uuid", deleteDelectionByNonexistentSelectionUUID)
 	t.Run("Create new selection", createNewSelection)
+	t.Run("Get the full selection model", getSelectionFullModel)
+	t.Run("Get selections with chosen fields", getSelectionWithFields)
+	t.Run("Get selections with excluded fields", getSelectionWithExclude)
+	t.Run("Update a selection's completion state and settings", updateSelectionStateAndSettings)
+	t.Run("Update the bounds of single pages of a selection", updateSelectionBoundsPerPage)
+	t.Run("Update a nonexistent selection", updateNonexistentSelection)
+	t.Run("Update a selection without any fields", updateSelectionWithoutFields)
+	t.Run("Extract the text inside a selection", extractSelectionText)
+	t.Run("Extract a selection with an unknown method", extractSelectionUnknownMethod)
+	t.Run("Extract a selection with the wrong owner", extractSelectionWrongOwner)
+	t.Run("Access selections of another owner's document", accessSelectionsOfAnotherOwner)
 }
 
 func getSelectionFromPresentSelectionUUID(t *testing.T) {
 	t.Parallel()
 	testDocumentUuidString := "a5fdea38-0a86-4c19-ae4f-c87a01bc860d"
-	expectedJsonResponse := `{"selections":[{"selectionUUID":"a5fdea38-0a86-4c19-ae4f-c87a01bc860d","documentUUID":"b66fd223-515f-4503-80cc-2bdaa50ef474"}]}`
+	expectedJsonResponse := `{"selections":[{"selectionUUID":"a5fdea38-0a86-4c19-ae4f-c87a01bc860d","documentUUID":"b66fd223-515f-4503-80cc-2bdaa50ef474","isComplete":false}]}`
 
 	ctx := context.Background()
 	ctr, err := testutil.CreateTestContainerPostgresWithInitFileName(ctx, dbUser, dbPassword, "OneDocumentTableEntryAndTwoSelections")
@@ -44,12 +60,12 @@ func getSelectionFromPresentSelectionUUID(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	selectionCtrl := &v1.SelectionController{SelectionRepository: postgres.NewSelectionRepository(dbHandle)}
-	router := v1.SetupRouter(nil, selectionCtrl, nil)
+	router := v1.SetupRouter(nil, selectionCtrl, nil, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
 		"GET",
-		fmt.Sprintf("/api/v1/selections/?selectionUUID=%s", testDocumentUuidString),
+		fmt.Sprintf("/api/v1/selections/?selectionUUID=%s&ownerUUID=%s", testDocumentUuidString, selectionOwnerUUID),
 		nil,
 	))
 
@@ -63,7 +79,7 @@ func getSelectionFromPresentSelectionUUID(t *testing.T) {
 func getSelectionsFromPresentDocumentUUID(t *testing.T) {
 	t.Parallel()
 	testDocumentUuidString := "b66fd223-515f-4503-80cc-2bdaa50ef474"
-	expectedJsonResponse := `{"selections":[{"selectionUUID":"a5fdea38-0a86-4c19-ae4f-c87a01bc860d","documentUUID":"b66fd223-515f-4503-80cc-2bdaa50ef474"},{"selectionUUID":"335a6b95-6707-4e2b-9c37-c76d017f6f97","documentUUID":"b66fd223-515f-4503-80cc-2bdaa50ef474"}]}`
+	expectedJsonResponse := `{"selections":[{"selectionUUID":"a5fdea38-0a86-4c19-ae4f-c87a01bc860d","documentUUID":"b66fd223-515f-4503-80cc-2bdaa50ef474","isComplete":false},{"selectionUUID":"335a6b95-6707-4e2b-9c37-c76d017f6f97","documentUUID":"b66fd223-515f-4503-80cc-2bdaa50ef474","isComplete":false}]}`
 
 	ctx := context.Background()
 	ctr, err := testutil.CreateTestContainerPostgresWithInitFileName(ctx, dbUser, dbPassword, "OneDocumentTableEntryAndTwoSelections")
@@ -74,12 +90,12 @@ func getSelectionsFromPresentDocumentUUID(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	selectionCtrl := &v1.SelectionController{SelectionRepository: postgres.NewSelectionRepository(dbHandle)}
-	router := v1.SetupRouter(nil, selectionCtrl, nil)
+	router := v1.SetupRouter(nil, selectionCtrl, nil, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
 		"GET",
-		fmt.Sprintf("/api/v1/selections/?documentUUID=%s", testDocumentUuidString),
+		fmt.Sprintf("/api/v1/selections/?documentUUID=%s&ownerUUID=%s", testDocumentUuidString, selectionOwnerUUID),
 		nil,
 	))
 
@@ -92,7 +108,7 @@ func getSelectionsFromPresentDocumentUUID(t *testing.T) {
 func getSelectionsFromNonExistentDocumentUUID(t *testing.T) {
 	t.Parallel()
 	testDocumentUuidString := uuid.Nil.String()
-	expectedJsonResponse := `{"selections":[]}`
+	expectedJsonResponse := fmt.Sprintf(`{"error":"Selection with documentUUID %s was not found."}`, testDocumentUuidString)
 
 	ctx := context.Background()
 	ctr, err := testutil.CreateTestContainerPostgresWithInitFileName(ctx, dbUser, dbPassword, "OneDocumentTableEntryAndTwoSelections")
@@ -103,25 +119,23 @@ func getSelectionsFromNonExistentDocumentUUID(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	selectionCtrl := &v1.SelectionController{SelectionRepository: postgres.NewSelectionRepository(dbHandle)}
-	router := v1.SetupRouter(nil, selectionCtrl, nil)
+	router := v1.SetupRouter(nil, selectionCtrl, nil, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
 		"GET",
-		fmt.Sprintf("/api/v1/selections/?documentUUID=%s", testDocumentUuidString),
+		fmt.Sprintf("/api/v1/selections/?documentUUID=%s&ownerUUID=%s", testDocumentUuidString, selectionOwnerUUID),
 		nil,
 	))
 
-	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
-	assert.NotNil(t, w.Body.String())
-	assert.NotContains(t, w.Body.String(), "Error")
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
 	assert.Equal(t, expectedJsonResponse, w.Body.String(), "Body does not match expected output.")
 }
 
 func getSelectionsFromInvalidDocumentUUID(t *testing.T) {
 	t.Parallel()
 	testDocumentUuidString := uuid.New().String()
-	expectedJsonResponse := `{"selections":[]}`
+	expectedJsonResponse := fmt.Sprintf(`{"error":"Selection with documentUUID %s was not found."}`, testDocumentUuidString)
 
 	ctx := context.Background()
 	ctr, err := testutil.CreateTestContainerPostgresWithInitFileName(ctx, dbUser, dbPassword, "OneDocumentTableEntryAndTwoSelections")
@@ -132,19 +146,17 @@ func getSelectionsFromInvalidDocumentUUID(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	selectionCtrl := &v1.SelectionController{SelectionRepository: postgres.NewSelectionRepository(dbHandle)}
-	router := v1.SetupRouter(nil, selectionCtrl, nil)
+	router := v1.SetupRouter(nil, selectionCtrl, nil, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
 		"GET",
-		fmt.Sprintf("/api/v1/selections/?documentUUID=%s", testDocumentUuidString),
+		fmt.Sprintf("/api/v1/selections/?documentUUID=%s&ownerUUID=%s", testDocumentUuidString, selectionOwnerUUID),
 		nil,
 	))
 
 	fmt.Println(w.Body.String())
-	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
-	assert.NotNil(t, w.Body.String())
-	assert.NotContains(t, w.Body.String(), "Error")
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
 	assert.Equal(t, expectedJsonResponse, w.Body.String(), "Body does not match expected output.")
 }
 
@@ -160,12 +172,12 @@ func deleteSelectionsBySelectionUUID(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	selectionCtrl := &v1.SelectionController{SelectionRepository: postgres.NewSelectionRepository(dbHandle)}
-	router := v1.SetupRouter(nil, selectionCtrl, nil)
+	router := v1.SetupRouter(nil, selectionCtrl, nil, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
 		"DELETE",
-		fmt.Sprintf("/api/v1/selections/?selectionUUID=%s", "a5fdea38-0a86-4c19-ae4f-c87a01bc860d"),
+		fmt.Sprintf("/api/v1/selections/?selectionUUID=%s&ownerUUID=%s", "a5fdea38-0a86-4c19-ae4f-c87a01bc860d", selectionOwnerUUID),
 		nil,
 	))
 
@@ -186,12 +198,12 @@ func deleteSelectionsByDocumentUUID(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	selectionCtrl := &v1.SelectionController{SelectionRepository: postgres.NewSelectionRepository(dbHandle)}
-	router := v1.SetupRouter(nil, selectionCtrl, nil)
+	router := v1.SetupRouter(nil, selectionCtrl, nil, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
 		"DELETE",
-		fmt.Sprintf("/api/v1/selections/?documentUUID=%s", documentTestUUID),
+		fmt.Sprintf("/api/v1/selections/?documentUUID=%s&ownerUUID=%s", documentTestUUID, selectionOwnerUUID),
 		nil,
 	))
 
@@ -224,17 +236,17 @@ func deleteDelectionByNonexistentSelectionUUID(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	selectionCtrl := &v1.SelectionController{SelectionRepository: postgres.NewSelectionRepository(dbHandle)}
-	router := v1.SetupRouter(nil, selectionCtrl, nil)
+	router := v1.SetupRouter(nil, selectionCtrl, nil, nil)
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
 		"DELETE",
-		fmt.Sprintf("/api/v1/selections/?selectionUUID=%s", uuid.New().String()),
+		fmt.Sprintf("/api/v1/selections/?selectionUUID=%s&ownerUUID=%s", uuid.New().String(), selectionOwnerUUID),
 		nil,
 	))
 
 	fmt.Println(w.Body.String())
-	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
 }
 
 func createNewSelection(t *testing.T) {
@@ -250,7 +262,7 @@ func createNewSelection(t *testing.T) {
 
 	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
 	selectionCtrl := &v1.SelectionController{SelectionRepository: postgres.NewSelectionRepository(dbHandle)}
-	router := v1.SetupRouter(nil, selectionCtrl, nil)
+	router := v1.SetupRouter(nil, selectionCtrl, nil, nil)
 
 	request := &v1.AddNewSelectionRequest{
 		DocumentUUID:    func() *uuid.UUID { v := uuid.MustParse(documentTestUUID); return &v }(),
@@ -263,10 +275,268 @@ func createNewSelection(t *testing.T) {
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, httptest.NewRequest(
 		"POST",
-		"/api/v1/selections/",
+		"/api/v1/selections/?ownerUUID="+selectionOwnerUUID,
 		strings.NewReader(string(requestJSON)),
 	))
 
 	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
 	assert.NotContains(t, w.Body.String(), "Error")
 }
+
+// setupExtraction uploads a one page document containing two lines of text, and creates a selection on its first page
+// with the given bounds. It returns the router and the UUID of the selection.
+func setupExtraction(t *testing.T, ownerUUID string, bounds ...models.SelectionBounds) (http.Handler, uuid.UUID) {
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, dbUser, dbPassword)
+	require.NoError(t, err)
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	documentRepository := postgres.NewDocumentRepository(dbHandle)
+	documentCtrl := &v1.DocumentController{DocumentRepository: documentRepository}
+	selectionCtrl := &v1.SelectionController{SelectionRepository: postgres.NewSelectionRepository(dbHandle), DocumentRepository: documentRepository}
+	router := v1.SetupRouter(documentCtrl, selectionCtrl, nil, nil)
+
+	content := testutil.BuildTestPDF(testutil.LetterPage(
+		testutil.TestText{X: 100, Y: 700, Size: 10, S: "Invoice number 42"},
+		testutil.TestText{X: 100, Y: 680, Size: 10, S: "Total due"},
+	))
+	request := httptest.NewRequest("POST", "/api/v1/documents/?ownerUUID="+ownerUUID, bytes.NewReader(content))
+	request.Header.Set("Content-Type", "application/pdf")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	uploaded := struct {
+		DocumentUUID uuid.UUID `json:"documentUUID"`
+	}{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&uploaded))
+
+	selectionRequest, err := json.Marshal(v1.AddNewSelectionRequest{
+		DocumentUUID:    &uploaded.DocumentUUID,
+		SelectionBounds: &map[int][]models.SelectionBounds{1: bounds},
+	})
+	require.NoError(t, err)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/selections/?ownerUUID="+ownerUUID, bytes.NewReader(selectionRequest)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	created := struct {
+		SelectionUUID uuid.UUID `json:"selectionUUID"`
+	}{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+
+	return router, created.SelectionUUID
+}
+
+func extractSelectionText(t *testing.T) {
+	t.Parallel()
+	ownerUUID := "ea167a48-c1b3-46c4-911b-090e807132fc"
+	words := "words"
+
+	router, selectionUUID := setupExtraction(t, ownerUUID,
+		models.SelectionBounds{X1: 90, Y1: 670, X2: 300, Y2: 720},
+		models.SelectionBounds{SelectionMethod: &words, X1: 90, Y1: 675, X2: 300, Y2: 695},
+	)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/selections/"+selectionUUID.String()+"/extract?ownerUUID="+ownerUUID, nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	response := v1.ExtractSelectionResponse{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	require.Len(t, response.Extraction[1], 2)
+
+	assert.Equal(t, selectionUUID, response.SelectionUUID)
+	assert.Equal(t, "Invoice number 42\nTotal due", response.Extraction[1][0].Text)
+	assert.Equal(t, "Total due", response.Extraction[1][1].Text)
+	require.NotNil(t, response.Extraction[1][1].Words)
+	assert.Equal(t, []models.TextBox{
+		{Text: "Total", X1: 100, Y1: 680, X2: 125, Y2: 690},
+		{Text: "due", X1: 130, Y1: 680, X2: 145, Y2: 690},
+	}, *response.Extraction[1][1].Words)
+}
+
+func extractSelectionUnknownMethod(t *testing.T) {
+	t.Parallel()
+	ownerUUID := "ea167a48-c1b3-46c4-911b-090e807132fc"
+	ocr := "ocr"
+
+	router, selectionUUID := setupExtraction(t, ownerUUID, models.SelectionBounds{SelectionMethod: &ocr, X2: 612, Y2: 792})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/selections/"+selectionUUID.String()+"/extract?ownerUUID="+ownerUUID, nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func extractSelectionWrongOwner(t *testing.T) {
+	t.Parallel()
+	ownerUUID := "ea167a48-c1b3-46c4-911b-090e807132fc"
+
+	router, selectionUUID := setupExtraction(t, ownerUUID, models.SelectionBounds{X2: 612, Y2: 792})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/selections/"+selectionUUID.String()+"/extract?ownerUUID="+uuid.NewString(), nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func setupSelectionUpdate(t *testing.T) (http.Handler, postgres.DatabaseHandler) {
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgresWithInitFileName(ctx, dbUser, dbPassword, "OneDocumentTableEntryAndTwoSelections")
+	require.NoError(t, err)
+
+	connectionString, err := ctr.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
+	selectionCtrl := &v1.SelectionController{SelectionRepository: postgres.NewSelectionRepository(dbHandle)}
+	return v1.SetupRouter(nil, selectionCtrl, nil, nil), dbHandle
+}
+
+func patchSelection(router http.Handler, selectionUUID string, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("PATCH", "/api/v1/selections/"+selectionUUID+"?ownerUUID="+selectionOwnerUUID, strings.NewReader(body)))
+	return w
+}
+
+func updateSelectionStateAndSettings(t *testing.T) {
+	t.Parallel()
+	selectionUUID := "a5fdea38-0a86-4c19-ae4f-c87a01bc860d"
+	router, dbHandle := setupSelectionUpdate(t)
+
+	w := patchSelection(router, selectionUUID, `{"isComplete": true, "settings": "{\"colour\": \"red\"}"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = patchSelection(router, selectionUUID, `{"settings": "not json"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	err := dbHandle.WithConnection(func(db *sql.DB) error {
+		var (
+			isComplete bool
+			settings   string
+		)
+
+		row := db.QueryRow(`SELECT "isCompleted", "Settings" FROM selection_table WHERE "Selection_UUID" = $1`, selectionUUID)
+		if err := row.Scan(&isComplete, &settings); err != nil {
+			return err
+		}
+
+		assert.True(t, isComplete)
+		assert.JSONEq(t, `{"colour": "red"}`, settings)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func updateSelectionBoundsPerPage(t *testing.T) {
+	t.Parallel()
+	selectionUUID := "a5fdea38-0a86-4c19-ae4f-c87a01bc860d"
+	router, _ := setupSelectionUpdate(t)
+
+	w := patchSelection(router, selectionUUID, `{"selectionBounds": {"1": [{"x1": 1, "y1": 2, "x2": 3, "y2": 4}], "2": [{"x1": 5, "y1": 6, "x2": 7, "y2": 8}]}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = patchSelection(router, selectionUUID, `{"selectionBounds": {"1": null, "3": [{"x1": 9, "y1": 10, "x2": 11, "y2": 12}]}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	response := struct {
+		Selection models.Selection `json:"selection"`
+	}{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+
+	assert.Equal(t, uuid.MustParse(selectionUUID), response.Selection.Uuid)
+	require.NotNil(t, response.Selection.SelectionBounds)
+	assert.Equal(t, map[int][]models.SelectionBounds{
+		2: {{X1: 5, Y1: 6, X2: 7, Y2: 8}},
+		3: {{X1: 9, Y1: 10, X2: 11, Y2: 12}},
+	}, *response.Selection.SelectionBounds)
+}
+
+func updateNonexistentSelection(t *testing.T) {
+	t.Parallel()
+	router, _ := setupSelectionUpdate(t)
+
+	w := patchSelection(router, uuid.NewString(), `{"isComplete": true}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func updateSelectionWithoutFields(t *testing.T) {
+	t.Parallel()
+	router, _ := setupSelectionUpdate(t)
+
+	w := patchSelection(router, "a5fdea38-0a86-4c19-ae4f-c87a01bc860d", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func getSelectionFullModel(t *testing.T) {
+	t.Parallel()
+	selectionUUID := "a5fdea38-0a86-4c19-ae4f-c87a01bc860d"
+	router, _ := setupSelectionUpdate(t)
+
+	w := patchSelection(router, selectionUUID, `{"isComplete": true, "settings": "{\"colour\":\"red\"}", "selectionBounds": {"1": [{"x1": 1, "y1": 2, "x2": 3, "y2": 4}]}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/selections/?selectionUUID="+selectionUUID+"&ownerUUID="+selectionOwnerUUID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	expectedJsonResponse := `{"selections":[{"selectionUUID":"a5fdea38-0a86-4c19-ae4f-c87a01bc860d","documentUUID":"b66fd223-515f-4503-80cc-2bdaa50ef474","isComplete":true,"settings":"{\"colour\":\"red\"}","selectionBounds":{"1":[{"extract_method":null,"x1":1,"x2":3,"y1":2,"y2":4}]}}]}`
+	assert.JSONEq(t, expectedJsonResponse, w.Body.String())
+}
+
+func getSelectionWithFields(t *testing.T) {
+	t.Parallel()
+	router, _ := setupSelectionUpdate(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/selections/?documentUUID=b66fd223-515f-4503-80cc-2bdaa50ef474&fields=isComplete&ownerUUID="+selectionOwnerUUID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	expectedJsonResponse := `{"selections":[{"selectionUUID":"a5fdea38-0a86-4c19-ae4f-c87a01bc860d","isComplete":false},{"selectionUUID":"335a6b95-6707-4e2b-9c37-c76d017f6f97","isComplete":false}]}`
+	assert.JSONEq(t, expectedJsonResponse, w.Body.String())
+}
+
+func getSelectionWithExclude(t *testing.T) {
+	t.Parallel()
+	router, _ := setupSelectionUpdate(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/selections/?selectionUUID=a5fdea38-0a86-4c19-ae4f-c87a01bc860d&exclude=isComplete&exclude=documentUUID&ownerUUID="+selectionOwnerUUID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	assert.JSONEq(t, `{"selections":[{"selectionUUID":"a5fdea38-0a86-4c19-ae4f-c87a01bc860d"}]}`, w.Body.String())
+}
+
+func accessSelectionsOfAnotherOwner(t *testing.T) {
+	t.Parallel()
+	selectionUUID := "a5fdea38-0a86-4c19-ae4f-c87a01bc860d"
+	documentUUID := "b66fd223-515f-4503-80cc-2bdaa50ef474"
+	otherOwnerUUID := uuid.NewString()
+	router, _ := setupSelectionUpdate(t)
+
+	requests := map[string]*http.Request{
+		"get by selection uuid":    httptest.NewRequest("GET", "/api/v1/selections/?selectionUUID="+selectionUUID+"&ownerUUID="+otherOwnerUUID, nil),
+		"get by document uuid":     httptest.NewRequest("GET", "/api/v1/selections/?documentUUID="+documentUUID+"&ownerUUID="+otherOwnerUUID, nil),
+		"add":                      httptest.NewRequest("POST", "/api/v1/selections/?ownerUUID="+otherOwnerUUID, strings.NewReader(`{"documentUUID": "`+documentUUID+`"}`)),
+		"update":                   httptest.NewRequest("PATCH", "/api/v1/selections/"+selectionUUID+"?ownerUUID="+otherOwnerUUID, strings.NewReader(`{"isComplete": true}`)),
+		"delete by selection uuid": httptest.NewRequest("DELETE", "/api/v1/selections/?selectionUUID="+selectionUUID+"&ownerUUID="+otherOwnerUUID, nil),
+		"delete by document uuid":  httptest.NewRequest("DELETE", "/api/v1/selections/?documentUUID="+documentUUID+"&ownerUUID="+otherOwnerUUID, nil),
+	}
+
+	for name, request := range requests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusNotFound, w.Code, name)
+	}
+
+	// The owner still sees both selections, unchanged.
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/selections/?documentUUID="+documentUUID+"&ownerUUID="+selectionOwnerUUID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	expectedJsonResponse := `{"selections":[{"selectionUUID":"a5fdea38-0a86-4c19-ae4f-c87a01bc860d","documentUUID":"b66fd223-515f-4503-80cc-2bdaa50ef474","isComplete":false},{"selectionUUID":"335a6b95-6707-4e2b-9c37-c76d017f6f97","documentUUID":"b66fd223-515f-4503-80cc-2bdaa50ef474","isComplete":false}]}`
+	assert.JSONEq(t, expectedJsonResponse, w.Body.String())
+}
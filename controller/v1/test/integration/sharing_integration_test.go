@@ -0,0 +1,170 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	v1 "pdf_service_api/controller/v1"
+	"pdf_service_api/models"
+	"pdf_service_api/postgres"
+	"pdf_service_api/testutil"
+	"strings"
+	"testing"
+)
+
+// sharedDocumentUUID is the document of the OneDocumentTableEntryAndTwoSelections fixture, owned by selectionOwnerUUID.
+const sharedDocumentUUID = "b66fd223-515f-4503-80cc-2bdaa50ef474"
+
+func TestSharingIntegration(t *testing.T) {
+	t.Parallel()
+	t.Run("A viewer can read but not change a document", viewerCanReadButNotChange)
+	t.Run("An editor can change selections but not share", editorCanChangeSelections)
+	t.Run("List documents shared with an owner", listSharedDocuments)
+	t.Run("Revoke a grant", revokeGrant)
+	t.Run("Share a document of another owner", shareDocumentOfAnotherOwner)
+	t.Run("Share a document with an unknown role", shareDocumentWithUnknownRole)
+}
+
+func setupSharing(t *testing.T) http.Handler {
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgresWithInitFileName(ctx, dbUser, dbPassword, "OneDocumentTableEntryAndTwoSelections")
+	require.NoError(t, err)
+
+	connectionString, err := ctr.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+
+	dbHandle := postgres.DatabaseHandler{DbConfig: postgres.ConfigForDatabase{ConUrl: connectionString}}
+	documentRepository := postgres.NewDocumentRepository(dbHandle)
+	documentCtrl := &v1.DocumentController{DocumentRepository: documentRepository, GrantRepository: postgres.NewGrantRepository(dbHandle)}
+	selectionCtrl := &v1.SelectionController{SelectionRepository: postgres.NewSelectionRepository(dbHandle), DocumentRepository: documentRepository}
+	return v1.SetupRouter(documentCtrl, selectionCtrl, nil, nil)
+}
+
+func serve(router http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return w
+}
+
+func grantAccess(t *testing.T, router http.Handler, granteeUUID, role string) {
+	w := serve(router, "POST", "/api/v1/documents/"+sharedDocumentUUID+"/grants?ownerUUID="+selectionOwnerUUID, `{"granteeUUID": "`+granteeUUID+`", "role": "`+role+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func viewerCanReadButNotChange(t *testing.T) {
+	t.Parallel()
+	router := setupSharing(t)
+	viewerUUID := uuid.NewString()
+	grantAccess(t, router, viewerUUID, models.RoleViewer)
+
+	w := serve(router, "GET", "/api/v1/documents/?documentUUID="+sharedDocumentUUID+"&ownerUUID="+viewerUUID, "")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = serve(router, "GET", "/api/v1/selections/?documentUUID="+sharedDocumentUUID+"&ownerUUID="+viewerUUID, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "a5fdea38-0a86-4c19-ae4f-c87a01bc860d")
+
+	w = serve(router, "POST", "/api/v1/selections/?ownerUUID="+viewerUUID, `{"documentUUID": "`+sharedDocumentUUID+`"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	w = serve(router, "PATCH", "/api/v1/selections/a5fdea38-0a86-4c19-ae4f-c87a01bc860d?ownerUUID="+viewerUUID, `{"isComplete": true}`)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	w = serve(router, "GET", "/api/v1/documents/"+sharedDocumentUUID+"/grants?ownerUUID="+viewerUUID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	serve(router, "DELETE", "/api/v1/documents/?documentUUID="+sharedDocumentUUID+"&ownerUUID="+viewerUUID, "")
+	w = serve(router, "GET", "/api/v1/documents/?documentUUID="+sharedDocumentUUID+"&ownerUUID="+selectionOwnerUUID, "")
+	assert.Equal(t, http.StatusOK, w.Code, "a viewer must not be able to delete the document")
+}
+
+func editorCanChangeSelections(t *testing.T) {
+	t.Parallel()
+	router := setupSharing(t)
+	editorUUID := uuid.NewString()
+	grantAccess(t, router, editorUUID, models.RoleEditor)
+
+	w := serve(router, "POST", "/api/v1/selections/?ownerUUID="+editorUUID, `{"documentUUID": "`+sharedDocumentUUID+`"}`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = serve(router, "PATCH", "/api/v1/selections/a5fdea38-0a86-4c19-ae4f-c87a01bc860d?ownerUUID="+editorUUID, `{"isComplete": true}`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = serve(router, "POST", "/api/v1/documents/"+sharedDocumentUUID+"/grants?ownerUUID="+editorUUID, `{"granteeUUID": "`+uuid.NewString()+`", "role": "viewer"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	w = serve(router, "GET", "/api/v1/documents/"+sharedDocumentUUID+"/grants?ownerUUID="+selectionOwnerUUID, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	response := struct {
+		Grants []models.Grant `json:"grants"`
+	}{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Grants, 1)
+	assert.Equal(t, editorUUID, response.Grants[0].GranteeUUID.String())
+	assert.Equal(t, models.RoleEditor, response.Grants[0].Role)
+}
+
+func listSharedDocuments(t *testing.T) {
+	t.Parallel()
+	router := setupSharing(t)
+	viewerUUID := uuid.NewString()
+	grantAccess(t, router, viewerUUID, models.RoleViewer)
+
+	w := serve(router, "GET", "/api/v1/documents/?exclude=pdfBase64&ownerUUID="+viewerUUID, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"documents":[]}`, w.Body.String())
+
+	w = serve(router, "GET", "/api/v1/documents/?exclude=pdfBase64&shared=true&ownerUUID="+viewerUUID, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), sharedDocumentUUID)
+
+	w = serve(router, "GET", "/api/v1/documents/?shared=maybe&ownerUUID="+viewerUUID, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+}
+
+func revokeGrant(t *testing.T) {
+	t.Parallel()
+	router := setupSharing(t)
+	viewerUUID := uuid.NewString()
+	grantAccess(t, router, viewerUUID, models.RoleViewer)
+
+	w := serve(router, "DELETE", "/api/v1/documents/"+sharedDocumentUUID+"/grants/"+viewerUUID+"?ownerUUID="+selectionOwnerUUID, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = serve(router, "GET", "/api/v1/documents/?documentUUID="+sharedDocumentUUID+"&ownerUUID="+viewerUUID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	w = serve(router, "DELETE", "/api/v1/documents/"+sharedDocumentUUID+"/grants/"+viewerUUID+"?ownerUUID="+selectionOwnerUUID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+}
+
+func shareDocumentOfAnotherOwner(t *testing.T) {
+	t.Parallel()
+	router := setupSharing(t)
+	otherOwnerUUID := uuid.NewString()
+
+	w := serve(router, "POST", "/api/v1/documents/"+sharedDocumentUUID+"/grants?ownerUUID="+otherOwnerUUID, `{"granteeUUID": "`+otherOwnerUUID+`", "role": "owner"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = serve(router, "POST", "/api/v1/documents/"+sharedDocumentUUID+"/grants?ownerUUID="+otherOwnerUUID, `{"granteeUUID": "`+uuid.NewString()+`", "role": "owner"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	w = serve(router, "GET", "/api/v1/documents/?documentUUID="+sharedDocumentUUID+"&ownerUUID="+otherOwnerUUID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+}
+
+func shareDocumentWithUnknownRole(t *testing.T) {
+	t.Parallel()
+	router := setupSharing(t)
+
+	w := serve(router, "POST", "/api/v1/documents/"+sharedDocumentUUID+"/grants?ownerUUID="+selectionOwnerUUID, `{"granteeUUID": "`+uuid.NewString()+`", "role": "admin"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = serve(router, "POST", "/api/v1/documents/"+sharedDocumentUUID+"/grants?ownerUUID="+selectionOwnerUUID, `{"role": "viewer"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+}
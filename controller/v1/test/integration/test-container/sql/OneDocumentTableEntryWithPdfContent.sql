@@ -0,0 +1,2 @@
+insert into document_table ("Document_UUID", "Document_Base64", "Time_Created", "Document_Title", "Owner_UUID", "Owner_Type")
+values (uuid('b66fd223-515f-4503-80cc-2bdaa50ef474'),'JVBERi0xLjQgVEhJUyBJUyBBIFRFU1QgRE9DVU1FTlQ=', '2022-10-10 11:30:30', 'Fake Title','ea167a48-c1b3-46c4-911b-090e807132fc', 1)
@@ -0,0 +1,182 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	v1 "pdf_service_api/controller/v1"
+	"pdf_service_api/models"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memoryAPIKeyRepository keeps API keys in memory, by the hash they are looked up with.
+type memoryAPIKeyRepository struct {
+	mutex sync.Mutex
+	keys  map[string]models.APIKey
+}
+
+func (m *memoryAPIKeyRepository) CreateAPIKey(_ context.Context, key models.APIKey, keyHash string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.keys[keyHash] = key
+	return nil
+}
+
+func (m *memoryAPIKeyRepository) GetAPIKeys(_ context.Context, owner uuid.UUID) ([]models.APIKey, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	keys := make([]models.APIKey, 0)
+	for _, key := range m.keys {
+		if key.OwnerUUID == owner {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func (m *memoryAPIKeyRepository) RevokeAPIKey(_ context.Context, key, owner uuid.UUID) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for hash, stored := range m.keys {
+		if stored.Uuid == key && stored.OwnerUUID == owner {
+			delete(m.keys, hash)
+			return nil
+		}
+	}
+
+	return sql.ErrNoRows
+}
+
+func (m *memoryAPIKeyRepository) AuthenticateAPIKey(_ context.Context, keyHash string) (models.APIKey, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	key, ok := m.keys[keyHash]
+	if !ok {
+		return models.APIKey{}, sql.ErrNoRows
+	}
+
+	return key, nil
+}
+
+func apiKeyRouter(t *testing.T) (*gin.Engine, *memoryAPIKeyRepository) {
+	repository := &memoryAPIKeyRepository{keys: make(map[string]models.APIKey)}
+	jwtMiddleware, err := v1.NewJWTMiddleware(v1.JWTConfig{HMACSecret: testSecret})
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := v1.SetupRouter(&v1.DocumentController{}, nil, nil, &v1.APIKeyController{APIKeyRepository: repository}, v1.NewAPIKeyMiddleware(repository), jwtMiddleware)
+	router.GET("/whoami", v1.NewAPIKeyMiddleware(repository), jwtMiddleware, func(c *gin.Context) {
+		identity, _ := v1.IdentityFromContext(c)
+		c.JSON(http.StatusOK, gin.H{"ownerUUID": identity.OwnerUUID, "permissions": identity.Permissions})
+	})
+
+	return router, repository
+}
+
+func serveWithAPIKey(router http.Handler, method, target, key string, body io.Reader) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, body)
+	r.Header.Set("X-API-Key", key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func serveWithToken(router http.Handler, method, target, token string, body io.Reader) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, body)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func createAPIKey(t *testing.T, router http.Handler, token string, permissions string) v1.CreateAPIKeyResponse {
+	w := serveWithToken(router, "POST", "/api/v1/keys/", token, strings.NewReader(`{"name": "pipeline", "permissions": `+permissions+`}`))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	response := v1.CreateAPIKeyResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func TestAPIKeyAuthenticatesAsItsOwner(t *testing.T) {
+	router, repository := apiKeyRouter(t)
+	owner := uuid.New()
+	created := createAPIKey(t, router, signHS256(t, ownerClaims(owner)), `["selections:read", "documents:read", "documents:read"]`)
+
+	assert.True(t, strings.HasPrefix(created.Key, created.APIKey.Prefix))
+	assert.Equal(t, []string{"documents:read", "selections:read"}, created.APIKey.Permissions)
+	assert.NotContains(t, repository.keys, created.Key, "the key must only be stored hashed")
+
+	w := serveWithAPIKey(router, "GET", "/whoami", created.Key, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"ownerUUID": "`+owner.String()+`", "permissions": ["documents:read", "selections:read"]}`, w.Body.String())
+}
+
+func TestAPIKeyIsOnlyShownOnce(t *testing.T) {
+	router, _ := apiKeyRouter(t)
+	token := signHS256(t, ownerClaims(uuid.New()))
+	created := createAPIKey(t, router, token, `["documents:read"]`)
+
+	w := serveWithToken(router, "GET", "/api/v1/keys/", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), created.APIKey.Uuid.String())
+	assert.NotContains(t, w.Body.String(), created.Key)
+}
+
+func TestAPIKeyPermissionsAreEnforced(t *testing.T) {
+	router, _ := apiKeyRouter(t)
+	created := createAPIKey(t, router, signHS256(t, ownerClaims(uuid.New())), `["documents:read"]`)
+
+	w := serveWithAPIKey(router, "DELETE", "/api/v1/documents/?documentUUID="+uuid.NewString(), created.Key, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	w = serveWithAPIKey(router, "POST", "/api/v1/keys/", created.Key, strings.NewReader(`{"name": "escalated", "permissions": ["documents:write"]}`))
+	assert.Equal(t, http.StatusForbidden, w.Code, "an API key must not be able to create keys")
+}
+
+func TestRevokedAPIKeyIsRejected(t *testing.T) {
+	router, _ := apiKeyRouter(t)
+	token := signHS256(t, ownerClaims(uuid.New()))
+	created := createAPIKey(t, router, token, `["documents:read"]`)
+
+	w := serveWithToken(router, "DELETE", "/api/v1/keys/"+created.APIKey.Uuid.String(), signHS256(t, ownerClaims(uuid.New())), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, "only the owner can revoke a key")
+
+	w = serveWithToken(router, "DELETE", "/api/v1/keys/"+created.APIKey.Uuid.String(), token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = serveWithAPIKey(router, "GET", "/whoami", created.Key, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestUnknownAPIKeyIsRejected(t *testing.T) {
+	router, _ := apiKeyRouter(t)
+
+	w := serveWithAPIKey(router, "GET", "/whoami", "pdfsvc_unknown", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestCreateAPIKeyValidatesPermissions(t *testing.T) {
+	router, _ := apiKeyRouter(t)
+	token := signHS256(t, ownerClaims(uuid.New()))
+
+	for _, body := range []string{
+		`{"name": "pipeline", "permissions": ["documents:admin"]}`,
+		`{"name": "pipeline", "permissions": ["keys:manage"]}`,
+		`{"name": "pipeline", "permissions": []}`,
+		`{"permissions": ["documents:read"]}`,
+	} {
+		w := serveWithToken(router, "POST", "/api/v1/keys/", token, strings.NewReader(body))
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
@@ -0,0 +1,190 @@
+package unit
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	v1 "pdf_service_api/controller/v1"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("test-secret")
+
+// identityRouter returns a router that answers with the identity the middleware stored for the request.
+func identityRouter(t *testing.T, config v1.JWTConfig) *gin.Engine {
+	middleware, err := v1.NewJWTMiddleware(config)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/whoami", middleware, func(c *gin.Context) {
+		identity, _ := v1.IdentityFromContext(c)
+		c.JSON(http.StatusOK, gin.H{"ownerUUID": identity.OwnerUUID, "ownerType": identity.OwnerType})
+	})
+
+	return router
+}
+
+func request(router http.Handler, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", "/whoami", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func signHS256(t *testing.T, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testSecret)
+	require.NoError(t, err)
+	return token
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func ownerClaims(owner uuid.UUID) jwt.MapClaims {
+	return jwt.MapClaims{"sub": owner.String(), "owner_type": 2, "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+func jwks(t *testing.T, kid string, key *rsa.PublicKey) []byte {
+	set := map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+	return data
+}
+
+func generateKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func TestJWTMiddlewareHS256(t *testing.T) {
+	owner := uuid.New()
+	router := identityRouter(t, v1.JWTConfig{HMACSecret: testSecret})
+
+	w := request(router, signHS256(t, ownerClaims(owner)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"ownerUUID":"`+owner.String()+`","ownerType":2}`, w.Body.String())
+}
+
+func TestJWTMiddlewareRS256WithJWKSFile(t *testing.T) {
+	owner := uuid.New()
+	key := generateKey(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwks(t, "file-key", &key.PublicKey), 0600))
+
+	router := identityRouter(t, v1.JWTConfig{JWKSFile: path})
+
+	w := request(router, signRS256(t, key, "file-key", ownerClaims(owner)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"ownerUUID":"`+owner.String()+`","ownerType":2}`, w.Body.String())
+
+	w = request(router, signRS256(t, generateKey(t), "file-key", ownerClaims(owner)))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestJWTMiddlewareRS256WithJWKSURL(t *testing.T) {
+	owner := uuid.New()
+	key := generateKey(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(jwks(t, "url-key", &key.PublicKey))
+	}))
+	t.Cleanup(server.Close)
+
+	router := identityRouter(t, v1.JWTConfig{JWKSURL: server.URL, HTTPClient: server.Client()})
+
+	w := request(router, signRS256(t, key, "url-key", ownerClaims(owner)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = request(router, signRS256(t, key, "unknown-key", ownerClaims(owner)))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestJWTMiddlewareRejectsInvalidTokens(t *testing.T) {
+	router := identityRouter(t, v1.JWTConfig{HMACSecret: testSecret, Issuer: "issuer", Audience: "pdf_service_api"})
+	valid := func() jwt.MapClaims {
+		claims := ownerClaims(uuid.New())
+		claims["iss"] = "issuer"
+		claims["aud"] = "pdf_service_api"
+		return claims
+	}
+
+	assert.Equal(t, http.StatusOK, request(router, signHS256(t, valid())).Code)
+
+	tests := map[string]func(claims jwt.MapClaims){
+		"expired":            func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"without expiry":     func(claims jwt.MapClaims) { delete(claims, "exp") },
+		"wrong issuer":       func(claims jwt.MapClaims) { claims["iss"] = "someone else" },
+		"wrong audience":     func(claims jwt.MapClaims) { claims["aud"] = "another service" },
+		"subject not a uuid": func(claims jwt.MapClaims) { claims["sub"] = "not-a-uuid" },
+		"without subject":    func(claims jwt.MapClaims) { delete(claims, "sub") },
+		"fractional type":    func(claims jwt.MapClaims) { claims["owner_type"] = 1.5 },
+	}
+
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			claims := valid()
+			modify(claims)
+
+			w := request(router, signHS256(t, claims))
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+		})
+	}
+}
+
+func TestJWTMiddlewareRequiresBearerToken(t *testing.T) {
+	router := identityRouter(t, v1.JWTConfig{HMACSecret: testSecret})
+
+	w := request(router, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+
+	w = request(router, "not.a.token")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestJWTMiddlewareRejectsUnconfiguredAlgorithms(t *testing.T) {
+	router := identityRouter(t, v1.JWTConfig{HMACSecret: testSecret})
+
+	w := request(router, signRS256(t, generateKey(t), "", ownerClaims(uuid.New())))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestNewJWTMiddlewareRequiresKeys(t *testing.T) {
+	_, err := v1.NewJWTMiddleware(v1.JWTConfig{})
+	assert.Error(t, err)
+
+	_, err = v1.NewJWTMiddleware(v1.JWTConfig{JWKSFile: filepath.Join(t.TempDir(), "missing.json")})
+	assert.Error(t, err)
+}
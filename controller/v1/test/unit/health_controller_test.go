@@ -0,0 +1,108 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	v1 "pdf_service_api/controller/v1"
+	"testing"
+	"time"
+)
+
+func healthRouter(healthCtrl *v1.HealthController) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := v1.SetupRouter(nil, nil, nil, nil)
+	healthCtrl.SetupRouter(&router.RouterGroup)
+	return router
+}
+
+func getReadiness(t *testing.T, router *gin.Engine) (int, v1.ReadinessResponse) {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+
+	var response v1.ReadinessResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+func passing(ctx context.Context) error {
+	return nil
+}
+
+func TestLiveness(t *testing.T) {
+	router := healthRouter(&v1.HealthController{Checks: []v1.ReadinessCheck{{Name: "database", Check: func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}}}})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code, "liveness does not depend on the readiness checks")
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestReadinessReportsEveryCheck(t *testing.T) {
+	healthCtrl := &v1.HealthController{Checks: []v1.ReadinessCheck{
+		{Name: "database", Check: passing},
+		{Name: "blobStore", Check: passing},
+	}}
+
+	code, response := getReadiness(t, healthRouter(healthCtrl))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", response.Status)
+	assert.Equal(t, "up", response.Checks["database"].Status)
+	assert.Equal(t, "up", response.Checks["blobStore"].Status)
+	assert.NoError(t, healthCtrl.Ready(context.Background()))
+}
+
+func TestReadinessFailsWithFailingCheck(t *testing.T) {
+	healthCtrl := &v1.HealthController{Checks: []v1.ReadinessCheck{
+		{Name: "database", Check: passing},
+		{Name: "migrations", Check: func(ctx context.Context) error {
+			return errors.New("1 migration(s) pending")
+		}},
+	}}
+
+	code, response := getReadiness(t, healthRouter(healthCtrl))
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not ready", response.Status)
+	assert.Equal(t, "up", response.Checks["database"].Status)
+	assert.Equal(t, "down", response.Checks["migrations"].Status)
+	assert.Equal(t, "1 migration(s) pending", response.Checks["migrations"].Error)
+	assert.ErrorContains(t, healthCtrl.Ready(context.Background()), "migrations: 1 migration(s) pending")
+}
+
+func TestReadinessChecksTimeOut(t *testing.T) {
+	healthCtrl := &v1.HealthController{Timeout: 20 * time.Millisecond, Checks: []v1.ReadinessCheck{
+		{Name: "database", Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	}}
+
+	start := time.Now()
+	code, response := getReadiness(t, healthRouter(healthCtrl))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, context.DeadlineExceeded.Error(), response.Checks["database"].Error)
+}
+
+func TestReadinessFailsOnceMarkedNotReady(t *testing.T) {
+	checked := false
+	healthCtrl := &v1.HealthController{Checks: []v1.ReadinessCheck{{Name: "database", Check: func(ctx context.Context) error {
+		checked = true
+		return nil
+	}}}}
+	router := healthRouter(healthCtrl)
+
+	healthCtrl.MarkNotReady()
+	code, response := getReadiness(t, router)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "shutting down", response.Status)
+	assert.False(t, checked, "checks are not run while shutting down")
+	assert.Error(t, healthCtrl.Ready(context.Background()))
+}
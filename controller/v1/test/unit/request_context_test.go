@@ -0,0 +1,86 @@
+package unit
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	v1 "pdf_service_api/controller/v1"
+	"pdf_service_api/models"
+	"testing"
+	"time"
+)
+
+// blockingDocumentRepository stands in for a query that never finishes by itself: listing documents waits until the
+// context it was given ends, and reports the context's error on done.
+type blockingDocumentRepository struct {
+	models.DocumentRepository
+	done chan error
+}
+
+func (b blockingDocumentRepository) GetDocumentByOwnerUUID(ctx context.Context, _ uuid.UUID, _ int8, _ int8, _ bool, _ map[string]bool) ([]models.Document, error) {
+	<-ctx.Done()
+	b.done <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func blockingRouter(middleware ...gin.HandlerFunc) (*gin.Engine, chan error) {
+	done := make(chan error, 1)
+	gin.SetMode(gin.TestMode)
+	documentCtrl := &v1.DocumentController{DocumentRepository: blockingDocumentRepository{done: done}}
+	return v1.SetupRouter(documentCtrl, nil, nil, nil, middleware...), done
+}
+
+func TestCancelledRequestCancelsRepositoryCall(t *testing.T) {
+	router, done := blockingRouter()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	request := httptest.NewRequest("GET", "/api/v1/documents/?ownerUUID="+uuid.NewString(), nil).WithContext(ctx)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("the repository call was not cancelled with the request")
+	}
+}
+
+func TestRequestTimeoutCancelsRepositoryCall(t *testing.T) {
+	router, done := blockingRouter(v1.RequestTimeout(20 * time.Millisecond))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/documents/?ownerUUID="+uuid.NewString(), nil))
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("the repository call outlived the request timeout")
+	}
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestRequestTimeoutSetsDeadline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/deadline", v1.RequestTimeout(time.Minute), func(c *gin.Context) {
+		deadline, ok := c.Request.Context().Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/deadline", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
@@ -26,7 +26,15 @@ const docTemplate = `{
     "paths": {
         "/documents": {
             "get": {
-                "description": "Retrieves document details. Documents can be fetched either by their unique Document UUID or by an Owner UUID.\nOptional exclusion parameters can be used to omit specific fields from the response.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves document details. Documents can be fetched either by their unique Document UUID or by an Owner UUID.\nOptional exclusion parameters can be used to omit specific fields from the response.\nDocuments shared with the owner can be fetched by their Document UUID, and are included in the owner's list when shared is true.",
                 "consumes": [
                     "application/json"
                 ],
@@ -46,10 +54,15 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "The unique identifier of the owner whose documents are to be retrieved.",
+                        "description": "The unique identifier of the owner whose documents are to be retrieved, only read when authentication is disabled",
                         "name": "ownerUUID",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "When listing by owner, also include the documents shared with the owner.",
+                        "name": "shared",
+                        "in": "query"
                     },
                     {
                         "type": "array",
@@ -113,9 +126,19 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Uploads a document by receiving its base64 encoded string in the request body.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Uploads a document either as a JSON body containing its base64 encoded string, as a multipart form\ncontaining a \"file\" part, or as a raw application/pdf body. Metadata is extracted from the PDF automatically.",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "multipart/form-data",
+                    "application/pdf"
                 ],
                 "produces": [
                     "application/json"
@@ -126,13 +149,36 @@ const docTemplate = `{
                 "summary": "Upload a new document",
                 "parameters": [
                     {
-                        "description": "Document upload request",
+                        "description": "Document upload request, used when the Content-Type is application/json",
                         "name": "request",
                         "in": "body",
-                        "required": true,
                         "schema": {
                             "$ref": "#/definitions/v1.CreateRequest"
                         }
+                    },
+                    {
+                        "type": "file",
+                        "description": "The PDF file, used when the Content-Type is multipart/form-data",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The title of the document, used when the Content-Type is multipart/form-data",
+                        "name": "documentTitle",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the document owner, used when the Content-Type is multipart/form-data and authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "The type of the document owner, used when the Content-Type is multipart/form-data and authentication is disabled",
+                        "name": "ownerType",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
@@ -151,6 +197,14 @@ const docTemplate = `{
                 }
             },
             "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
                 "description": "Deletes a document based on the provided document UUID.",
                 "consumes": [
                     "application/json"
@@ -172,10 +226,9 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "The UUID of the owner of the document that is getting deleted",
+                        "description": "The UUID of the owner of the document that is getting deleted, only read when authentication is disabled",
                         "name": "ownerUUID",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -194,45 +247,17 @@ const docTemplate = `{
                 }
             }
         },
-        "/meta": {
-            "get": {
-                "description": "Retrieves metadata associated with a given UUID.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "meta"
-                ],
-                "summary": "Get metadata by UUID",
-                "parameters": [
+        "/documents/composite": {
+            "post": {
+                "security": [
                     {
-                        "type": "string",
-                        "description": "The UUID of the metadata to retrieve",
-                        "name": "documentUUID",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Successful retrieval of metadata",
-                        "schema": {
-                            "$ref": "#/definitions/models.Meta"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad request, typically due to missing/invalid UUID"
+                        "BearerAuth": []
                     },
-                    "500": {
-                        "description": "Internal server error, typically due to database issues"
+                    {
+                        "ApiKeyAuth": []
                     }
-                }
-            },
-            "put": {
-                "description": "Updates specific fields of an existing metadata entry.",
+                ],
+                "description": "Creates a document along with its metadata and initial selections atomically. Metadata is extracted\nfrom the PDF when it is not given.",
                 "consumes": [
                     "application/json"
                 ],
@@ -240,165 +265,1179 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "meta"
+                    "documents"
                 ],
-                "summary": "Update existing metadata",
+                "summary": "Create a document with metadata and selections",
                 "parameters": [
                     {
-                        "description": "Metadata update request",
+                        "description": "The document, its metadata and its selections",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/v1.UpdateMetaRequest"
+                            "$ref": "#/definitions/v1.CreateDocumentWithDetailsRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successful update"
+                        "description": "Successful creation, returns the document and selection UUIDs",
+                        "schema": {
+                            "$ref": "#/definitions/v1.CreateDocumentWithDetailsResponse"
+                        }
                     },
                     "400": {
-                        "description": "Bad request, typically due to invalid input"
+                        "description": "Bad Request: Invalid input, or the document could not be stored.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
                     },
-                    "500": {
-                        "description": "Internal server error, typically due to database issues"
+                    "403": {
+                        "description": "Forbidden: The caller cannot write documents, metadata and selections.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
                     }
                 }
-            },
-            "post": {
-                "description": "Creates new metadata with a generated UUID.",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/documents/{documentUUID}/content": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Streams the raw PDF bytes of a document. Supports Range requests with 206 Partial Content responses.",
                 "produces": [
-                    "application/json"
+                    "application/pdf"
                 ],
                 "tags": [
-                    "meta"
+                    "documents"
                 ],
-                "summary": "Add new metadata",
+                "summary": "Download a document's PDF content",
                 "parameters": [
                     {
-                        "description": "Metadata creation request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.AddMetaRequest"
-                        }
+                        "type": "string",
+                        "description": "The UUID of the document to download",
+                        "name": "documentUUID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the owner of the document, only read when authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The byte range to return, for example bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successful creation, returns the metadata UUID",
+                        "description": "The full PDF document",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "type": "file"
                         }
                     },
-                    "400": {
-                        "description": "Bad request, typically due to invalid input"
+                    "206": {
+                        "description": "The requested range of the PDF document",
+                        "schema": {
+                            "type": "file"
+                        }
                     },
-                    "500": {
-                        "description": "Internal server error, typically due to database issues"
-                    }
-                }
-            },
-            "delete": {
-                "description": "Deletes metadata based on the provided UUID in the request body.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "meta"
-                ],
-                "summary": "Delete metadata by UUID",
-                "parameters": [
-                    {
-                        "description": "Metadata deletion request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
+                    "400": {
+                        "description": "Bad Request: Invalid UUID format or missing owner UUID.",
                         "schema": {
-                            "$ref": "#/definitions/v1.DeleteMetaRequest"
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
                         }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Successful deletion",
+                    },
+                    "404": {
+                        "description": "Not Found: No document found for the given UUID.",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "boolean"
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad request, typically due to invalid input"
+                    "416": {
+                        "description": "Requested Range Not Satisfiable"
                     },
                     "500": {
-                        "description": "Internal server error, typically due to database issues"
+                        "description": "Internal Server Error: An unexpected error occurred on the server.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
                     }
                 }
             }
         },
-        "/selections": {
+        "/documents/{documentUUID}/grants": {
             "get": {
-                "description": "Retrieves selections based on either a document's UUID or a specific selection's UUID.",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Lists the owners a document is shared with and their roles.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "selections"
+                    "documents"
                 ],
-                "summary": "Get selections by document or selection UUID",
+                "summary": "List the grants of a document",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "The UUID of the document to retrieve selections for",
+                        "description": "The UUID of the document",
                         "name": "documentUUID",
-                        "in": "query"
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "description": "The UUID of the specific selection to retrieve",
-                        "name": "selectionUUID",
+                        "description": "The UUID of the owner of the document, only read when authentication is disabled",
+                        "name": "ownerUUID",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successful retrieval of selections",
+                        "description": "The grants of the document",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "array",
-                                "items": {
-                                    "$ref": "#/definitions/models.Selection"
+                            "properties": {
+                                "grants": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/models.Grant"
+                                    }
                                 }
                             }
                         }
                     },
                     "400": {
-                        "description": "Bad request, typically due to missing/invalid UUID parameter"
+                        "description": "Bad Request: Invalid UUID format or missing owner UUID.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: No document the caller can share was found for the given UUID.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
                     },
                     "500": {
-                        "description": "Internal server error, typically due to database issues"
+                        "description": "Internal Server Error: An unexpected error occurred on the server.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
                     }
                 }
             },
             "post": {
-                "description": "Creates a new selection associated with a document.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Grants another owner the viewer, editor or owner role on a document.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "documents"
+                ],
+                "summary": "Share a document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The UUID of the document to share",
+                        "name": "documentUUID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the owner of the document, only read when authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "query"
+                    },
+                    {
+                        "description": "The grantee and its role",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.GrantAccessRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The grant that was stored",
+                        "schema": {
+                            "$ref": "#/definitions/models.Grant"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid UUID format, missing grantee or unknown role.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: No document the caller can share was found for the given UUID.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error: An unexpected error occurred on the server.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/{documentUUID}/grants/{granteeUUID}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stops sharing a document with a grantee.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "documents"
+                ],
+                "summary": "Revoke a grant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The UUID of the document",
+                        "name": "documentUUID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the grantee to revoke",
+                        "name": "granteeUUID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the owner of the document, only read when authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful revocation",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "boolean"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid UUID format or missing owner UUID.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: The document is not shared with the grantee, or the caller cannot share it.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error: An unexpected error occurred on the server.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/{documentUUID}/pages/{pageNumber}/image": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Renders a page server-side and returns it as a PNG or JPEG image. Rendered images are cached.",
+                "produces": [
+                    "image/png",
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "documents"
+                ],
+                "summary": "Render a page of a document as an image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The UUID of the document",
+                        "name": "documentUUID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "The page number, counted from 1",
+                        "name": "pageNumber",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the owner of the document, only read when authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            24,
+                            72,
+                            96,
+                            150,
+                            300
+                        ],
+                        "type": "integer",
+                        "default": 96,
+                        "description": "The resolution in dots per inch",
+                        "name": "dpi",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "png",
+                            "jpeg"
+                        ],
+                        "type": "string",
+                        "default": "png",
+                        "description": "The image format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The rendered page",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid UUID format, page number, resolution, format or missing owner UUID.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: No document or page found for the given UUID and page number.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity: The stored document could not be rendered, or the image of the page would be too large.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error: An unexpected error occurred on the server.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable: The page could not be rendered before the request timed out.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/{documentUUID}/pages/{pageNumber}/words": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns every word on a page with its bounding box, in reading order, so clients can snap selections to words.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "documents"
+                ],
+                "summary": "List the words on a page of a document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The UUID of the document",
+                        "name": "documentUUID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "The page number, counted from 1",
+                        "name": "pageNumber",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the owner of the document, only read when authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The words on the page",
+                        "schema": {
+                            "$ref": "#/definitions/models.PageWords"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid UUID format, page number or missing owner UUID.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: No document or page found for the given UUID and page number.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity: The stored document could not be parsed.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error: An unexpected error occurred on the server.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/keys": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the caller's API keys. Only the prefix of each key is returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "List API keys",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The UUID of the owner of the keys, only read when authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The caller's keys",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "apiKeys": {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/models.APIKey"
+                                    }
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid or missing owner UUID.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: API keys cannot manage API keys.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error: An unexpected error occurred on the server.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates an API key acting for the caller with the given permissions. The key is only shown once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "Create an API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The UUID of the owner of the key, only read when authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "query"
+                    },
+                    {
+                        "description": "The name and permissions of the key",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The created key",
+                        "schema": {
+                            "$ref": "#/definitions/v1.CreateAPIKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Missing name or unknown permission.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: API keys cannot manage API keys.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error: An unexpected error occurred on the server.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/keys/{keyUUID}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes one of the caller's API keys.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "Revoke an API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The UUID of the key to revoke",
+                        "name": "keyUUID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the owner of the key, only read when authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful revocation",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "boolean"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid UUID format or missing owner UUID.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: API keys cannot manage API keys.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found: The caller has no key with the given UUID.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error: An unexpected error occurred on the server.",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/meta": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves metadata associated with a given UUID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "meta"
+                ],
+                "summary": "Get metadata by UUID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The UUID of the metadata to retrieve",
+                        "name": "documentUUID",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the document's owner, only read when authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful retrieval of metadata",
+                        "schema": {
+                            "$ref": "#/definitions/models.Meta"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, typically due to missing/invalid UUID"
+                    },
+                    "404": {
+                        "description": "Metadata not found"
+                    },
+                    "500": {
+                        "description": "Internal server error, typically due to database issues"
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Updates specific fields of an existing metadata entry.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "meta"
+                ],
+                "summary": "Update existing metadata",
+                "parameters": [
+                    {
+                        "description": "Metadata update request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UpdateMetaRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the document's owner, only read when authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful update"
+                    },
+                    "400": {
+                        "description": "Bad request, typically due to invalid input"
+                    },
+                    "404": {
+                        "description": "Metadata not found"
+                    },
+                    "500": {
+                        "description": "Internal server error, typically due to database issues"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates the metadata of a document the caller can edit.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "meta"
+                ],
+                "summary": "Add new metadata",
+                "parameters": [
+                    {
+                        "description": "Metadata creation request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.AddMetaRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the document's owner, only read when authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful creation, returns the metadata UUID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, typically due to invalid input"
+                    },
+                    "404": {
+                        "description": "Document not found"
+                    },
+                    "500": {
+                        "description": "Internal server error, typically due to database issues"
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes metadata based on the provided UUID in the request body.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "meta"
+                ],
+                "summary": "Delete metadata by UUID",
+                "parameters": [
+                    {
+                        "description": "Metadata deletion request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.DeleteMetaRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the document's owner, only read when authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful deletion",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "boolean"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, typically due to invalid input"
+                    },
+                    "404": {
+                        "description": "Metadata not found"
+                    },
+                    "500": {
+                        "description": "Internal server error, typically due to database issues"
+                    }
+                }
+            }
+        },
+        "/selections": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves selections based on either a document's UUID or a specific selection's UUID.\nOptional fields and exclusion parameters can be used to choose which fields are returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "selections"
+                ],
+                "summary": "Get selections by document or selection UUID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The UUID of the document to retrieve selections for",
+                        "name": "documentUUID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the specific selection to retrieve",
+                        "name": "selectionUUID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Fields to include in the response. Allowed values: ` + "`" + `documentUUID` + "`" + `, ` + "`" + `isComplete` + "`" + `, ` + "`" + `settings` + "`" + `, ` + "`" + `selectionBounds` + "`" + `, ` + "`" + `extraction` + "`" + `.",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Fields to exclude from the response. Allowed values: ` + "`" + `documentUUID` + "`" + `, ` + "`" + `isComplete` + "`" + `, ` + "`" + `settings` + "`" + `, ` + "`" + `selectionBounds` + "`" + `, ` + "`" + `extraction` + "`" + `.",
+                        "name": "exclude",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the document's owner, only read when authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful retrieval of selections",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.Selection"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, typically due to missing/invalid UUID parameter"
+                    },
+                    "404": {
+                        "description": "Selection or document not found"
+                    },
+                    "500": {
+                        "description": "Internal server error, typically due to database issues"
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a new selection associated with a document.",
                 "consumes": [
                     "application/json"
                 ],
@@ -418,6 +1457,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/v1.AddNewSelectionRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the document's owner, only read when authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -433,12 +1478,23 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad request, typically due to invalid input"
                     },
+                    "404": {
+                        "description": "Document not found"
+                    },
                     "500": {
                         "description": "Internal server error, typically due to database issues"
                     }
                 }
             },
             "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
                 "description": "Deletes selections based on a specific selection UUID or all selections associated with a document UUID.",
                 "consumes": [
                     "application/json"
@@ -462,6 +1518,12 @@ const docTemplate = `{
                         "description": "The UUID of the document whose selections are to be deleted",
                         "name": "documentUUID",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the document's owner, only read when authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -477,17 +1539,186 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad request, typically due to missing/invalid UUID parameter"
                     },
+                    "404": {
+                        "description": "Selection or document not found"
+                    },
+                    "500": {
+                        "description": "Internal server error, typically due to database issues"
+                    }
+                }
+            }
+        },
+        "/selections/{selectionUUID}": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Updates the completion state, settings or bounds of a selection without changing its UUID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "selections"
+                ],
+                "summary": "Partially update a selection",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The UUID of the selection to update",
+                        "name": "selectionUUID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Selection update request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UpdateSelectionRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the document's owner, only read when authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful update, returns the updated selection",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/models.Selection"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, typically due to invalid input"
+                    },
+                    "404": {
+                        "description": "Selection not found"
+                    },
+                    "500": {
+                        "description": "Internal server error, typically due to database issues"
+                    }
+                }
+            }
+        },
+        "/selections/{selectionUUID}/extract": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Extracts the text inside each bounds rectangle of a selection from the stored PDF, stores the result and returns it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "selections"
+                ],
+                "summary": "Extract the text inside a selection",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The UUID of the selection to extract",
+                        "name": "selectionUUID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The UUID of the document's owner, only read when authentication is disabled",
+                        "name": "ownerUUID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful extraction",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ExtractSelectionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, typically due to invalid parameters, extraction methods or pages"
+                    },
+                    "404": {
+                        "description": "Selection or document not found"
+                    },
+                    "422": {
+                        "description": "The stored document could not be parsed"
+                    },
                     "500": {
                         "description": "Internal server error, typically due to database issues"
                     }
                 }
             }
-        }
-    },
-    "definitions": {
+        }
+    },
+    "definitions": {
+        "models.APIKey": {
+            "type": "object",
+            "properties": {
+                "keyUUID": {
+                    "type": "string",
+                    "example": "0c7a3c9e-4d8a-4c55-9a2b-7b4c1c1fd0d2"
+                },
+                "lastUsed": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "batch pipeline"
+                },
+                "ownerType": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "ownerUUID": {
+                    "type": "string",
+                    "example": "34906041-2d68-45a2-9671-9f0ba89f31a9"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "documents:read",
+                        "selections:write"
+                    ]
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "pdfsvc_3Fh1"
+                },
+                "timeCreated": {
+                    "type": "string"
+                }
+            }
+        },
         "models.Document": {
             "type": "object",
             "properties": {
+                "checksumSHA256": {
+                    "type": "string"
+                },
                 "documentTitle": {
                     "type": "string"
                 },
@@ -510,6 +1741,60 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.Selection"
                     }
                 },
+                "sizeBytes": {
+                    "type": "integer"
+                },
+                "timeCreated": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExtractedBounds": {
+            "type": "object",
+            "properties": {
+                "bounds": {
+                    "$ref": "#/definitions/models.SelectionBounds"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TextBox"
+                    }
+                },
+                "method": {
+                    "type": "string",
+                    "example": "text"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "words": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TextBox"
+                    }
+                }
+            }
+        },
+        "models.Grant": {
+            "type": "object",
+            "properties": {
+                "documentUUID": {
+                    "type": "string",
+                    "example": "ba3ca973-5052-4030-a528-39b49736d8ad"
+                },
+                "granteeType": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "granteeUUID": {
+                    "type": "string",
+                    "example": "34906041-2d68-45a2-9671-9f0ba89f31a9"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "viewer"
+                },
                 "timeCreated": {
                     "type": "string"
                 }
@@ -544,18 +1829,75 @@ const docTemplate = `{
                     "type": "string",
                     "example": "34906041-2d68-45a2-9671-9f0ba89f31a9"
                 },
+                "pages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PageMeta"
+                    }
+                },
                 "width": {
                     "type": "number",
                     "example": 1920
                 }
             }
         },
+        "models.PageMeta": {
+            "type": "object",
+            "properties": {
+                "cropBox": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "mediaBox": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "pageNumber": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "rotation": {
+                    "type": "integer",
+                    "example": 90
+                },
+                "userUnit": {
+                    "type": "number",
+                    "example": 1
+                }
+            }
+        },
+        "models.PageWords": {
+            "type": "object",
+            "properties": {
+                "documentUUID": {
+                    "type": "string",
+                    "example": "ba3ca973-5052-4030-a528-39b49736d8ad"
+                },
+                "pageNumber": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "words": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TextBox"
+                    }
+                }
+            }
+        },
         "models.Selection": {
             "type": "object",
             "properties": {
                 "documentUUID": {
                     "type": "string"
                 },
+                "extraction": {
+                    "$ref": "#/definitions/models.SelectionExtraction"
+                },
                 "isComplete": {
                     "type": "boolean"
                 },
@@ -601,6 +1943,40 @@ const docTemplate = `{
                 }
             }
         },
+        "models.SelectionExtraction": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "array",
+                "items": {
+                    "$ref": "#/definitions/models.ExtractedBounds"
+                }
+            }
+        },
+        "models.TextBox": {
+            "type": "object",
+            "properties": {
+                "text": {
+                    "type": "string",
+                    "example": "Invoice"
+                },
+                "x1": {
+                    "type": "number",
+                    "example": 72
+                },
+                "x2": {
+                    "type": "number",
+                    "example": 107
+                },
+                "y1": {
+                    "type": "number",
+                    "example": 700
+                },
+                "y2": {
+                    "type": "number",
+                    "example": 710
+                }
+            }
+        },
         "v1.AddMetaRequest": {
             "type": "object",
             "properties": {
@@ -608,16 +1984,19 @@ const docTemplate = `{
                     "type": "number",
                     "format": "float32"
                 },
-                "images": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
-                    }
-                },
                 "numberOfPages": {
                     "type": "integer",
                     "format": "int32"
                 },
+                "pages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PageMeta"
+                    }
+                },
+                "uuid": {
+                    "type": "string"
+                },
                 "width": {
                     "type": "number",
                     "format": "float32"
@@ -647,6 +2026,78 @@ const docTemplate = `{
                 }
             }
         },
+        "v1.CreateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "batch pipeline"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "documents:read",
+                        "selections:write"
+                    ]
+                }
+            }
+        },
+        "v1.CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "apiKey": {
+                    "$ref": "#/definitions/models.APIKey"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "pdfsvc_q8V1d0mXbU2m3Lw0H8mJ2hY7Ck5Nf9QxJt4s6Rp1ZaE"
+                }
+            }
+        },
+        "v1.CreateDocumentWithDetailsRequest": {
+            "type": "object",
+            "properties": {
+                "documentBase64String": {
+                    "type": "string"
+                },
+                "documentTitle": {
+                    "type": "string"
+                },
+                "meta": {
+                    "$ref": "#/definitions/v1.DocumentMetaRequest"
+                },
+                "ownerType": {
+                    "type": "integer"
+                },
+                "ownerUUID": {
+                    "type": "string"
+                },
+                "selections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.DocumentSelectionRequest"
+                    }
+                }
+            }
+        },
+        "v1.CreateDocumentWithDetailsResponse": {
+            "type": "object",
+            "properties": {
+                "documentUUID": {
+                    "type": "string",
+                    "example": "ba3ca973-5052-4030-a528-39b49736d8ad"
+                },
+                "selectionUUIDs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "v1.CreateRequest": {
             "type": "object",
             "properties": {
@@ -672,23 +2123,94 @@ const docTemplate = `{
                 }
             }
         },
-        "v1.UpdateMetaRequest": {
+        "v1.DocumentMetaRequest": {
             "type": "object",
             "properties": {
                 "height": {
                     "type": "number",
-                    "format": "float32"
+                    "example": 1080
                 },
-                "images": {
+                "numberOfPages": {
+                    "type": "integer",
+                    "example": 31
+                },
+                "pages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PageMeta"
+                    }
+                },
+                "width": {
+                    "type": "number",
+                    "example": 1920
+                }
+            }
+        },
+        "v1.DocumentSelectionRequest": {
+            "type": "object",
+            "properties": {
+                "isComplete": {
+                    "type": "boolean"
+                },
+                "selectionBounds": {
                     "type": "object",
                     "additionalProperties": {
-                        "type": "string"
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/models.SelectionBounds"
+                        }
                     }
                 },
+                "settings": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ExtractSelectionResponse": {
+            "type": "object",
+            "properties": {
+                "extraction": {
+                    "$ref": "#/definitions/models.SelectionExtraction"
+                },
+                "selectionUUID": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.GrantAccessRequest": {
+            "type": "object",
+            "properties": {
+                "granteeType": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "granteeUUID": {
+                    "type": "string",
+                    "example": "34906041-2d68-45a2-9671-9f0ba89f31a9"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "viewer"
+                }
+            }
+        },
+        "v1.UpdateMetaRequest": {
+            "type": "object",
+            "properties": {
+                "height": {
+                    "type": "number",
+                    "format": "float32"
+                },
                 "numberOfPages": {
                     "type": "integer",
                     "format": "int32"
                 },
+                "pages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PageMeta"
+                    }
+                },
                 "uuid": {
                     "type": "string"
                 },
@@ -697,6 +2219,40 @@ const docTemplate = `{
                     "format": "float32"
                 }
             }
+        },
+        "v1.UpdateSelectionRequest": {
+            "type": "object",
+            "properties": {
+                "isComplete": {
+                    "type": "boolean"
+                },
+                "selectionBounds": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/models.SelectionBounds"
+                        }
+                    }
+                },
+                "settings": {
+                    "type": "string"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "An API key created through the keys endpoints, acting for its owner with the key's permissions.",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "A JWT bearer token, sent as \"Bearer \u003ctoken\u003e\". The owner is read from the token's sub claim.",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     },
     "externalDocs": {
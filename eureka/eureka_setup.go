@@ -1,27 +1,366 @@
 package eureka
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/ArthurHlt/go-eureka-client/eureka"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
-type Eureka struct {
+// Status is the status of an instance as reported to Eureka.
+type Status string
+
+const (
+	StatusUp           Status = "UP"
+	StatusDown         Status = "DOWN"
+	StatusStarting     Status = "STARTING"
+	StatusOutOfService Status = "OUT_OF_SERVICE"
+)
+
+const (
+	defaultRenewalInterval = 30 * time.Second
+	defaultLeaseDuration   = 90 * time.Second
+	defaultRetryInterval   = time.Second
+)
+
+// errInstanceNotFound is returned by a renewal when Eureka no longer knows the instance, for example after its lease
+// expired, so the client registers it again.
+var errInstanceNotFound = errors.New("the instance is not registered with eureka")
+
+// Config configures a Client. ServiceURLs, AppName, HostName, IPAddr and Port are required.
+// InstanceID defaults to <HostName>:<AppName>:<Port> and ManagementPort to Port. RenewalInterval and LeaseDuration
+// default to Eureka's 30 and 90 seconds. Failed renewals are retried after RetryInterval, doubling up to
+// RenewalInterval. HealthCheck, when set, is called before every renewal and decides whether the instance is reported
+// UP or DOWN.
+type Config struct {
+	ServiceURLs     []string
+	AppName         string
+	InstanceID      string
+	HostName        string
+	IPAddr          string
+	Port            int
+	ManagementPort  int
+	Version         string
+	Metadata        map[string]string
+	HealthCheckURL  string
+	StatusPageURL   string
+	RenewalInterval time.Duration
+	LeaseDuration   time.Duration
+	RetryInterval   time.Duration
+	HealthCheck     func(ctx context.Context) Status
+	HTTPClient      *http.Client
+}
+
+// Client keeps an instance registered with Eureka: it registers the instance, renews its lease, reports its status
+// and removes the registration again. Requests go to the first of the service URLs that answers.
+type Client struct {
+	config Config
+
+	mutex       sync.Mutex
+	status      Status
+	override    bool
+	registered  bool
+	dirtyMillis int64
+}
+
+// NewClient creates a client for config. The instance is not registered until Register or Run is called.
+func NewClient(config Config) (*Client, error) {
+	if len(config.ServiceURLs) == 0 {
+		return nil, errors.New("at least one eureka service URL must be configured")
+	}
+
+	if config.AppName == "" || config.HostName == "" || config.IPAddr == "" || config.Port <= 0 {
+		return nil, errors.New("the app name, host name, IP address and port of the instance must be configured")
+	}
+
+	serviceURLs := make([]string, 0, len(config.ServiceURLs))
+	for _, serviceURL := range config.ServiceURLs {
+		serviceURLs = append(serviceURLs, strings.TrimSuffix(serviceURL, "/"))
+	}
+	config.ServiceURLs = serviceURLs
+
+	if config.InstanceID == "" {
+		config.InstanceID = fmt.Sprintf("%s:%s:%d", config.HostName, config.AppName, config.Port)
+	}
+
+	if config.ManagementPort == 0 {
+		config.ManagementPort = config.Port
+	}
+
+	if config.RenewalInterval <= 0 {
+		config.RenewalInterval = defaultRenewalInterval
+	}
+
+	if config.LeaseDuration <= 0 {
+		config.LeaseDuration = defaultLeaseDuration
+	}
+
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = defaultRetryInterval
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &Client{config: config, status: StatusStarting, dirtyMillis: time.Now().UnixMilli()}, nil
+}
+
+// InstanceID returns the ID the instance is registered under.
+func (c *Client) InstanceID() string {
+	return c.config.InstanceID
+}
+
+// Status returns the status last reported for the instance.
+func (c *Client) Status() Status {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.status
+}
+
+// Register registers the instance, or registers it again with its current status and metadata.
+func (c *Client) Register(ctx context.Context) error {
+	c.mutex.Lock()
+	body, err := json.Marshal(c.instanceLocked())
+	c.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.send(ctx, http.MethodPost, "/apps/"+c.config.AppName, body); err != nil {
+		return fmt.Errorf("failed to register with eureka: %w", err)
+	}
+
+	c.mutex.Lock()
+	c.registered = true
+	c.mutex.Unlock()
+	return nil
+}
+
+// SetStatus reports status for the instance. OUT_OF_SERVICE takes the instance out of rotation until the next call
+// to SetStatus, regardless of the health check; the other statuses are replaced by the health check at the next
+// renewal when one is configured.
+func (c *Client) SetStatus(ctx context.Context, status Status) error {
+	if !c.changeStatus(status, true) {
+		return nil
+	}
+
+	return c.Register(ctx)
+}
+
+// Run registers the instance and renews its lease every RenewalInterval until ctx is done. Failed registrations and
+// renewals are retried with a growing delay, and an instance Eureka no longer knows is registered again.
+func (c *Client) Run(ctx context.Context) {
+	retryDelay := c.config.RetryInterval
+	for {
+		delay := c.config.RenewalInterval
+		if err := c.renew(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			fmt.Println(err.Error())
+			delay, retryDelay = retryDelay, min(2*retryDelay, c.config.RenewalInterval)
+		} else {
+			retryDelay = c.config.RetryInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Deregister removes the instance from Eureka, so it stops being routed to before its lease expires. It does nothing
+// when the instance is not registered.
+func (c *Client) Deregister(ctx context.Context) error {
+	c.mutex.Lock()
+	registered := c.registered
+	c.registered = false
+	c.mutex.Unlock()
+
+	if !registered {
+		return nil
+	}
+
+	_, err := c.send(ctx, http.MethodDelete, "/apps/"+c.config.AppName+"/"+c.config.InstanceID, nil)
+	if err != nil && !errors.Is(err, errInstanceNotFound) {
+		return fmt.Errorf("failed to deregister from eureka: %w", err)
+	}
+
+	return nil
 }
 
-func (Eureka) JoinEureka(hostname, ip, app string, port int) error {
-	client := eureka.NewClient([]string{
-		"http://127.0.0.1:8761/eureka", //From a spring boot based eureka server
-		// add others servers here
-	})
-	instance := eureka.NewInstanceInfo(hostname, ip, app, port, 30, false) //Create a new instance to register
-	instance.Metadata = &eureka.MetaData{
-		Map: make(map[string]string),
+// renew reports the status decided by the health check, registering the instance when it is not registered or its
+// status changed, and renews its lease otherwise.
+func (c *Client) renew(ctx context.Context) error {
+	status := StatusUp
+	if c.config.HealthCheck != nil {
+		status = c.config.HealthCheck(ctx)
+	}
+	changed := c.changeStatus(status, false)
+
+	c.mutex.Lock()
+	registered := c.registered
+	c.mutex.Unlock()
+
+	if !registered || changed {
+		return c.Register(ctx)
+	}
+
+	_, err := c.send(ctx, http.MethodPut, "/apps/"+c.config.AppName+"/"+c.config.InstanceID, nil)
+	if errors.Is(err, errInstanceNotFound) {
+		return c.Register(ctx)
 	}
-	instance.Metadata.Map["foo"] = "bar" //add metadata for example
-	err := client.RegisterInstance("go-backend", instance)
+
 	if err != nil {
-		return fmt.Errorf("failed to join eureka: %s", err)
+		return fmt.Errorf("failed to renew the eureka lease: %w", err)
 	}
 
 	return nil
 }
+
+// changeStatus records status and reports whether it differs from the status reported before. Statuses set by
+// SetStatus are manual; OUT_OF_SERVICE set manually is not replaced by the health check.
+func (c *Client) changeStatus(status Status, manual bool) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if manual {
+		c.override = status == StatusOutOfService
+	} else if c.override {
+		return false
+	}
+
+	if c.status == status {
+		return false
+	}
+
+	c.status = status
+	c.dirtyMillis = time.Now().UnixMilli()
+	return true
+}
+
+// send sends a request to the service URLs in turn until one of them answers with a status other than a server
+// error. A 404 Not Found is returned as errInstanceNotFound.
+func (c *Client) send(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	var errs []error
+	for _, serviceURL := range c.config.ServiceURLs {
+		request, err := http.NewRequestWithContext(ctx, method, serviceURL+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		request.Header.Set("Accept", "application/json")
+		if body != nil {
+			request.Header.Set("Content-Type", "application/json")
+		}
+
+		response, err := c.config.HTTPClient.Do(request)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			errs = append(errs, err)
+			continue
+		}
+		_, _ = io.Copy(io.Discard, response.Body)
+		_ = response.Body.Close()
+
+		switch {
+		case response.StatusCode == http.StatusNotFound:
+			return nil, errInstanceNotFound
+		case response.StatusCode >= http.StatusInternalServerError:
+			errs = append(errs, fmt.Errorf("%s %s returned %s", method, serviceURL+path, response.Status))
+		case response.StatusCode >= http.StatusBadRequest:
+			return nil, fmt.Errorf("%s %s returned %s", method, serviceURL+path, response.Status)
+		default:
+			return response, nil
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// instance is the registration sent to Eureka, in the JSON form of its REST API.
+type instance struct {
+	Instance instanceInfo `json:"instance"`
+}
+
+type instanceInfo struct {
+	InstanceID         string            `json:"instanceId"`
+	HostName           string            `json:"hostName"`
+	App                string            `json:"app"`
+	IPAddr             string            `json:"ipAddr"`
+	VipAddress         string            `json:"vipAddress"`
+	SecureVipAddress   string            `json:"secureVipAddress"`
+	Status             Status            `json:"status"`
+	Port               port              `json:"port"`
+	SecurePort         port              `json:"securePort"`
+	HomePageURL        string            `json:"homePageUrl"`
+	StatusPageURL      string            `json:"statusPageUrl,omitempty"`
+	HealthCheckURL     string            `json:"healthCheckUrl,omitempty"`
+	DataCenterInfo     dataCenterInfo    `json:"dataCenterInfo"`
+	LeaseInfo          leaseInfo         `json:"leaseInfo"`
+	Metadata           map[string]string `json:"metadata"`
+	LastDirtyTimestamp string            `json:"lastDirtyTimestamp"`
+}
+
+type port struct {
+	Port    int    `json:"$"`
+	Enabled string `json:"@enabled"`
+}
+
+type dataCenterInfo struct {
+	Class string `json:"@class"`
+	Name  string `json:"name"`
+}
+
+type leaseInfo struct {
+	RenewalIntervalInSecs int `json:"renewalIntervalInSecs"`
+	DurationInSecs        int `json:"durationInSecs"`
+}
+
+func (c *Client) instanceLocked() instance {
+	metadata := map[string]string{"management.port": strconv.Itoa(c.config.ManagementPort)}
+	if c.config.Version != "" {
+		metadata["version"] = c.config.Version
+	}
+
+	for key, value := range c.config.Metadata {
+		metadata[key] = value
+	}
+
+	return instance{Instance: instanceInfo{
+		InstanceID:       c.config.InstanceID,
+		HostName:         c.config.HostName,
+		App:              strings.ToUpper(c.config.AppName),
+		IPAddr:           c.config.IPAddr,
+		VipAddress:       c.config.AppName,
+		SecureVipAddress: c.config.AppName,
+		Status:           c.status,
+		Port:             port{Port: c.config.Port, Enabled: "true"},
+		SecurePort:       port{Port: 443, Enabled: "false"},
+		HomePageURL:      fmt.Sprintf("http://%s:%d/", c.config.HostName, c.config.Port),
+		StatusPageURL:    c.config.StatusPageURL,
+		HealthCheckURL:   c.config.HealthCheckURL,
+		DataCenterInfo:   dataCenterInfo{Class: "com.netflix.appinfo.InstanceInfo$DefaultDataCenterInfo", Name: "MyOwn"},
+		LeaseInfo: leaseInfo{
+			RenewalIntervalInSecs: int(c.config.RenewalInterval.Seconds()),
+			DurationInSecs:        int(c.config.LeaseDuration.Seconds()),
+		},
+		Metadata:           metadata,
+		LastDirtyTimestamp: strconv.FormatInt(c.dirtyMillis, 10),
+	}}
+}
@@ -0,0 +1,265 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"pdf_service_api/eureka"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeEureka is a Eureka server keeping registrations in memory. failures makes the next requests fail with a 500.
+type fakeEureka struct {
+	mutex     sync.Mutex
+	instances map[string]map[string]any
+	requests  []string
+	failures  int
+}
+
+func newFakeEureka(t *testing.T) (*fakeEureka, *httptest.Server) {
+	fake := &fakeEureka{instances: make(map[string]map[string]any)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeEureka) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	if f.failures > 0 {
+		f.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/eureka/apps/"), "/")
+	switch {
+	case r.Method == http.MethodPost && len(parts) == 1:
+		var body struct {
+			Instance map[string]any `json:"instance"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		f.instances[parts[0]+"/"+body.Instance["instanceId"].(string)] = body.Instance
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut && len(parts) == 2, r.Method == http.MethodDelete && len(parts) == 2:
+		key := parts[0] + "/" + parts[1]
+		if _, ok := f.instances[key]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			delete(f.instances, key)
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (f *fakeEureka) instance(key string) (map[string]any, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	instance, ok := f.instances[key]
+	return instance, ok
+}
+
+func (f *fakeEureka) count(request string) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	count := 0
+	for _, r := range f.requests {
+		if r == request {
+			count++
+		}
+	}
+	return count
+}
+
+func (f *fakeEureka) forget(key string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	delete(f.instances, key)
+}
+
+func newClient(t *testing.T, config eureka.Config) *eureka.Client {
+	config.AppName = "pdf-service"
+	config.HostName = "host"
+	config.IPAddr = "10.0.0.1"
+	config.Port = 8080
+	config.Version = "1.2.3"
+	if config.RenewalInterval == 0 {
+		config.RenewalInterval = 20 * time.Millisecond
+	}
+	config.RetryInterval = 5 * time.Millisecond
+
+	client, err := eureka.NewClient(config)
+	require.NoError(t, err)
+	return client
+}
+
+func runClient(t *testing.T, client *eureka.Client) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.Run(ctx)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestNewClientRequiresInstance(t *testing.T) {
+	_, err := eureka.NewClient(eureka.Config{AppName: "pdf-service", HostName: "host", IPAddr: "10.0.0.1", Port: 8080})
+	assert.Error(t, err, "a service URL is required")
+
+	_, err = eureka.NewClient(eureka.Config{ServiceURLs: []string{"http://localhost:8761/eureka"}, AppName: "pdf-service"})
+	assert.Error(t, err, "the instance must be described")
+}
+
+func TestRegisterSendsInstance(t *testing.T) {
+	fake, server := newFakeEureka(t)
+	client := newClient(t, eureka.Config{
+		ServiceURLs:    []string{server.URL + "/eureka/"},
+		ManagementPort: 9090,
+		Metadata:       map[string]string{"zone": "a"},
+	})
+
+	require.NoError(t, client.Register(context.Background()))
+
+	instance, ok := fake.instance("pdf-service/" + client.InstanceID())
+	require.True(t, ok)
+	assert.Equal(t, "host:pdf-service:8080", client.InstanceID())
+	assert.Equal(t, "PDF-SERVICE", instance["app"])
+	assert.Equal(t, "10.0.0.1", instance["ipAddr"])
+	assert.Equal(t, "STARTING", instance["status"])
+	assert.Equal(t, map[string]any{"$": float64(8080), "@enabled": "true"}, instance["port"])
+	assert.Equal(t, map[string]any{"version": "1.2.3", "management.port": "9090", "zone": "a"}, instance["metadata"])
+}
+
+func TestRunRegistersAndRenews(t *testing.T) {
+	fake, server := newFakeEureka(t)
+	client := newClient(t, eureka.Config{ServiceURLs: []string{server.URL + "/eureka"}})
+	runClient(t, client)
+
+	renewal := "PUT /eureka/apps/pdf-service/" + client.InstanceID()
+	require.Eventually(t, func() bool { return fake.count(renewal) >= 3 }, 2*time.Second, 5*time.Millisecond)
+
+	assert.Equal(t, 1, fake.count("POST /eureka/apps/pdf-service"))
+	instance, _ := fake.instance("pdf-service/" + client.InstanceID())
+	assert.Equal(t, "UP", instance["status"])
+}
+
+func TestRunRegistersAgainWhenForgotten(t *testing.T) {
+	fake, server := newFakeEureka(t)
+	client := newClient(t, eureka.Config{ServiceURLs: []string{server.URL + "/eureka"}})
+	runClient(t, client)
+
+	key := "pdf-service/" + client.InstanceID()
+	require.Eventually(t, func() bool { _, ok := fake.instance(key); return ok }, 2*time.Second, 5*time.Millisecond)
+
+	fake.forget(key)
+	require.Eventually(t, func() bool { return fake.count("POST /eureka/apps/pdf-service") >= 2 }, 2*time.Second, 5*time.Millisecond)
+
+	_, ok := fake.instance(key)
+	assert.True(t, ok)
+}
+
+func TestRunRetriesWithBackoff(t *testing.T) {
+	fake, server := newFakeEureka(t)
+	fake.failures = 3
+	client := newClient(t, eureka.Config{ServiceURLs: []string{server.URL + "/eureka"}, RenewalInterval: time.Hour})
+
+	start := time.Now()
+	runClient(t, client)
+
+	key := "pdf-service/" + client.InstanceID()
+	require.Eventually(t, func() bool { _, ok := fake.instance(key); return ok }, 2*time.Second, time.Millisecond)
+
+	assert.Equal(t, 4, fake.count("POST /eureka/apps/pdf-service"))
+	assert.GreaterOrEqual(t, time.Since(start), (5+10+20)*time.Millisecond, "the delay between retries doubles")
+}
+
+func TestRequestsFailOverToTheNextServer(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(down.Close)
+
+	fake, server := newFakeEureka(t)
+	client := newClient(t, eureka.Config{ServiceURLs: []string{down.URL + "/eureka", server.URL + "/eureka"}})
+
+	require.NoError(t, client.Register(context.Background()))
+	_, ok := fake.instance("pdf-service/" + client.InstanceID())
+	assert.True(t, ok)
+}
+
+func TestStatusFollowsHealthCheck(t *testing.T) {
+	fake, server := newFakeEureka(t)
+
+	var mutex sync.Mutex
+	health := eureka.StatusUp
+	client := newClient(t, eureka.Config{
+		ServiceURLs: []string{server.URL + "/eureka"},
+		HealthCheck: func(ctx context.Context) eureka.Status {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return health
+		},
+	})
+	runClient(t, client)
+
+	key := "pdf-service/" + client.InstanceID()
+	status := func() any { instance, _ := fake.instance(key); return instance["status"] }
+	require.Eventually(t, func() bool { return status() == "UP" }, 2*time.Second, 5*time.Millisecond)
+
+	mutex.Lock()
+	health = eureka.StatusDown
+	mutex.Unlock()
+	require.Eventually(t, func() bool { return status() == "DOWN" }, 2*time.Second, 5*time.Millisecond)
+
+	require.NoError(t, client.SetStatus(context.Background(), eureka.StatusOutOfService))
+	assert.Equal(t, "OUT_OF_SERVICE", status())
+
+	mutex.Lock()
+	health = eureka.StatusUp
+	mutex.Unlock()
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, "OUT_OF_SERVICE", status(), "the health check does not replace OUT_OF_SERVICE")
+
+	require.NoError(t, client.SetStatus(context.Background(), eureka.StatusUp))
+	assert.Equal(t, eureka.StatusUp, client.Status())
+}
+
+func TestDeregister(t *testing.T) {
+	fake, server := newFakeEureka(t)
+	client := newClient(t, eureka.Config{ServiceURLs: []string{server.URL + "/eureka"}})
+
+	require.NoError(t, client.Deregister(context.Background()), "deregistering an unregistered instance does nothing")
+	assert.Zero(t, fake.count("DELETE /eureka/apps/pdf-service/"+client.InstanceID()))
+
+	require.NoError(t, client.Register(context.Background()))
+	require.NoError(t, client.Deregister(context.Background()))
+
+	_, ok := fake.instance("pdf-service/" + client.InstanceID())
+	assert.False(t, ok)
+	assert.Equal(t, 1, fake.count("DELETE /eureka/apps/pdf-service/"+client.InstanceID()))
+}
@@ -1,25 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"github.com/gin-gonic/gin"
 	"log"
 	"os"
+	"pdf_service_api/config"
 	v1 "pdf_service_api/controller/v1"
-	"pdf_service_api/eureka"
+	"pdf_service_api/models"
 	pg "pdf_service_api/postgres"
-	"strconv"
+	"pdf_service_api/storage"
+	"time"
 )
 
-var (
-	dbUser        = os.Getenv("DATABASE_USER")
-	dbPassword    = os.Getenv("DATABASE_PASSWORD")
-	dbPort        = os.Getenv("DATABASE_PORT")
-	dbHost        = os.Getenv("DATABASE_HOST")
-	dbDatabase    = os.Getenv("DATABASE_DB")
-	eurekaAppIp   = os.Getenv("EUREKA_APP_IP")
-	eurekaAppName = os.Getenv("EUREKA_APP_NAME")
-	appPort       = os.Getenv("APP_PORT")
-)
+// version is the version of the build, set with -ldflags "-X main.version=<version>".
+var version = "dev"
 
 // @title           Go Backend API
 // @version         1.0
@@ -36,63 +34,152 @@ var (
 // @host      localhost:8080
 // @BasePath  /api/v1
 
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 A JWT bearer token, sent as "Bearer <token>". The owner is read from the token's sub claim.
+
+// @securityDefinitions.apikey  ApiKeyAuth
+// @in                          header
+// @name                        X-API-Key
+// @description                 An API key created through the keys endpoints, acting for its owner with the key's permissions.
+
 // @externalDocs.description  OpenAPI
 // @externalDocs.url          https://swagger.io/resources/open-api/
 func main() {
-	fmt.Println(os.Hostname())
-	errHandleFunction := func(str string) {
-		panic("Database login credentials must be present.")
+	cfg, args, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		printUsage()
+		return
 	}
-	mustNotBeEmpty(errHandleFunction, dbUser, dbPassword, dbPort, dbHost, dbDatabase)
 
-	dbHandler := pg.DatabaseHandler{DbConfig: pg.ConfigForDatabase{
-		Host:     dbHost,
-		Port:     dbPort,
-		Username: dbUser,
-		Password: dbPassword,
-		Database: dbDatabase,
-	}}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n\n", err)
+		printUsage()
+		os.Exit(2)
+	}
+
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	if command == "help" {
+		printUsage()
+		return
+	}
+
+	run, ok := commands[command]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", command)
+		printUsage()
+		os.Exit(2)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err)
+		os.Exit(2)
+	}
+
+	dbHandler := createDatabaseHandler(cfg.Database)
+	err = run(context.Background(), cfg, dbHandler, args)
+	if closeErr := dbHandler.Close(); closeErr != nil {
+		fmt.Println(closeErr.Error())
+	}
 
-	err := dbHandler.RunInitScript()
 	if err != nil {
-		err = fmt.Errorf("failed to run init script: %s", err)
-		panic(err)
+		log.Fatal(err)
 	}
+}
 
-	documentCtrl := &v1.DocumentController{DocumentRepository: pg.NewDocumentRepository(dbHandler)}
-	selectionCtrl := &v1.SelectionController{SelectionRepository: pg.NewSelectionRepository(dbHandler)}
-	metaCtrl := &v1.MetaController{MetaRepository: pg.NewMetaRepository(dbHandler)}
+const usage = `usage: pdf_service_api [flags] [command] [arguments]
 
-	router := v1.SetupRouter(documentCtrl, selectionCtrl, metaCtrl)
+commands:
+  serve                                  start the HTTP server (the default)
+  migrate up | down [steps] | status     apply, revert or list schema migrations
+  import -owner <uuid> <dir>             upload every PDF in dir for an owner
+  export -out <dir> <owner>              write the documents, meta and selections of an owner to dir
+  reprocess [-owner <uuid>]              extract the meta of every document again
 
-	if eurekaAppIp != "" && appPort != "" {
-		eurekaAppPort, err := strconv.Atoi(appPort)
-		if err != nil {
-			fmt.Println(err.Error())
-		}
+Settings are read from the defaults, the configuration file, the environment variables and the flags, each
+overriding the ones before.
 
-		var appName = eurekaAppName
-		var appHostname = eurekaAppName
-		appHostname, _ = os.Hostname()
+flags:
+`
 
-		if appName == "" {
-			appName = appHostname
-		}
+func printUsage() {
+	fmt.Fprint(os.Stderr, usage)
+	config.Usage(os.Stderr)
+}
+
+// commands maps every subcommand to the function running it with the remaining arguments.
+var commands = map[string]func(ctx context.Context, cfg config.Config, dbHandler pg.DatabaseHandler, args []string) error{
+	"serve":     serve,
+	"migrate":   runMigrateCommand,
+	"import":    runImportCommand,
+	"export":    runExportCommand,
+	"reprocess": runReprocessCommand,
+}
 
-		e := eureka.Eureka{}
-		err = e.JoinEureka(appHostname, eurekaAppIp, appName, eurekaAppPort)
-		if err != nil {
-			fmt.Println(err.Error())
-		}
+// createDatabaseHandler builds the handler for the configured database.
+func createDatabaseHandler(cfg config.DatabaseConfig) pg.DatabaseHandler {
+	return pg.DatabaseHandler{
+		DbConfig: pg.ConfigForDatabase{
+			Host:     cfg.Host,
+			Port:     cfg.Port,
+			Username: cfg.User,
+			Password: cfg.Password,
+			Database: cfg.Name,
+			ConUrl:   cfg.URL,
+		},
+		Pool: pg.ConfigForPool{
+			MaxOpenConns:    cfg.MaxOpenConns,
+			MaxIdleConns:    cfg.MaxIdleConns,
+			ConnMaxLifetime: time.Duration(cfg.ConnMaxLifetime),
+			ConnMaxIdleTime: time.Duration(cfg.ConnMaxIdleTime),
+		},
+		StatementTimeout: time.Duration(cfg.StatementTimeout),
 	}
+}
 
-	log.Fatal(router.Run(":8080"))
+// createBlobStore builds the configured BlobStore.
+func createBlobStore(cfg config.BlobStoreConfig) (models.BlobStore, error) {
+	switch cfg.Type {
+	case "filesystem":
+		return storage.NewFilesystemBlobStore(cfg.Path)
+	case "s3":
+		return storage.NewS3BlobStore(storage.ConfigForS3{
+			Endpoint:  cfg.S3.Endpoint,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			Region:    cfg.S3.Region,
+			UseSSL:    cfg.S3.UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob store type %q", cfg.Type)
+	}
 }
 
-func mustNotBeEmpty(errorHandle func(string), a ...string) {
-	for _, s := range a {
-		if len(s) == 0 {
-			errorHandle(s)
-		}
+// createAuthMiddleware builds the JWT middleware, preceded by the middleware accepting API keys as an alternative.
+// The service refuses to start without an HMAC secret or JWKS unless authentication is disabled, in which case
+// handlers read the owner from the ownerUUID query parameter.
+func createAuthMiddleware(cfg config.AuthConfig, apiKeyRepository models.APIKeyRepository) ([]gin.HandlerFunc, error) {
+	if cfg.Disabled {
+		fmt.Println("Authentication is disabled, requests are trusted to name their owner")
+		return nil, nil
+	}
+
+	middleware, err := v1.NewJWTMiddleware(v1.JWTConfig{
+		HMACSecret: []byte(cfg.JWTHMACSecret),
+		JWKSFile:   cfg.JWTJWKSFile,
+		JWKSURL:    cfg.JWTJWKSURL,
+		Issuer:     cfg.JWTIssuer,
+		Audience:   cfg.JWTAudience,
+	})
+	if err != nil {
+		return nil, err
 	}
+
+	return []gin.HandlerFunc{v1.NewAPIKeyMiddleware(apiKeyRepository), middleware}, nil
 }
@@ -0,0 +1,88 @@
+package unit
+
+import (
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"pdf_service_api/metrics"
+	"strings"
+	"testing"
+	"time"
+)
+
+// metricsRouter serves one route through the middleware, along with /metrics.
+func metricsRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(metrics.Middleware())
+	router.GET("/documents/:id", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	return router
+}
+
+func scrape(t *testing.T, router *gin.Engine) string {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain"), "metrics are served in the text format")
+	return w.Body.String()
+}
+
+func TestMiddlewareLabelsRequestsWithRoute(t *testing.T) {
+	router := metricsRouter()
+	for _, path := range []string{"/documents/1", "/documents/2", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	body := scrape(t, router)
+	assert.Contains(t, body, `pdf_service_http_requests_total{method="GET",route="/documents/:id",status="204"} 2`)
+	assert.Contains(t, body, `pdf_service_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `pdf_service_http_request_duration_seconds_count{method="GET",route="/documents/:id"} 2`)
+	assert.NotContains(t, body, `route="/documents/1"`, "paths are not used as labels")
+}
+
+func TestDomainCounters(t *testing.T) {
+	uploaded := testutil.ToFloat64(metrics.DocumentsUploaded)
+	metrics.DocumentsUploaded.Inc()
+	assert.Equal(t, uploaded+1, testutil.ToFloat64(metrics.DocumentsUploaded))
+
+	body := scrape(t, metricsRouter())
+	assert.Contains(t, body, "pdf_service_documents_uploaded_total")
+	assert.Contains(t, body, "pdf_service_document_bytes_stored_total")
+	assert.Contains(t, body, "pdf_service_selections_created_total")
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestObserveQuery(t *testing.T) {
+	metrics.ObserveQuery("documentRepository", "GetDocumentByDocumentUUID", 30*time.Millisecond)
+
+	body := scrape(t, metricsRouter())
+	assert.Contains(t, body, `pdf_service_db_query_duration_seconds_count{method="GetDocumentByDocumentUUID",repository="documentRepository"} 1`)
+}
+
+func TestDBStatsCollector(t *testing.T) {
+	collector := metrics.NewDBStatsCollector(func() sql.DBStats {
+		return sql.DBStats{MaxOpenConnections: 20, OpenConnections: 5, InUse: 3, Idle: 2, WaitCount: 7, WaitDuration: 1500 * time.Millisecond}
+	})
+
+	expected := `
+# HELP pdf_service_db_in_use_connections Connections currently in use.
+# TYPE pdf_service_db_in_use_connections gauge
+pdf_service_db_in_use_connections 3
+# HELP pdf_service_db_open_connections Established connections, both in use and idle.
+# TYPE pdf_service_db_open_connections gauge
+pdf_service_db_open_connections 5
+# HELP pdf_service_db_wait_duration_seconds_total Time spent waiting for a connection.
+# TYPE pdf_service_db_wait_duration_seconds_total counter
+pdf_service_db_wait_duration_seconds_total 1.5
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"pdf_service_db_in_use_connections", "pdf_service_db_open_connections", "pdf_service_db_wait_duration_seconds_total"))
+	assert.Equal(t, 9, testutil.CollectAndCount(collector))
+}
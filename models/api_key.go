@@ -0,0 +1,56 @@
+package models
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Permissions that can be given to an API key. Callers authenticated with a bearer token hold every permission.
+const (
+	PermissionDocumentsRead   = "documents:read"
+	PermissionDocumentsWrite  = "documents:write"
+	PermissionDocumentsShare  = "documents:share"
+	PermissionSelectionsRead  = "selections:read"
+	PermissionSelectionsWrite = "selections:write"
+	PermissionMetaRead        = "meta:read"
+	PermissionMetaWrite       = "meta:write"
+)
+
+// PermissionKeysManage allows creating, listing and revoking API keys. It cannot be given to an API key, so a key can
+// never be used to create another key with more permissions than itself.
+const PermissionKeysManage = "keys:manage"
+
+// APIKeyPermissions lists the permissions that can be given to an API key.
+var APIKeyPermissions = []string{
+	PermissionDocumentsRead,
+	PermissionDocumentsWrite,
+	PermissionDocumentsShare,
+	PermissionSelectionsRead,
+	PermissionSelectionsWrite,
+	PermissionMetaRead,
+	PermissionMetaWrite,
+}
+
+// APIKey lets a service act for an owner with a limited set of permissions. Only a hash of the key is stored; the
+// key itself is shown once, when it is created, and Prefix holds its first characters so it can be recognised later.
+type APIKey struct {
+	Uuid        uuid.UUID  `json:"keyUUID" example:"0c7a3c9e-4d8a-4c55-9a2b-7b4c1c1fd0d2"`
+	OwnerUUID   uuid.UUID  `json:"ownerUUID" example:"34906041-2d68-45a2-9671-9f0ba89f31a9"`
+	OwnerType   *int       `json:"ownerType,omitempty" example:"1"`
+	Name        string     `json:"name" example:"batch pipeline"`
+	Prefix      string     `json:"prefix" example:"pdfsvc_3Fh1"`
+	Permissions []string   `json:"permissions" example:"documents:read,selections:write"`
+	TimeCreated *time.Time `json:"timeCreated,omitempty"`
+	LastUsed    *time.Time `json:"lastUsed,omitempty"`
+}
+
+// APIKeyRepository stores API keys by the hash of the key. Keys of other owners are reported as sql.ErrNoRows.
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key APIKey, keyHash string) error
+	GetAPIKeys(ctx context.Context, owner uuid.UUID) ([]APIKey, error)
+	RevokeAPIKey(ctx context.Context, key, owner uuid.UUID) error
+	// AuthenticateAPIKey returns the key with the given hash and records that it was used, or returns sql.ErrNoRows
+	// when no such key exists.
+	AuthenticateAPIKey(ctx context.Context, keyHash string) (APIKey, error)
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound is returned by a BlobStore when no content exists for the requested key.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore persists document content outside the database, addressed by a storage key.
+type BlobStore interface {
+	// Put stores the content under key, replacing anything already stored there.
+	// Size is the number of bytes in content, or -1 when it is not known ahead of time.
+	Put(ctx context.Context, key string, content io.Reader, size int64) error
+	// Open returns a seekable reader over the content stored under key.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete removes the content stored under key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// Ping reports an error when the store cannot be reached.
+	Ping(ctx context.Context) error
+}
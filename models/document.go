@@ -1,7 +1,9 @@
 package models
 
 import (
+	"context"
 	"github.com/google/uuid"
+	"io"
 	"time"
 )
 
@@ -12,12 +14,23 @@ type Document struct {
 	OwnerUUID     *uuid.UUID   `json:"ownerUUID,omitempty"`
 	OwnerType     *int         `json:"ownerType,omitempty"`
 	PdfBase64     *string      `json:"pdfBase64,omitempty"`
+	SizeBytes     *int64       `json:"sizeBytes,omitempty"`
+	Checksum      *string      `json:"checksumSHA256,omitempty"`
+	StorageKey    *string      `json:"-"`
 	SelectionData *[]Selection `json:"selectionData,omitempty"`
 }
 
+// DocumentRepository stores documents. Documents can be read by their owner and by anyone they have been shared with
+// through a Grant, and deleted by their owner or grantees with the owner role.
 type DocumentRepository interface {
-	UploadDocument(document Document) error
-	GetDocumentByDocumentUUID(document, owner uuid.UUID, excludes map[string]bool) (Document, error)
-	GetDocumentByOwnerUUID(owner uuid.UUID, limit int8, offset int8, excludes map[string]bool) ([]Document, error)
-	DeleteDocumentById(documentUuid, ownerUuid uuid.UUID) error
+	UploadDocument(ctx context.Context, document Document) error
+	UploadDocumentFromReader(ctx context.Context, document Document, content io.Reader) error
+	// UploadDocumentWithDetails stores a document along with its meta, when not nil, and its initial selections
+	// atomically: when any of them cannot be stored, none of them are.
+	UploadDocumentWithDetails(ctx context.Context, document Document, content io.Reader, meta *Meta, selections []Selection) error
+	GetDocumentByDocumentUUID(ctx context.Context, document, owner uuid.UUID, excludes map[string]bool) (Document, error)
+	// GetDocumentByOwnerUUID lists the documents of owner, including those shared with owner when includeShared is set.
+	GetDocumentByOwnerUUID(ctx context.Context, owner uuid.UUID, limit int8, offset int8, includeShared bool, excludes map[string]bool) ([]Document, error)
+	GetDocumentContent(ctx context.Context, document, owner uuid.UUID) (Document, io.ReadSeekCloser, error)
+	DeleteDocumentById(ctx context.Context, documentUuid, ownerUuid uuid.UUID) error
 }
@@ -0,0 +1,32 @@
+package models
+
+// Extraction methods supported for SelectionBounds.SelectionMethod.
+const (
+	ExtractionMethodText  = "text"
+	ExtractionMethodWords = "words"
+	ExtractionMethodLines = "lines"
+)
+
+// TextBox is a run of text on a page together with its bounding box, in PDF points measured from the bottom left
+// corner of the page. Y1 is the baseline of the text and Y2 the top of its font size.
+type TextBox struct {
+	Text string  `json:"text" example:"Invoice"`
+	X1   float64 `json:"x1" example:"72"`
+	Y1   float64 `json:"y1" example:"700"`
+	X2   float64 `json:"x2" example:"107"`
+	Y2   float64 `json:"y2" example:"710"`
+}
+
+// ExtractedBounds is the text found inside a single SelectionBounds rectangle.
+// Text is always set; Words and Lines are only set when the matching extraction method was requested.
+type ExtractedBounds struct {
+	Bounds SelectionBounds `json:"bounds"`
+	Method string          `json:"method" example:"text"`
+	Text   string          `json:"text"`
+	Words  *[]TextBox      `json:"words,omitempty"`
+	Lines  *[]TextBox      `json:"lines,omitempty"`
+}
+
+// SelectionExtraction holds the extracted text of a selection, keyed by page number in the same way as
+// Selection.SelectionBounds.
+type SelectionExtraction map[int][]ExtractedBounds
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Roles that can be granted on a document. Each role includes the access of the roles before it: viewers can read the
+// document, its selections and meta, editors can also change its selections and meta, and owners can also delete the
+// document and manage who it is shared with.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleOwner  = "owner"
+)
+
+// Grant shares a document with a grantee, who is not its owner, in one of the roles.
+type Grant struct {
+	DocumentUUID uuid.UUID  `json:"documentUUID" example:"ba3ca973-5052-4030-a528-39b49736d8ad"`
+	GranteeUUID  uuid.UUID  `json:"granteeUUID" example:"34906041-2d68-45a2-9671-9f0ba89f31a9"`
+	GranteeType  *int       `json:"granteeType,omitempty" example:"1"`
+	Role         string     `json:"role" example:"viewer"`
+	TimeCreated  *time.Time `json:"timeCreated,omitempty"`
+}
+
+// GrantRepository stores the grants of documents. Grants can only be managed by the document's owner, or by grantees
+// with the owner role; documents that caller may not manage are reported as sql.ErrNoRows, as if they did not exist.
+type GrantRepository interface {
+	// GrantAccess shares a document with the grant's grantee, replacing the role of an existing grant.
+	GrantAccess(ctx context.Context, grant Grant, caller uuid.UUID) error
+	GetGrants(ctx context.Context, document, caller uuid.UUID) ([]Grant, error)
+	RevokeAccess(ctx context.Context, document, grantee, caller uuid.UUID) error
+}
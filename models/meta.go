@@ -1,12 +1,17 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"context"
+	"github.com/google/uuid"
+)
 
+// MetaRepository stores document metadata. Every operation is scoped to the callers that can access the document, as
+// for SelectionRepository, and metadata of documents the caller cannot access is reported as sql.ErrNoRows.
 type MetaRepository interface {
-	AddMeta(data Meta) error
-	DeleteMeta(data Meta) error
-	UpdateMeta(uid uuid.UUID, data Meta) error
-	GetMeta(uid uuid.UUID) (Meta, error)
+	AddMeta(ctx context.Context, data Meta, ownerUid uuid.UUID) error
+	DeleteMeta(ctx context.Context, data Meta, ownerUid uuid.UUID) error
+	UpdateMeta(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID, data Meta) error
+	GetMeta(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID) (Meta, error)
 }
 
 type Meta struct {
@@ -15,6 +20,18 @@ type Meta struct {
 	Width         *float32  `json:"width" example:"1920"`
 	Height        *float32  `json:"height" example:"1080"`
 	Images        *map[uint32]string
-	OwnerUUID     *uuid.UUID `json:"ownerUUID" example:"34906041-2d68-45a2-9671-9f0ba89f31a9"`
-	OwnerType     *string    `json:"ownerType" example:"1"`
+	OwnerUUID     *uuid.UUID  `json:"ownerUUID" example:"34906041-2d68-45a2-9671-9f0ba89f31a9"`
+	OwnerType     *string     `json:"ownerType" example:"1"`
+	Pages         *[]PageMeta `json:"pages,omitempty"`
+}
+
+// PageMeta describes the geometry of a single page. Boxes are given as [llx, lly, urx, ury] in PDF user space units,
+// and Rotation is the clockwise rotation applied when the page is displayed.
+// The top level Width and Height of Meta summarise page 1 for clients that do not read the per page list.
+type PageMeta struct {
+	PageNumber uint32     `json:"pageNumber" example:"1"`
+	MediaBox   [4]float64 `json:"mediaBox"`
+	CropBox    [4]float64 `json:"cropBox"`
+	Rotation   int        `json:"rotation" example:"90"`
+	UserUnit   float64    `json:"userUnit" example:"1"`
 }
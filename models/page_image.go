@@ -0,0 +1,24 @@
+package models
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"io"
+)
+
+// PageImage describes a rendered image of a page of a document, stored in a BlobStore under StorageKey.
+type PageImage struct {
+	DocumentUUID uuid.UUID
+	PageNumber   int
+	Dpi          int
+	Format       string
+	StorageKey   string
+}
+
+// PageImageRepository caches rendered page images so each page is only rendered once per resolution and format.
+type PageImageRepository interface {
+	// GetPageImage opens the cached image of a page of a document readable by owner, or returns sql.ErrNoRows
+	// when the page has not been rendered with that resolution and format.
+	GetPageImage(ctx context.Context, document, owner uuid.UUID, page, dpi int, format string) (PageImage, io.ReadSeekCloser, error)
+	SavePageImage(ctx context.Context, image PageImage, content io.Reader) error
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+// PageWords is the list of words on a single page of a document, with their bounding boxes.
+type PageWords struct {
+	DocumentUUID uuid.UUID `json:"documentUUID" example:"ba3ca973-5052-4030-a528-39b49736d8ad"`
+	PageNumber   int       `json:"pageNumber" example:"1"`
+	Words        []TextBox `json:"words"`
+}
+
+// PageWordsRepository stores the words computed for each page of a document, so they only need to be extracted once.
+type PageWordsRepository interface {
+	// GetPageWords returns the stored words of a page of a document readable by owner, or sql.ErrNoRows
+	// when none have been stored yet.
+	GetPageWords(ctx context.Context, document, owner uuid.UUID, page int) (PageWords, error)
+	SavePageWords(ctx context.Context, words PageWords) error
+}
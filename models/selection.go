@@ -1,21 +1,39 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"context"
+	"github.com/google/uuid"
+)
 
 type Selection struct {
 	Uuid            uuid.UUID                  `json:"selectionUUID"`
 	DocumentUUID    *uuid.UUID                 `json:"documentUUID,omitempty"`
-	IsComplete      bool                       `json:"isComplete,omitempty"`
+	IsComplete      *bool                      `json:"isComplete,omitempty"`
 	Settings        *string                    `json:"settings,omitempty"`
 	SelectionBounds *map[int][]SelectionBounds `json:"selectionBounds,omitempty"`
+	Extraction      *SelectionExtraction       `json:"extraction,omitempty"`
 }
 
+// SelectionRepository stores selections. Every operation is scoped to the callers that can access the selection's
+// document: its owner, and grantees, who can read with any role but only change selections as an editor or owner.
+// Selections of documents the caller cannot access are reported as sql.ErrNoRows, as if they did not exist.
 type SelectionRepository interface {
-	GetSelectionsByDocumentUUID(uid uuid.UUID) ([]Selection, error)
-	GetSelectionsBySelectionUUID(uid uuid.UUID) ([]Selection, error)
-	DeleteSelectionBySelectionUUID(uid uuid.UUID) error
-	AddNewSelection(selection Selection) error
-	DeleteSelectionByDocumentUUID(uid uuid.UUID) error
+	GetSelectionsByDocumentUUID(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID, excludes map[string]bool) ([]Selection, error)
+	GetSelectionsBySelectionUUID(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID, excludes map[string]bool) ([]Selection, error)
+	DeleteSelectionBySelectionUUID(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID) error
+	AddNewSelection(ctx context.Context, selection Selection, ownerUid uuid.UUID) error
+	DeleteSelectionByDocumentUUID(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID) error
+	SaveExtraction(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID, extraction SelectionExtraction) error
+	UpdateSelection(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID, update SelectionUpdate) error
+}
+
+// SelectionUpdate is a partial update of a selection, where nil fields are left unchanged.
+// SelectionBounds replaces the bounds of each page it contains, and a nil or empty list removes that page's bounds;
+// pages it does not contain keep their bounds.
+type SelectionUpdate struct {
+	IsComplete      *bool
+	Settings        *string
+	SelectionBounds map[int]*[]SelectionBounds
 }
 
 type SelectionBounds struct {
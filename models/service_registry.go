@@ -0,0 +1,12 @@
+package models
+
+import "context"
+
+// ServiceRegistry registers the running instance with a service discovery system, so other services can find it.
+type ServiceRegistry interface {
+	// Run registers the instance and keeps its registration and health status up to date until ctx is done.
+	Run(ctx context.Context)
+	// Deregister removes the instance, so it stops being routed to. Deregistering an instance that is not registered
+	// is not an error.
+	Deregister(ctx context.Context) error
+}
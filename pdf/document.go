@@ -0,0 +1,172 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	lpdf "github.com/ledongthuc/pdf"
+	"io"
+	"math"
+	"pdf_service_api/models"
+)
+
+// ErrPageOutOfRange is returned when a page is requested that the document does not have.
+var ErrPageOutOfRange = errors.New("page out of range")
+
+// Document is a parsed PDF file that can be queried for page information.
+type Document struct {
+	reader *lpdf.Reader
+}
+
+// Open parses the PDF held in content, which must be size bytes long.
+func Open(content io.ReaderAt, size int64) (document *Document, err error) {
+	defer recoverParseError(&err)
+
+	reader, err := lpdf.NewReader(content, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pdf: %w", err)
+	}
+
+	return &Document{reader: reader}, nil
+}
+
+// OpenReadSeeker parses the PDF held in content. Content that does not support random access is read into memory first.
+func OpenReadSeeker(content io.ReadSeeker) (*Document, error) {
+	size, err := content.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	if readerAt, ok := content.(io.ReaderAt); ok {
+		return Open(readerAt, size)
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return Open(bytes.NewReader(data), int64(len(data)))
+}
+
+// NumPages returns the number of pages in the document. Reading the page count resolves the document catalog, so
+// malformed documents are reported as an error.
+func (d *Document) NumPages() (count int, err error) {
+	defer recoverParseError(&err)
+
+	return d.reader.NumPage(), nil
+}
+
+// PageGeometry returns the page boxes, rotation and user unit of the given page, resolving any values the page
+// inherits from the page tree. Pages are numbered from 1.
+func (d *Document) PageGeometry(page int) (geometry models.PageMeta, err error) {
+	defer recoverParseError(&err)
+
+	p, err := d.page(page)
+	if err != nil {
+		return models.PageMeta{}, err
+	}
+
+	mediaBox, ok := readRectangle(inheritedAttribute(p, "MediaBox"))
+	if !ok {
+		return models.PageMeta{}, fmt.Errorf("page %d has no valid media box", page)
+	}
+
+	cropBox, ok := readRectangle(inheritedAttribute(p, "CropBox"))
+	if !ok {
+		cropBox = mediaBox
+	}
+
+	userUnit := p.V.Key("UserUnit").Float64()
+	if userUnit <= 0 {
+		userUnit = 1
+	}
+
+	return models.PageMeta{
+		PageNumber: uint32(page),
+		MediaBox:   mediaBox,
+		CropBox:    cropBox,
+		Rotation:   normaliseRotation(inheritedAttribute(p, "Rotate").Int64()),
+		UserUnit:   userUnit,
+	}, nil
+}
+
+// PageSize returns the width and height of the given page's crop box, as it is displayed once its rotation
+// has been applied. Pages are numbered from 1.
+func (d *Document) PageSize(page int) (width, height float64, err error) {
+	geometry, err := d.PageGeometry(page)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	width, height = DisplayedSize(geometry)
+	return width, height, nil
+}
+
+// DisplayedSize returns the width and height of a page's crop box once its rotation has been applied.
+func DisplayedSize(geometry models.PageMeta) (width, height float64) {
+	width = math.Abs(geometry.CropBox[2] - geometry.CropBox[0])
+	height = math.Abs(geometry.CropBox[3] - geometry.CropBox[1])
+
+	if geometry.Rotation == 90 || geometry.Rotation == 270 {
+		width, height = height, width
+	}
+
+	return width, height
+}
+
+func (d *Document) page(page int) (lpdf.Page, error) {
+	numPages, err := d.NumPages()
+	if err != nil {
+		return lpdf.Page{}, err
+	}
+
+	if page < 1 || page > numPages {
+		return lpdf.Page{}, fmt.Errorf("%w: page %d, the document has %d pages", ErrPageOutOfRange, page, numPages)
+	}
+
+	p := d.reader.Page(page)
+	if p.V.IsNull() {
+		return lpdf.Page{}, fmt.Errorf("page %d could not be found", page)
+	}
+
+	return p, nil
+}
+
+// inheritedAttribute looks up a page attribute, walking up the page tree for attributes such as MediaBox and Rotate
+// that a page may inherit from its ancestors.
+func inheritedAttribute(page lpdf.Page, key string) lpdf.Value {
+	for v := page.V; !v.IsNull(); v = v.Key("Parent") {
+		if value := v.Key(key); !value.IsNull() {
+			return value
+		}
+	}
+
+	return lpdf.Value{}
+}
+
+// readRectangle reads a PDF rectangle array, normalising it so the lower left corner comes first.
+func readRectangle(value lpdf.Value) ([4]float64, bool) {
+	if value.Len() != 4 {
+		return [4]float64{}, false
+	}
+
+	x1, y1, x2, y2 := value.Index(0).Float64(), value.Index(1).Float64(), value.Index(2).Float64(), value.Index(3).Float64()
+	return [4]float64{math.Min(x1, x2), math.Min(y1, y2), math.Max(x1, x2), math.Max(y1, y2)}, true
+}
+
+// normaliseRotation maps a /Rotate value onto one of 0, 90, 180 or 270.
+func normaliseRotation(rotation int64) int {
+	return int(((rotation % 360) + 360) % 360)
+}
+
+// recoverParseError converts the panics raised by the underlying parser on malformed input into errors.
+func recoverParseError(err *error) {
+	if r := recover(); r != nil {
+		*err = errors.New(fmt.Sprint("failed to parse pdf: ", r))
+	}
+}
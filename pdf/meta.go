@@ -0,0 +1,43 @@
+package pdf
+
+import (
+	"github.com/google/uuid"
+	"pdf_service_api/models"
+)
+
+// ExtractMeta builds the metadata stored for a document: its page count, the geometry of every page,
+// and the displayed size of the first page as a summary.
+func ExtractMeta(documentUuid uuid.UUID, document *Document) (models.Meta, error) {
+	numPages, err := document.NumPages()
+	if err != nil {
+		return models.Meta{}, err
+	}
+
+	numberOfPages := uint32(numPages)
+	pages := make([]models.PageMeta, 0, numberOfPages)
+	meta := models.Meta{
+		DocumentUUID:  documentUuid,
+		NumberOfPages: &numberOfPages,
+		Pages:         &pages,
+	}
+
+	for page := 1; page <= int(numberOfPages); page++ {
+		geometry, err := document.PageGeometry(page)
+		if err != nil {
+			return models.Meta{}, err
+		}
+
+		pages = append(pages, geometry)
+	}
+
+	if len(pages) == 0 {
+		return meta, nil
+	}
+
+	width, height := DisplayedSize(pages[0])
+	w, h := float32(width), float32(height)
+	meta.Width = &w
+	meta.Height = &h
+
+	return meta, nil
+}
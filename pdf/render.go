@@ -0,0 +1,204 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"pdf_service_api/models"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Image formats supported by EncodeImage.
+const (
+	ImageFormatPNG  = "png"
+	ImageFormatJPEG = "jpeg"
+)
+
+// Defaults for the settings left at zero in RendererConfig. The rasteriser is pdftoppm from poppler-utils, and images
+// are limited to 25 megapixels, enough for a 300 dpi render of an A3 page.
+const (
+	defaultRenderCommand = "pdftoppm"
+	defaultMaxPixels     = 25_000_000
+)
+
+// ErrUnknownImageFormat is returned when an image is requested in a format that is not supported.
+var ErrUnknownImageFormat = errors.New("unknown image format")
+
+// ErrRendererUnavailable is returned when the command rasterising pages cannot be run.
+var ErrRendererUnavailable = errors.New("the page renderer is unavailable")
+
+// ErrImageTooLarge is returned when the image of a page would have more pixels than the renderer allows.
+var ErrImageTooLarge = errors.New("the page image would be too large")
+
+// ImageContentType returns the MIME type of an image format.
+func ImageContentType(format string) (string, error) {
+	switch format {
+	case ImageFormatPNG:
+		return "image/png", nil
+	case ImageFormatJPEG:
+		return "image/jpeg", nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownImageFormat, format)
+	}
+}
+
+// RendererConfig configures a Renderer. Command is the pdftoppm compatible rasteriser to run, found on the PATH unless
+// it is a path, and defaults to pdftoppm. At most MaxConcurrent pages are rendered at once, by default one per CPU,
+// and pages whose image would have more than MaxPixels pixels are refused.
+type RendererConfig struct {
+	Command       string
+	MaxConcurrent int
+	MaxPixels     int64
+}
+
+// Renderer rasterises pages of PDFs by running a separate rasteriser process, so no PDF rendering library is linked
+// into the service.
+type Renderer struct {
+	config RendererConfig
+	slots  chan struct{}
+}
+
+// NewRenderer creates a renderer for config. The command is not looked up until a page is rendered.
+func NewRenderer(config RendererConfig) *Renderer {
+	if config.Command == "" {
+		config.Command = defaultRenderCommand
+	}
+
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = runtime.NumCPU()
+	}
+
+	if config.MaxPixels <= 0 {
+		config.MaxPixels = defaultMaxPixels
+	}
+
+	return &Renderer{config: config, slots: make(chan struct{}, config.MaxConcurrent)}
+}
+
+// RenderPage rasterises a page of the PDF read from content at the given resolution in dots per inch, cropped to the
+// page's crop box and turned by its rotation. Pages are numbered from 1. The content is spooled to a temporary file
+// for the rasteriser, so it is never held in memory. When MaxConcurrent pages are already being rendered, RenderPage
+// waits for one of them to finish or for ctx to end.
+func (r *Renderer) RenderPage(ctx context.Context, content io.Reader, page int, dpi float64) (image.Image, error) {
+	select {
+	case r.slots <- struct{}{}:
+		defer func() { <-r.slots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	dir, err := os.MkdirTemp("", "render-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "document.pdf")
+	size, err := spool(input, content)
+	if err != nil {
+		return nil, err
+	}
+
+	geometry, err := checkPage(input, size, page)
+	if err != nil {
+		return nil, err
+	}
+
+	width, height := DisplayedSize(geometry)
+	scale := geometry.UserUnit * dpi / 72
+	if pixels := math.Ceil(width*scale) * math.Ceil(height*scale); pixels > float64(r.config.MaxPixels) {
+		return nil, fmt.Errorf("%w: page %d would have %.0f pixels at %g dpi, at most %d are allowed", ErrImageTooLarge, page, pixels, dpi, r.config.MaxPixels)
+	}
+
+	output := filepath.Join(dir, "page")
+	number := strconv.Itoa(page)
+	command := exec.CommandContext(ctx, r.config.Command,
+		"-f", number, "-l", number,
+		"-r", strconv.FormatFloat(dpi, 'f', -1, 64),
+		"-cropbox", "-singlefile", "-png",
+		input, output)
+
+	stderr := &bytes.Buffer{}
+	command.Stderr = stderr
+	if err := command.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%w: %w", ErrRendererUnavailable, err)
+		}
+
+		return nil, fmt.Errorf("failed to render page %d: %w: %s", page, err, strings.TrimSpace(stderr.String()))
+	}
+
+	rendered, err := os.Open(output + ".png")
+	if err != nil {
+		return nil, fmt.Errorf("failed to render page %d: %w", page, err)
+	}
+	defer rendered.Close()
+
+	// Check the size the rasteriser reports before decoding, in case it disagrees with the size computed above.
+	config, err := png.DecodeConfig(rendered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render page %d: %w", page, err)
+	}
+
+	if int64(config.Width)*int64(config.Height) > r.config.MaxPixels {
+		return nil, fmt.Errorf("%w: page %d has %dx%d pixels", ErrImageTooLarge, page, config.Width, config.Height)
+	}
+
+	if _, err := rendered.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	return png.Decode(rendered)
+}
+
+// spool copies content into a new file at path, returning the number of bytes written.
+func spool(path string, content io.Reader) (int64, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := io.Copy(file, content)
+	return size, errors.Join(err, file.Close())
+}
+
+// checkPage parses the PDF at path and returns the geometry of the page, so malformed documents, missing pages and
+// oversized pages are reported without running the rasteriser.
+func checkPage(path string, size int64, page int) (geometry models.PageMeta, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return models.PageMeta{}, err
+	}
+	defer file.Close()
+
+	document, err := Open(file, size)
+	if err != nil {
+		return models.PageMeta{}, err
+	}
+
+	return document.PageGeometry(page)
+}
+
+// EncodeImage writes img to w in the given format.
+func EncodeImage(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case ImageFormatPNG:
+		return png.Encode(w, img)
+	case ImageFormatJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownImageFormat, format)
+	}
+}
@@ -0,0 +1,111 @@
+package unit
+
+import (
+	"bytes"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pdf_service_api/models"
+	"pdf_service_api/pdf"
+	"pdf_service_api/testutil"
+	"testing"
+)
+
+func TestExtractMetaLetterDocument(t *testing.T) {
+	documentUuid := uuid.New()
+	content := testutil.BuildTestPDF(testutil.LetterPage(), testutil.LetterPage(), testutil.LetterPage())
+
+	document, err := pdf.Open(bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+
+	meta, err := pdf.ExtractMeta(documentUuid, document)
+	require.NoError(t, err)
+
+	assert.Equal(t, documentUuid, meta.DocumentUUID)
+	assert.EqualValues(t, 3, *meta.NumberOfPages)
+	assert.EqualValues(t, 612, *meta.Width)
+	assert.EqualValues(t, 792, *meta.Height)
+}
+
+func TestExtractMetaUsesCropBoxAndRotation(t *testing.T) {
+	content := testutil.BuildTestPDF(testutil.TestPage{
+		MediaBox: [4]float64{0, 0, 612, 792},
+		CropBox:  &[4]float64{10, 20, 510, 720},
+		Rotate:   90,
+	})
+
+	document, err := pdf.Open(bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+
+	meta, err := pdf.ExtractMeta(uuid.New(), document)
+	require.NoError(t, err)
+
+	assert.EqualValues(t, 700, *meta.Width, "Rotated pages should report their displayed width")
+	assert.EqualValues(t, 500, *meta.Height, "Rotated pages should report their displayed height")
+}
+
+func TestOpenReadSeeker(t *testing.T) {
+	content := testutil.BuildTestPDF(testutil.LetterPage())
+
+	document, err := pdf.OpenReadSeeker(bytes.NewReader(content))
+	require.NoError(t, err)
+
+	numPages, err := document.NumPages()
+	require.NoError(t, err)
+	assert.Equal(t, 1, numPages)
+}
+
+func TestOpenInvalidDocument(t *testing.T) {
+	content := []byte("%PDF-1.4 THIS IS A TEST DOCUMENT")
+
+	_, err := pdf.Open(bytes.NewReader(content), int64(len(content)))
+	assert.Error(t, err)
+}
+
+func TestPageSizeOutOfRange(t *testing.T) {
+	content := testutil.BuildTestPDF(testutil.LetterPage())
+
+	document, err := pdf.Open(bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+
+	_, _, err = document.PageSize(2)
+	assert.Error(t, err)
+}
+
+func TestExtractMetaPages(t *testing.T) {
+	content := testutil.BuildTestPDF(
+		testutil.LetterPage(),
+		testutil.TestPage{
+			MediaBox: [4]float64{0, 0, 612, 792},
+			CropBox:  &[4]float64{510, 720, 10, 20},
+			Rotate:   -90,
+			UserUnit: 2.5,
+		},
+	)
+
+	document, err := pdf.Open(bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+
+	meta, err := pdf.ExtractMeta(uuid.New(), document)
+	require.NoError(t, err)
+	require.NotNil(t, meta.Pages)
+	require.Len(t, *meta.Pages, 2)
+
+	assert.Equal(t, models.PageMeta{
+		PageNumber: 1,
+		MediaBox:   [4]float64{0, 0, 612, 792},
+		CropBox:    [4]float64{0, 0, 612, 792},
+		Rotation:   0,
+		UserUnit:   1,
+	}, (*meta.Pages)[0], "Pages without a crop box should use their media box")
+	assert.Equal(t, models.PageMeta{
+		PageNumber: 2,
+		MediaBox:   [4]float64{0, 0, 612, 792},
+		CropBox:    [4]float64{10, 20, 510, 720},
+		Rotation:   270,
+		UserUnit:   2.5,
+	}, (*meta.Pages)[1], "Boxes and rotation should be normalised")
+
+	assert.EqualValues(t, 612, *meta.Width, "The summary size should describe the first page")
+	assert.EqualValues(t, 792, *meta.Height, "The summary size should describe the first page")
+}
@@ -0,0 +1,167 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"pdf_service_api/pdf"
+	"pdf_service_api/testutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRasteriser writes a pdftoppm compatible command to a temporary directory. It records its arguments and writes
+// a 3x2 PNG where pdftoppm would write the page.
+func fakeRasteriser(t *testing.T) (command string, args func() []string) {
+	dir := t.TempDir()
+
+	fixture := &bytes.Buffer{}
+	require.NoError(t, png.Encode(fixture, image.NewRGBA(image.Rect(0, 0, 3, 2))))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "page.png"), fixture.Bytes(), 0o600))
+
+	command = filepath.Join(dir, "pdftoppm")
+	script := "#!/bin/sh\n" +
+		"echo \"$@\" > " + filepath.Join(dir, "args") + "\n" +
+		"for last; do :; done\n" +
+		"cp " + filepath.Join(dir, "page.png") + " \"$last.png\"\n"
+	require.NoError(t, os.WriteFile(command, []byte(script), 0o700))
+
+	return command, func() []string {
+		recorded, err := os.ReadFile(filepath.Join(dir, "args"))
+		require.NoError(t, err)
+		return strings.Fields(string(recorded))
+	}
+}
+
+func TestRenderPageRunsRasteriser(t *testing.T) {
+	command, args := fakeRasteriser(t)
+	renderer := pdf.NewRenderer(pdf.RendererConfig{Command: command})
+	content := testutil.BuildTestPDF(testutil.LetterPage(), testutil.LetterPage())
+
+	img, err := renderer.RenderPage(context.Background(), bytes.NewReader(content), 2, 72)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 3, 2), img.Bounds())
+	assert.Equal(t, []string{"-f", "2", "-l", "2", "-r", "72", "-cropbox", "-singlefile", "-png"}, args()[:9])
+}
+
+func TestRenderPageRefusesLargeImages(t *testing.T) {
+	command, _ := fakeRasteriser(t)
+	renderer := pdf.NewRenderer(pdf.RendererConfig{Command: command, MaxPixels: 1_000_000})
+	content := testutil.BuildTestPDF(testutil.LetterPage(), testutil.TestPage{MediaBox: [4]float64{0, 0, 14400, 14400}})
+
+	_, err := renderer.RenderPage(context.Background(), bytes.NewReader(content), 1, 72)
+	assert.NoError(t, err, "a letter page has 484704 pixels at 72 dpi")
+
+	_, err = renderer.RenderPage(context.Background(), bytes.NewReader(content), 1, 150)
+	assert.True(t, errors.Is(err, pdf.ErrImageTooLarge), "a letter page has 2103750 pixels at 150 dpi")
+
+	_, err = renderer.RenderPage(context.Background(), bytes.NewReader(content), 2, 24)
+	assert.True(t, errors.Is(err, pdf.ErrImageTooLarge), "a 200 inch page has 23040000 pixels at 24 dpi")
+}
+
+func TestRenderPageRefusesLargeRasteriserOutput(t *testing.T) {
+	command, _ := fakeRasteriser(t)
+	renderer := pdf.NewRenderer(pdf.RendererConfig{Command: command, MaxPixels: 5})
+	content := testutil.BuildTestPDF(testutil.TestPage{MediaBox: [4]float64{0, 0, 1, 1}})
+
+	_, err := renderer.RenderPage(context.Background(), bytes.NewReader(content), 1, 72)
+	assert.True(t, errors.Is(err, pdf.ErrImageTooLarge), "the 3x2 image written by the rasteriser has 6 pixels")
+}
+
+func TestRenderPageLimitsConcurrentRenders(t *testing.T) {
+	dir := t.TempDir()
+	release := filepath.Join(dir, "release")
+	command := filepath.Join(dir, "pdftoppm")
+	script := "#!/bin/sh\n" +
+		"while [ ! -f " + release + " ]; do sleep 0.01; done\n" +
+		"exit 1\n"
+	require.NoError(t, os.WriteFile(command, []byte(script), 0o700))
+
+	renderer := pdf.NewRenderer(pdf.RendererConfig{Command: command, MaxConcurrent: 1})
+	content := testutil.BuildTestPDF(testutil.LetterPage())
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := renderer.RenderPage(context.Background(), bytes.NewReader(content), 1, 72)
+		first <- err
+	}()
+
+	// Wait for the first render to run the rasteriser, which blocks until it is released.
+	require.Eventually(t, func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := renderer.RenderPage(ctx, bytes.NewReader(content), 1, 72)
+		return errors.Is(err, context.DeadlineExceeded)
+	}, 2*time.Second, time.Millisecond, "a second render waits for the first")
+
+	require.NoError(t, os.WriteFile(release, nil, 0o600))
+	assert.Error(t, <-first, "the released rasteriser fails")
+
+	_, err := renderer.RenderPage(context.Background(), bytes.NewReader(content), 1, 72)
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, context.DeadlineExceeded), "the slot is free again")
+}
+
+func TestRenderPageSize(t *testing.T) {
+	if _, err := exec.LookPath("pdftoppm"); err != nil {
+		t.Skip("pdftoppm is not installed")
+	}
+
+	content := testutil.BuildTestPDF(testutil.LetterPage(), testutil.TestPage{MediaBox: [4]float64{0, 0, 842, 595}})
+
+	img, err := pdf.NewRenderer(pdf.RendererConfig{}).RenderPage(context.Background(), bytes.NewReader(content), 2, 36)
+	require.NoError(t, err)
+
+	assert.InDelta(t, 421, img.Bounds().Dx(), 1)
+	assert.InDelta(t, 298, img.Bounds().Dy(), 1)
+}
+
+func TestRenderPageOutOfRange(t *testing.T) {
+	command, _ := fakeRasteriser(t)
+	content := testutil.BuildTestPDF(testutil.LetterPage())
+
+	_, err := pdf.NewRenderer(pdf.RendererConfig{Command: command}).RenderPage(context.Background(), bytes.NewReader(content), 2, 72)
+	assert.True(t, errors.Is(err, pdf.ErrPageOutOfRange))
+}
+
+func TestRenderInvalidDocument(t *testing.T) {
+	command, _ := fakeRasteriser(t)
+
+	_, err := pdf.NewRenderer(pdf.RendererConfig{Command: command}).RenderPage(context.Background(), bytes.NewReader([]byte("%PDF-1.4 THIS IS A TEST DOCUMENT")), 1, 72)
+	assert.Error(t, err)
+}
+
+func TestRendererUnavailable(t *testing.T) {
+	renderer := pdf.NewRenderer(pdf.RendererConfig{Command: filepath.Join(t.TempDir(), "missing")})
+	content := testutil.BuildTestPDF(testutil.LetterPage())
+
+	_, err := renderer.RenderPage(context.Background(), bytes.NewReader(content), 1, 72)
+	assert.True(t, errors.Is(err, pdf.ErrRendererUnavailable))
+}
+
+func TestEncodeImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 10))
+
+	encoded := &bytes.Buffer{}
+	require.NoError(t, pdf.EncodeImage(encoded, img, pdf.ImageFormatPNG))
+	decoded, err := png.Decode(bytes.NewReader(encoded.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, img.Bounds(), decoded.Bounds())
+
+	encoded.Reset()
+	require.NoError(t, pdf.EncodeImage(encoded, img, pdf.ImageFormatJPEG))
+	_, err = jpeg.Decode(bytes.NewReader(encoded.Bytes()))
+	require.NoError(t, err)
+
+	assert.True(t, errors.Is(pdf.EncodeImage(encoded, img, "gif"), pdf.ErrUnknownImageFormat))
+}
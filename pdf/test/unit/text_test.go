@@ -0,0 +1,135 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pdf_service_api/models"
+	"pdf_service_api/pdf"
+	"pdf_service_api/testutil"
+	"testing"
+)
+
+func openTextDocument(t *testing.T) *pdf.Document {
+	content := testutil.BuildTestPDF(testutil.LetterPage(
+		testutil.TestText{X: 100, Y: 700, Size: 10, S: "Invoice number 42"},
+		testutil.TestText{X: 100, Y: 680, Size: 10, S: "Total due"},
+		testutil.TestText{X: 400, Y: 700, Size: 10, S: "Outside"},
+	))
+
+	document, err := pdf.Open(bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+	return document
+}
+
+func method(name string) *string {
+	return &name
+}
+
+func TestExtractBoundsText(t *testing.T) {
+	document := openTextDocument(t)
+
+	result, err := document.ExtractBounds(1, models.SelectionBounds{X1: 90, Y1: 670, X2: 300, Y2: 720})
+	require.NoError(t, err)
+
+	assert.Equal(t, models.ExtractionMethodText, result.Method)
+	assert.Equal(t, "Invoice number 42\nTotal due", result.Text)
+	assert.Nil(t, result.Words)
+	assert.Nil(t, result.Lines)
+}
+
+func TestExtractBoundsNormalisesRectangle(t *testing.T) {
+	document := openTextDocument(t)
+
+	result, err := document.ExtractBounds(1, models.SelectionBounds{SelectionMethod: method("None"), X1: 300, Y1: 690, X2: 90, Y2: 720})
+	require.NoError(t, err)
+
+	assert.Equal(t, models.ExtractionMethodText, result.Method)
+	assert.Equal(t, "Invoice number 42", result.Text)
+}
+
+func TestExtractBoundsOnlyIncludesCharactersInside(t *testing.T) {
+	document := openTextDocument(t)
+
+	// "Invoice" spans 100 to 135, so the rectangle ends halfway through the "c" at 125 to 130.
+	result, err := document.ExtractBounds(1, models.SelectionBounds{X1: 90, Y1: 690, X2: 128, Y2: 720})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Invoic", result.Text)
+}
+
+func TestExtractBoundsWords(t *testing.T) {
+	document := openTextDocument(t)
+
+	result, err := document.ExtractBounds(1, models.SelectionBounds{SelectionMethod: method("words"), X1: 90, Y1: 670, X2: 300, Y2: 720})
+	require.NoError(t, err)
+	require.NotNil(t, result.Words)
+
+	assert.Equal(t, []models.TextBox{
+		{Text: "Invoice", X1: 100, Y1: 700, X2: 135, Y2: 710},
+		{Text: "number", X1: 140, Y1: 700, X2: 170, Y2: 710},
+		{Text: "42", X1: 175, Y1: 700, X2: 185, Y2: 710},
+		{Text: "Total", X1: 100, Y1: 680, X2: 125, Y2: 690},
+		{Text: "due", X1: 130, Y1: 680, X2: 145, Y2: 690},
+	}, *result.Words)
+}
+
+func TestExtractBoundsLines(t *testing.T) {
+	document := openTextDocument(t)
+
+	result, err := document.ExtractBounds(1, models.SelectionBounds{SelectionMethod: method("Lines"), X1: 0, Y1: 0, X2: 612, Y2: 792})
+	require.NoError(t, err)
+	require.NotNil(t, result.Lines)
+
+	assert.Equal(t, []models.TextBox{
+		{Text: "Invoice number 42 Outside", X1: 100, Y1: 700, X2: 435, Y2: 710},
+		{Text: "Total due", X1: 100, Y1: 680, X2: 145, Y2: 690},
+	}, *result.Lines)
+}
+
+func TestExtractBoundsUnknownMethod(t *testing.T) {
+	document := openTextDocument(t)
+
+	_, err := document.ExtractBounds(1, models.SelectionBounds{SelectionMethod: method("ocr"), X2: 612, Y2: 792})
+	assert.True(t, errors.Is(err, pdf.ErrUnknownExtractionMethod))
+}
+
+func TestExtractBoundsPageOutOfRange(t *testing.T) {
+	document := openTextDocument(t)
+
+	_, err := document.ExtractBounds(2, models.SelectionBounds{X2: 612, Y2: 792})
+	assert.Error(t, err)
+}
+
+func TestWordsInReadingOrder(t *testing.T) {
+	content := testutil.BuildTestPDF(testutil.LetterPage(
+		testutil.TestText{X: 100, Y: 680, Size: 10, S: "second line"},
+		testutil.TestText{X: 300, Y: 700, Size: 10, S: "right"},
+		testutil.TestText{X: 100, Y: 700, Size: 10, S: "left"},
+	))
+
+	document, err := pdf.Open(bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+
+	words, err := document.Words(1)
+	require.NoError(t, err)
+
+	assert.Equal(t, []models.TextBox{
+		{Text: "left", X1: 100, Y1: 700, X2: 120, Y2: 710},
+		{Text: "right", X1: 300, Y1: 700, X2: 325, Y2: 710},
+		{Text: "second", X1: 100, Y1: 680, X2: 130, Y2: 690},
+		{Text: "line", X1: 135, Y1: 680, X2: 155, Y2: 690},
+	}, words)
+}
+
+func TestWordsOnEmptyPage(t *testing.T) {
+	content := testutil.BuildTestPDF(testutil.LetterPage())
+
+	document, err := pdf.Open(bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+
+	words, err := document.Words(1)
+	require.NoError(t, err)
+	assert.Empty(t, words)
+}
@@ -0,0 +1,205 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	lpdf "github.com/ledongthuc/pdf"
+	"math"
+	"pdf_service_api/models"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrUnknownExtractionMethod is returned when a selection asks for an extraction method that is not supported.
+var ErrUnknownExtractionMethod = errors.New("unknown extraction method")
+
+// ExtractionMethod resolves the extraction method requested by a selection bounds rectangle.
+// A missing method, or the placeholder "None", selects plain text extraction.
+func ExtractionMethod(bounds models.SelectionBounds) (string, error) {
+	if bounds.SelectionMethod == nil {
+		return models.ExtractionMethodText, nil
+	}
+
+	switch method := strings.ToLower(strings.TrimSpace(*bounds.SelectionMethod)); method {
+	case "", "none", models.ExtractionMethodText:
+		return models.ExtractionMethodText, nil
+	case models.ExtractionMethodWords, models.ExtractionMethodLines:
+		return method, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownExtractionMethod, *bounds.SelectionMethod)
+	}
+}
+
+// ExtractBounds returns the text on the given page that lies inside bounds, using the extraction method the bounds
+// request. Bounds are in PDF points measured from the bottom left corner of the page, and a character is inside the
+// rectangle when its centre is. Pages are numbered from 1.
+func (d *Document) ExtractBounds(page int, bounds models.SelectionBounds) (models.ExtractedBounds, error) {
+	method, err := ExtractionMethod(bounds)
+	if err != nil {
+		return models.ExtractedBounds{}, err
+	}
+
+	glyphs, err := d.glyphs(page)
+	if err != nil {
+		return models.ExtractedBounds{}, err
+	}
+
+	x1, x2 := math.Min(bounds.X1, bounds.X2), math.Max(bounds.X1, bounds.X2)
+	y1, y2 := math.Min(bounds.Y1, bounds.Y2), math.Max(bounds.Y1, bounds.Y2)
+
+	inside := make([]lpdf.Text, 0, len(glyphs))
+	for _, glyph := range glyphs {
+		centreX, centreY := glyph.X+glyph.W/2, glyph.Y+glyph.FontSize/2
+		if centreX >= x1 && centreX <= x2 && centreY >= y1 && centreY <= y2 {
+			inside = append(inside, glyph)
+		}
+	}
+
+	lines := groupLines(groupWords(inside))
+	lineBoxes := make([]models.TextBox, 0, len(lines))
+	for _, line := range lines {
+		lineBoxes = append(lineBoxes, joinBoxes(line, " "))
+	}
+
+	result := models.ExtractedBounds{
+		Bounds: bounds,
+		Method: method,
+		Text:   joinBoxes(lineBoxes, "\n").Text,
+	}
+
+	switch method {
+	case models.ExtractionMethodWords:
+		words := make([]models.TextBox, 0)
+		for _, line := range lines {
+			words = append(words, line...)
+		}
+		result.Words = &words
+	case models.ExtractionMethodLines:
+		result.Lines = &lineBoxes
+	}
+
+	return result, nil
+}
+
+// Words returns every word on the given page with its bounding box, in reading order: lines from the top of the
+// page down, and words within a line from left to right. Pages are numbered from 1.
+func (d *Document) Words(page int) ([]models.TextBox, error) {
+	glyphs, err := d.glyphs(page)
+	if err != nil {
+		return nil, err
+	}
+
+	words := make([]models.TextBox, 0)
+	for _, line := range groupLines(groupWords(glyphs)) {
+		words = append(words, line...)
+	}
+
+	return words, nil
+}
+
+// glyphs returns every character drawn on the given page, in content stream order.
+func (d *Document) glyphs(page int) (glyphs []lpdf.Text, err error) {
+	defer recoverParseError(&err)
+
+	p, err := d.page(page)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Content().Text, nil
+}
+
+// groupWords joins consecutive characters that share a baseline and are not separated by whitespace or a gap into
+// words.
+func groupWords(glyphs []lpdf.Text) []models.TextBox {
+	words := make([]models.TextBox, 0)
+	var current *models.TextBox
+	var builder strings.Builder
+
+	flush := func() {
+		if current != nil {
+			current.Text = builder.String()
+			words = append(words, *current)
+			current = nil
+			builder.Reset()
+		}
+	}
+
+	for _, glyph := range glyphs {
+		if strings.TrimFunc(glyph.S, unicode.IsSpace) == "" {
+			flush()
+			continue
+		}
+
+		if current != nil {
+			gap := glyph.X - current.X2
+			sameBaseline := math.Abs(glyph.Y-current.Y1) <= glyph.FontSize*0.3
+			if !sameBaseline || gap > glyph.FontSize*0.25 || gap < -glyph.FontSize*0.25 {
+				flush()
+			}
+		}
+
+		if current == nil {
+			current = &models.TextBox{X1: glyph.X, Y1: glyph.Y, X2: glyph.X, Y2: glyph.Y}
+		}
+
+		builder.WriteString(glyph.S)
+		current.X2 = math.Max(current.X2, glyph.X+glyph.W)
+		current.Y2 = math.Max(current.Y2, glyph.Y+glyph.FontSize)
+	}
+
+	flush()
+	return words
+}
+
+// groupLines arranges words into lines that read from the top of the page down, each ordered left to right.
+func groupLines(words []models.TextBox) [][]models.TextBox {
+	sorted := append([]models.TextBox(nil), words...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Y1 > sorted[j].Y1
+	})
+
+	lines := make([][]models.TextBox, 0)
+	for _, word := range sorted {
+		last := len(lines) - 1
+		if last >= 0 {
+			baseline := lines[last][0]
+			tolerance := math.Min(word.Y2-word.Y1, baseline.Y2-baseline.Y1) * 0.5
+			if math.Abs(word.Y1-baseline.Y1) <= tolerance {
+				lines[last] = append(lines[last], word)
+				continue
+			}
+		}
+
+		lines = append(lines, []models.TextBox{word})
+	}
+
+	for _, line := range lines {
+		sort.SliceStable(line, func(i, j int) bool {
+			return line[i].X1 < line[j].X1
+		})
+	}
+
+	return lines
+}
+
+// joinBoxes merges boxes into one box covering all of them, joining their text with separator.
+func joinBoxes(boxes []models.TextBox, separator string) models.TextBox {
+	if len(boxes) == 0 {
+		return models.TextBox{}
+	}
+
+	joined := boxes[0]
+	texts := []string{boxes[0].Text}
+	for _, box := range boxes[1:] {
+		joined.X1 = math.Min(joined.X1, box.X1)
+		joined.Y1 = math.Min(joined.Y1, box.Y1)
+		joined.X2 = math.Max(joined.X2, box.X2)
+		joined.Y2 = math.Max(joined.Y2, box.Y2)
+		texts = append(texts, box.Text)
+	}
+
+	joined.Text = strings.Join(texts, separator)
+	return joined
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"pdf_service_api/models"
+	"time"
+)
+
+type apiKeyRepository struct {
+	databaseManager DatabaseHandler
+}
+
+func NewAPIKeyRepository(db DatabaseHandler) models.APIKeyRepository {
+	return apiKeyRepository{databaseManager: db}
+}
+
+func (a apiKeyRepository) CreateAPIKey(ctx context.Context, key models.APIKey, keyHash string) error {
+	err := a.databaseManager.WithContext(ctx, "apiKeyRepository.CreateAPIKey", createAPIKeyFunction(key, keyHash))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a apiKeyRepository) GetAPIKeys(ctx context.Context, owner uuid.UUID) ([]models.APIKey, error) {
+	keys := make([]models.APIKey, 0)
+	err := a.databaseManager.WithContext(ctx, "apiKeyRepository.GetAPIKeys", getAPIKeysFunction(owner, func(data []models.APIKey) {
+		keys = data
+	}))
+	if err != nil {
+		return keys, err
+	}
+
+	return keys, nil
+}
+
+func (a apiKeyRepository) RevokeAPIKey(ctx context.Context, key, owner uuid.UUID) error {
+	err := a.databaseManager.WithContext(ctx, "apiKeyRepository.RevokeAPIKey", revokeAPIKeyFunction(key, owner))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a apiKeyRepository) AuthenticateAPIKey(ctx context.Context, keyHash string) (models.APIKey, error) {
+	key := models.APIKey{}
+	err := a.databaseManager.WithContext(ctx, "apiKeyRepository.AuthenticateAPIKey", authenticateAPIKeyFunction(keyHash, func(data models.APIKey) {
+		key = data
+	}))
+	if err != nil {
+		return models.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+const apiKeyColumns = `"Key_UUID", "Owner_UUID", "Owner_Type", "Name", "Key_Prefix", "Permissions", "Time_Created", "Last_Used"`
+
+func apiKeyScanDestinations(key *models.APIKey) []any {
+	return []any{&key.Uuid, &key.OwnerUUID, &key.OwnerType, &key.Name, &key.Prefix, pq.Array(&key.Permissions), &key.TimeCreated, &key.LastUsed}
+}
+
+func createAPIKeyFunction(key models.APIKey, keyHash string) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `INSERT INTO api_key_table ("Key_UUID", "Owner_UUID", "Owner_Type", "Name", "Key_Prefix", "Key_Hash", "Permissions") VALUES ($1, $2, $3, $4, $5, $6, $7)`
+		_, err := db.ExecContext(ctx, sqlStatement, key.Uuid, key.OwnerUUID, key.OwnerType, key.Name, key.Prefix, keyHash, pq.Array(key.Permissions))
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+func getAPIKeysFunction(owner uuid.UUID, callback func(data []models.APIKey)) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `SELECT ` + apiKeyColumns + ` FROM api_key_table WHERE "Owner_UUID" = $1 ORDER BY "Time_Created" DESC`
+		rows, err := db.QueryContext(ctx, sqlStatement, owner)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		keys := make([]models.APIKey, 0)
+		for rows.Next() {
+			key := models.APIKey{}
+			if err := rows.Scan(apiKeyScanDestinations(&key)...); err != nil {
+				return err
+			}
+
+			keys = append(keys, key)
+		}
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		callback(keys)
+		return nil
+	}
+}
+
+func revokeAPIKeyFunction(key, owner uuid.UUID) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `DELETE FROM api_key_table WHERE "Key_UUID" = $1 AND "Owner_UUID" = $2`
+		result, err := db.ExecContext(ctx, sqlStatement, key, owner)
+		if err != nil {
+			return err
+		}
+
+		return requireAffectedRows(result)
+	}
+}
+
+// lastUsedResolution is how stale the recorded last use of a key may get. Authenticating with a key only writes its
+// last use when the recorded one is older, so keys used for every request of a batch do not write on every request.
+const lastUsedResolution = time.Minute
+
+// authenticateAPIKeyFunction looks a key up by its hash, and records the time it was last used when the recorded one
+// is older than lastUsedResolution.
+func authenticateAPIKeyFunction(keyHash string, callback func(data models.APIKey)) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `SELECT ` + apiKeyColumns + ` FROM api_key_table WHERE "Key_Hash" = $1`
+		key := models.APIKey{}
+		if err := db.QueryRowContext(ctx, sqlStatement, keyHash).Scan(apiKeyScanDestinations(&key)...); err != nil {
+			return err
+		}
+
+		// Postgres decides whether the last use is stale, comparing it with its own clock in the time zone it was
+		// written in, and concurrent requests with the same key write it only once.
+		sqlStatement = `UPDATE api_key_table SET "Last_Used" = now() WHERE "Key_UUID" = $1 AND ("Last_Used" IS NULL OR "Last_Used" < now() - $2::float8 * interval '1 second') RETURNING "Last_Used"`
+		err := db.QueryRowContext(ctx, sqlStatement, key.Uuid, lastUsedResolution.Seconds()).Scan(&key.LastUsed)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		callback(key)
+		return nil
+	}
+}
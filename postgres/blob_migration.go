@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"pdf_service_api/models"
+	"strings"
+)
+
+const blobMigrationBatchSize = 50
+
+var errDocumentChanged = errors.New("document was changed or removed while it was being migrated")
+
+// BlobMigrationResult reports how many legacy documents were moved into the blob store and which ones could not be.
+type BlobMigrationResult struct {
+	Migrated int
+	Failed   map[uuid.UUID]error
+}
+
+// MigrateDocumentsToBlobStore moves the content of every document that is still held in the legacy
+// "Document_Base64" column into the blob store, recording its storage key, size and checksum and clearing the column.
+// It is safe to run repeatedly and alongside a running service; documents that fail to migrate are left untouched.
+func MigrateDocumentsToBlobStore(databaseManager DatabaseHandler, blobStore models.BlobStore) (BlobMigrationResult, error) {
+	result := BlobMigrationResult{Failed: make(map[uuid.UUID]error)}
+	ctx := context.Background()
+	lastUuid := uuid.Nil
+
+	for {
+		batch := make([]legacyDocument, 0, blobMigrationBatchSize)
+		err := databaseManager.WithContext(ctx, "postgres.MigrateDocumentsToBlobStore", getLegacyDocumentsFunction(lastUuid, blobMigrationBatchSize, func(data []legacyDocument) {
+			batch = data
+		}))
+		if err != nil {
+			return result, err
+		}
+
+		if len(batch) == 0 {
+			return result, nil
+		}
+
+		for _, document := range batch {
+			lastUuid = document.uuid
+
+			key := documentStorageKey(document.uuid)
+			content := base64.NewDecoder(base64.StdEncoding, strings.NewReader(document.pdfBase64))
+			blob, err := putBlob(ctx, blobStore, key, content)
+			if err != nil {
+				_ = blobStore.Delete(ctx, key)
+				result.Failed[document.uuid] = fmt.Errorf("failed to store content: %w", err)
+				continue
+			}
+
+			err = databaseManager.WithContext(ctx, "postgres.MigrateDocumentsToBlobStore", markDocumentMigratedFunction(document.uuid, key, blob))
+			if errors.Is(err, errDocumentChanged) {
+				// Another replica may have migrated the same document into the same key, so the blob is left in place.
+				result.Failed[document.uuid] = err
+				continue
+			}
+
+			if err != nil {
+				_ = blobStore.Delete(ctx, key)
+				result.Failed[document.uuid] = err
+				continue
+			}
+
+			result.Migrated++
+		}
+	}
+}
+
+type legacyDocument struct {
+	uuid      uuid.UUID
+	pdfBase64 string
+}
+
+func getLegacyDocumentsFunction(after uuid.UUID, limit int, callback func(data []legacyDocument)) contextCallback {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `SELECT "Document_UUID", "Document_Base64" FROM document_table WHERE "Storage_Key" IS NULL AND "Document_Base64" IS NOT NULL AND "Document_UUID" > $1 ORDER BY "Document_UUID" LIMIT $2`
+		rows, err := db.QueryContext(ctx, sqlStatement, after, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		documents := make([]legacyDocument, 0)
+		for rows.Next() {
+			document := legacyDocument{}
+			if err := rows.Scan(&document.uuid, &document.pdfBase64); err != nil {
+				return err
+			}
+
+			documents = append(documents, document)
+		}
+
+		callback(documents)
+		return rows.Err()
+	}
+}
+
+func markDocumentMigratedFunction(documentUuid uuid.UUID, key string, blob storedBlob) contextCallback {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `UPDATE document_table SET "Storage_Key" = $1, "Size_Bytes" = $2, "Checksum_SHA256" = $3, "Document_Base64" = NULL WHERE "Document_UUID" = $4 AND "Storage_Key" IS NULL`
+		result, err := db.ExecContext(ctx, sqlStatement, key, blob.size, blob.checksum, documentUuid)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return errDocumentChanged
+		}
+
+		return nil
+	}
+}
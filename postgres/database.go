@@ -1,55 +1,245 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
-	_ "embed"
-	"fmt"
+	"errors"
+	"pdf_service_api/metrics"
+	"strings"
+	"sync"
+	"time"
 )
 
+// Defaults for the connection pool settings left at zero in ConfigForPool.
+const (
+	defaultMaxOpenConns    = 20
+	defaultMaxIdleConns    = 10
+	defaultConnMaxLifetime = 30 * time.Minute
+	defaultConnMaxIdleTime = 5 * time.Minute
+)
+
+// defaultStatementTimeout bounds the statements run through WithContext and WithTransaction when
+// DatabaseHandler.StatementTimeout is zero.
+const defaultStatementTimeout = 30 * time.Second
+
+// DatabaseHandler gives repositories access to the database through a long-lived connection pool. The pool is opened
+// on first use and shared by every handler with the same connection and pool settings, so repositories can keep their
+// own copy of the handler. Close closes the pool when the service shuts down.
+// StatementTimeout bounds the statements run through WithContext and WithTransaction, unless their context ends sooner.
 type DatabaseHandler struct {
-	DbConfig ConfigForDatabase
+	DbConfig         ConfigForDatabase
+	Pool             ConfigForPool
+	StatementTimeout time.Duration
+}
+
+// ConfigForPool limits the connections held by the pool. Zero values select the defaults.
+type ConfigForPool struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
 }
 
+// poolKey identifies a shared pool. Handlers connecting to the same database with different pool settings get pools of
+// their own, so neither has its limits overridden by the other.
+type poolKey struct {
+	dataSource string
+	pool       ConfigForPool
+}
+
+var (
+	poolsMutex sync.Mutex
+	pools      = make(map[poolKey]*sql.DB)
+)
+
 type createdCallback func(db *sql.DB) error
 
+// Executor runs statements. Both *sql.DB and *sql.Tx implement it, so the statements of a repository can run on their
+// own through WithContext or together with others through WithTransaction.
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type contextCallback func(ctx context.Context, db Executor) error
+
+// WithConnection runs callback with the shared pool. Callbacks must not close the pool. Their statements are neither
+// bounded nor timed, so repositories use WithContext or WithTransaction instead.
 func (t *DatabaseHandler) WithConnection(callback createdCallback) error {
-	str := t.DbConfig.GetPsqlInfo()
-	db, err := sql.Open("postgres", str) //Create connection string
+	db, err := t.DB()
 	if err != nil {
 		return err
 	}
 
-	err = callback(db)
+	return callback(db)
+}
+
+// WithContext runs callback with the shared pool and a context derived from ctx that also ends after the statement
+// timeout. Callbacks pass the context to QueryContext and ExecContext, so their statements are cancelled when the
+// request they serve is. The time taken is recorded against query, which names the repository method running the
+// statements as repository.method, such as selectionRepository.AddNewSelection.
+func (t *DatabaseHandler) WithContext(ctx context.Context, query string, callback contextCallback) error {
+	defer observeQuery(query, time.Now())
+
+	db, err := t.DB()
 	if err != nil {
 		return err
 	}
 
-	defer func(db *sql.DB) { // Runs once withConnection has finished execution!
-		err := db.Close()
-		if err != nil {
-			panic(err)
+	ctx, cancel := context.WithTimeout(ctx, orDefault(t.StatementTimeout, defaultStatementTimeout))
+	defer cancel()
+
+	return callback(ctx, db)
+}
+
+// WithTransaction runs callback in a transaction bounded and timed like WithContext. The transaction is committed when
+// callback returns nil, and rolled back when it returns an error or panics, so either all of its statements take effect
+// or none.
+func (t *DatabaseHandler) WithTransaction(ctx context.Context, query string, callback contextCallback) error {
+	defer observeQuery(query, time.Now())
+
+	db, err := t.DB()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, orDefault(t.StatementTimeout, defaultStatementTimeout))
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			_ = tx.Rollback()
+			panic(recovered)
 		}
+	}()
 
-		if r := recover(); r != nil {
-			fmt.Printf("Recovered from panic: %v (type: %T)\n", r, r)
+	if err := callback(ctx, tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
 		}
-	}(db)
 
-	return nil
+		return err
+	}
+
+	return tx.Commit()
 }
 
-//go:embed SqlScripts/BasicSetup.sql
-var sqlScript string
+// DB returns the pool for the handler's connection and pool settings, opening it on first use.
+func (t *DatabaseHandler) DB() (*sql.DB, error) {
+	key, err := t.poolKey()
+	if err != nil {
+		return nil, err
+	}
 
-func (t *DatabaseHandler) RunInitScript() error {
-	err := t.WithConnection(func(db *sql.DB) error {
-		_, err := db.Exec(sqlScript)
-		if err != nil {
-			return err
-		}
+	poolsMutex.Lock()
+	defer poolsMutex.Unlock()
 
+	if db, ok := pools[key]; ok {
+		return db, nil
+	}
+
+	db, err := sql.Open("postgres", key.dataSource)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(key.pool.MaxOpenConns)
+	db.SetMaxIdleConns(key.pool.MaxIdleConns)
+	db.SetConnMaxLifetime(key.pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(key.pool.ConnMaxIdleTime)
+
+	pools[key] = db
+	return db, nil
+}
+
+// Close closes the pool for the handler's connection and pool settings. A later call to WithConnection opens a new
+// pool.
+func (t *DatabaseHandler) Close() error {
+	key, err := t.poolKey()
+	if err != nil {
 		return nil
-	})
+	}
+
+	poolsMutex.Lock()
+	db, ok := pools[key]
+	delete(pools, key)
+	poolsMutex.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	return db.Close()
+}
+
+// Stats returns the statistics of the handler's pool, or zero statistics when it has not been opened.
+func (t *DatabaseHandler) Stats() sql.DBStats {
+	key, err := t.poolKey()
+	if err != nil {
+		return sql.DBStats{}
+	}
+
+	poolsMutex.Lock()
+	defer poolsMutex.Unlock()
+
+	if db, ok := pools[key]; ok {
+		return db.Stats()
+	}
+
+	return sql.DBStats{}
+}
+
+// poolKey returns the key of the handler's pool, with the defaults applied to the pool settings left at zero so they
+// share a pool with handlers setting the defaults explicitly.
+func (t *DatabaseHandler) poolKey() (poolKey, error) {
+	str, err := t.DbConfig.GetPsqlInfo()
+	if err != nil {
+		return poolKey{}, err
+	}
+
+	return poolKey{
+		dataSource: str,
+		pool: ConfigForPool{
+			MaxOpenConns:    orDefault(t.Pool.MaxOpenConns, defaultMaxOpenConns),
+			MaxIdleConns:    orDefault(t.Pool.MaxIdleConns, defaultMaxIdleConns),
+			ConnMaxLifetime: orDefault(t.Pool.ConnMaxLifetime, defaultConnMaxLifetime),
+			ConnMaxIdleTime: orDefault(t.Pool.ConnMaxIdleTime, defaultConnMaxIdleTime),
+		},
+	}, nil
+}
+
+// observeQuery records the time taken since start against query, named as repository.method.
+func observeQuery(query string, start time.Time) {
+	repository, method, _ := strings.Cut(query, ".")
+	metrics.ObserveQuery(repository, method, time.Since(start))
+}
+
+func orDefault[T comparable](value, fallback T) T {
+	var zero T
+	if value == zero {
+		return fallback
+	}
 
-	return err
+	return value
+}
+
+// requireAffectedRows returns sql.ErrNoRows when a statement changed no rows, so repositories can report rows that
+// do not exist, or belong to another owner, the same way as a query that found nothing.
+func requireAffectedRows(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
 }
@@ -1,10 +1,12 @@
 package postgres
 
 import (
+	"errors"
 	"fmt"
 	_ "github.com/lib/pq"
 )
 
+// ConfigForDatabase holds the connection settings of a database. ConUrl, when set, is used in place of the others.
 type ConfigForDatabase struct {
 	Host     string
 	Port     string
@@ -14,53 +16,40 @@ type ConfigForDatabase struct {
 	ConUrl   string
 }
 
-func (t *ConfigForDatabase) GetHost() string {
-	if len(t.Host) == 0 {
-		panic("Host variable not set for database!")
+// Validate reports every connection setting that is missing.
+func (t *ConfigForDatabase) Validate() error {
+	if len(t.ConUrl) != 0 {
+		return nil
 	}
 
-	return t.Host
-}
-
-func (t *ConfigForDatabase) GetPort() string {
-	if len(t.Port) == 0 {
-		panic("Host variable not set for database!")
-	}
-
-	return t.Port
-}
-
-func (t *ConfigForDatabase) GetUsername() string {
-	if len(t.Username) == 0 {
-		panic("Host variable not set for database!")
+	var problems []error
+	for _, required := range []struct{ name, value string }{
+		{"host", t.Host},
+		{"port", t.Port},
+		{"username", t.Username},
+		{"password", t.Password},
+		{"database", t.Database},
+	} {
+		if len(required.value) == 0 {
+			problems = append(problems, fmt.Errorf("the database %s is not set", required.name))
+		}
 	}
 
-	return t.Username
+	return errors.Join(problems...)
 }
 
-func (t *ConfigForDatabase) GetPassword() string {
-	if len(t.Password) == 0 {
-		panic("Host variable not set for database!")
+// GetPsqlInfo returns the connection string of the database, or an error naming the missing settings.
+func (t *ConfigForDatabase) GetPsqlInfo() (string, error) {
+	if err := t.Validate(); err != nil {
+		return "", err
 	}
 
-	return t.Password
-}
-
-func (t *ConfigForDatabase) GetDatabase() string {
-	if len(t.Database) == 0 {
-		panic("Host variable not set for database!")
-	}
-
-	return t.Database
-}
-
-func (t *ConfigForDatabase) GetPsqlInfo() string {
 	if len(t.ConUrl) == 0 {
 		psqlInfo := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-			t.GetUsername(), t.GetPassword(), t.GetHost(), t.GetPort(), t.GetDatabase())
+			t.Username, t.Password, t.Host, t.Port, t.Database)
 
-		return psqlInfo
+		return psqlInfo, nil
 	}
 
-	return t.ConUrl
+	return t.ConUrl, nil
 }
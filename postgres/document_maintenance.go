@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+const documentBatchSize = 50
+
+// DocumentReference identifies a document and its owner, for maintenance jobs that go through every document.
+type DocumentReference struct {
+	Uuid      uuid.UUID
+	OwnerUUID *uuid.UUID
+}
+
+// ForEachDocument calls callback with every document, or with the documents owned by owner when it is not nil, in
+// order of their UUIDs. Documents are read in batches, so documents created while it runs may or may not be visited.
+// It stops at the first error returned by callback.
+func ForEachDocument(ctx context.Context, databaseManager DatabaseHandler, owner *uuid.UUID, callback func(document DocumentReference) error) error {
+	lastUuid := uuid.Nil
+
+	for {
+		batch := make([]DocumentReference, 0, documentBatchSize)
+		err := databaseManager.WithContext(ctx, "postgres.ForEachDocument", getDocumentReferencesFunction(owner, lastUuid, documentBatchSize, func(data []DocumentReference) {
+			batch = data
+		}))
+		if err != nil {
+			return err
+		}
+
+		if len(batch) == 0 {
+			return nil
+		}
+
+		for _, document := range batch {
+			lastUuid = document.Uuid
+			if err := callback(document); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func getDocumentReferencesFunction(owner *uuid.UUID, after uuid.UUID, limit int, callback func(data []DocumentReference)) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `SELECT "Document_UUID", "Owner_UUID" FROM document_table WHERE ($1::uuid IS NULL OR "Owner_UUID" = $1) AND "Document_UUID" > $2 ORDER BY "Document_UUID" LIMIT $3`
+		rows, err := db.QueryContext(ctx, sqlStatement, owner, after, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		documents := make([]DocumentReference, 0)
+		for rows.Next() {
+			document := DocumentReference{}
+			if err := rows.Scan(&document.Uuid, &document.OwnerUUID); err != nil {
+				return err
+			}
+
+			documents = append(documents, document)
+		}
+
+		callback(documents)
+		return rows.Err()
+	}
+}
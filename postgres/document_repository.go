@@ -2,50 +2,92 @@ package postgres
 
 import (
 	"bytes"
-	"database/sql"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"io"
+	"pdf_service_api/metrics"
 	"pdf_service_api/models"
+	"strings"
 	"text/template"
 )
 
 type documentRepository struct {
 	databaseManager DatabaseHandler
+	blobStore       models.BlobStore
 }
 
+// NewDocumentRepository creates a repository that keeps document content in the legacy "Document_Base64" column.
 func NewDocumentRepository(databaseManager DatabaseHandler) models.DocumentRepository {
 	return documentRepository{databaseManager: databaseManager}
 }
 
-func (d documentRepository) DeleteDocumentById(documentUuid, ownerUuid uuid.UUID) error {
-	err := d.databaseManager.WithConnection(deleteDocumentSqlDatabase(documentUuid, ownerUuid))
+// NewDocumentRepositoryWithBlobStore creates a repository that keeps document content in the given BlobStore,
+// leaving only the storage key, size and checksum in document_table.
+// Documents that were stored before the blob store was introduced can still be read.
+func NewDocumentRepositoryWithBlobStore(databaseManager DatabaseHandler, blobStore models.BlobStore) models.DocumentRepository {
+	return documentRepository{databaseManager: databaseManager, blobStore: blobStore}
+}
+
+func (d documentRepository) DeleteDocumentById(ctx context.Context, documentUuid, ownerUuid uuid.UUID) error {
+	var storageKeys []string
+	err := d.databaseManager.WithContext(ctx, "documentRepository.DeleteDocumentById", deleteDocumentSqlDatabase(documentUuid, ownerUuid, func(keys []string) {
+		storageKeys = keys
+	}))
 	if err != nil {
 		return err
 	}
 
+	if d.blobStore == nil {
+		return nil
+	}
+
+	// The rows are gone at this point, so the document is deleted whatever happens to its blobs. Every blob is removed
+	// even when the request is cancelled meanwhile or another blob cannot be, and the ones left behind are logged.
+	ctx = context.WithoutCancel(ctx)
+	var problems []error
+	for _, key := range storageKeys {
+		if err := d.blobStore.Delete(ctx, key); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	if err := errors.Join(problems...); err != nil {
+		fmt.Printf("Failed to delete %d blob(s) of deleted document %s:\n%s\n", len(problems), documentUuid, err)
+	}
+
 	return nil
 }
 
-func (d documentRepository) GetDocumentByOwnerUUID(uid uuid.UUID, limit int8, offset int8, excludes map[string]bool) ([]models.Document, error) {
+func (d documentRepository) GetDocumentByOwnerUUID(ctx context.Context, uid uuid.UUID, limit int8, offset int8, includeShared bool, excludes map[string]bool) ([]models.Document, error) {
 	if limit <= 0 || offset < 0 {
 		return make([]models.Document, 0), errors.New("limit or offset were invalid")
 	}
 
 	ss := make([]models.Document, 0)
-	err := d.databaseManager.WithConnection(getDocumentByOwnerUUIDFunction(uid, limit, offset, excludes, func(data []models.Document) {
+	err := d.databaseManager.WithContext(ctx, "documentRepository.GetDocumentByOwnerUUID", getDocumentByOwnerUUIDFunction(uid, limit, offset, includeShared, excludes, func(data []models.Document) {
 		ss = data
 	}))
 	if err != nil {
 		return ss, err
 	}
 
+	for i := range ss {
+		if err := d.loadBase64FromBlobStore(ctx, &ss[i]); err != nil {
+			return ss, err
+		}
+	}
+
 	return ss, nil
 }
 
-func (d documentRepository) GetDocumentByDocumentUUID(documentUid, ownerUid uuid.UUID, excludes map[string]bool) (models.Document, error) {
+func (d documentRepository) GetDocumentByDocumentUUID(ctx context.Context, documentUid, ownerUid uuid.UUID, excludes map[string]bool) (models.Document, error) {
 	document := &models.Document{}
-	err := d.databaseManager.WithConnection(getDocumentByDocumentUUIDFunction(documentUid, ownerUid, excludes, func(data models.Document) {
+	err := d.databaseManager.WithContext(ctx, "documentRepository.GetDocumentByDocumentUUID", getDocumentByDocumentUUIDFunction(documentUid, ownerUid, excludes, func(data models.Document) {
 		*document = data
 	}))
 
@@ -53,60 +95,288 @@ func (d documentRepository) GetDocumentByDocumentUUID(documentUid, ownerUid uuid
 		return models.Document{}, err
 	}
 
+	if err := d.loadBase64FromBlobStore(ctx, document); err != nil {
+		return models.Document{}, err
+	}
+
 	return *document, nil
 }
 
-func (d documentRepository) UploadDocument(document models.Document) error {
+func (d documentRepository) UploadDocument(ctx context.Context, document models.Document) error {
+	if d.blobStore != nil && document.PdfBase64 != nil {
+		content := base64.NewDecoder(base64.StdEncoding, strings.NewReader(*document.PdfBase64))
+		document.PdfBase64 = nil
+		return d.UploadDocumentFromReader(ctx, document, content)
+	}
+
 	uploadDocumentSQL := createDocumentFunction(&document) //create callback
-	err := d.databaseManager.WithConnection(uploadDocumentSQL)
+	err := d.databaseManager.WithContext(ctx, "documentRepository.UploadDocument", uploadDocumentSQL)
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	if document.PdfBase64 != nil {
+		size = decodedLen(*document.PdfBase64)
+	}
+
+	countUpload(size)
+	return nil
+}
+
+// UploadDocumentFromReader stores a document whose content is supplied as raw PDF bytes.
+// When a blob store is configured the content is streamed straight into it, otherwise it is base64 encoded
+// while it is read, so callers never need to hold an encoded copy themselves.
+func (d documentRepository) UploadDocumentFromReader(ctx context.Context, document models.Document, content io.Reader) error {
+	return d.storeDocument(ctx, document, content, func(document *models.Document) error {
+		return d.databaseManager.WithContext(ctx, "documentRepository.UploadDocumentFromReader", createDocumentFunction(document))
+	})
+}
+
+// UploadDocumentWithDetails stores a document together with its meta and initial selections in one transaction, so
+// either all of them are created or none are. The meta and selections are created by the document's owner, and are
+// given the UUID of the document.
+func (d documentRepository) UploadDocumentWithDetails(ctx context.Context, document models.Document, content io.Reader, meta *models.Meta, selections []models.Selection) error {
+	if (meta != nil || len(selections) > 0) && document.OwnerUUID == nil {
+		return errors.New("meta and selections can only be created for a document with an owner")
+	}
+
+	err := d.storeDocument(ctx, document, content, func(document *models.Document) error {
+		return d.databaseManager.WithTransaction(ctx, "documentRepository.UploadDocumentWithDetails", func(ctx context.Context, db Executor) error {
+			if err := createDocumentFunction(document)(ctx, db); err != nil {
+				return err
+			}
+
+			if meta != nil {
+				documentMeta := *meta
+				documentMeta.DocumentUUID = document.Uuid
+				if err := addMetaDataFunction(documentMeta, *document.OwnerUUID)(ctx, db); err != nil {
+					return err
+				}
+			}
+
+			for _, selection := range selections {
+				selection.DocumentUUID = &document.Uuid
+				if err := AddNewSelectionFunction(selection, *document.OwnerUUID)(ctx, db); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	})
 	if err != nil {
 		return err
 	}
 
+	metrics.SelectionsCreated.Add(float64(len(selections)))
 	return nil
 }
 
-func getDocumentByDocumentUUIDFunction(uid, ownerUid uuid.UUID, excludes map[string]bool, callback func(data models.Document)) func(db *sql.DB) error {
-	return func(db *sql.DB) error {
-		sqlStatement := `SELECT {{if .documentTitle }}{{else}}"Document_Title", {{end}}{{if .pdfBase64 }}{{else}}"Document_Base64", {{end}}{{if .timeCreated }}{{else}}"Time_Created", {{end}}{{if .ownerUUID }}{{else}}"Owner_UUID", {{end}}{{if .ownerType }}{{else}}"Owner_Type",{{end}} "Document_UUID" FROM document_table WHERE "Document_UUID" = $1 and "Owner_UUID" = $2`
-		templ, err := template.New("documentQuery").Parse(sqlStatement)
-		var buffer bytes.Buffer
-		err = templ.Execute(&buffer, excludes)
+// storeDocument keeps content in the blob store, or base64 encoded in the document when there is none, and then writes
+// the document's rows with store. The blob is removed again when the rows could not be written.
+func (d documentRepository) storeDocument(ctx context.Context, document models.Document, content io.Reader, store func(document *models.Document) error) error {
+	if d.blobStore == nil {
+		encoded := &strings.Builder{}
+		encoder := base64.NewEncoder(base64.StdEncoding, encoded)
+		size, err := io.Copy(encoder, content)
 		if err != nil {
-			fmt.Println(err)
+			return err
 		}
 
-		generatedSQL := buffer.String()
-		rows := db.QueryRow(generatedSQL, uid.String(), ownerUid.String())
-		if rows.Err() != nil {
-			return rows.Err()
+		if err := encoder.Close(); err != nil {
+			return err
 		}
 
-		document := models.Document{}
-
-		scanDestinations := make([]any, 0)
-		if !excludes["documentTitle"] {
-			scanDestinations = append(scanDestinations, &document.DocumentTitle)
+		pdfBase64 := encoded.String()
+		document.PdfBase64 = &pdfBase64
+		if err := store(&document); err != nil {
+			return err
 		}
 
-		if !excludes["pdfBase64"] {
-			scanDestinations = append(scanDestinations, &document.PdfBase64)
+		countUpload(size)
+		return nil
+	}
+
+	key := documentStorageKey(document.Uuid)
+	blob, err := putBlob(ctx, d.blobStore, key, content)
+	if err != nil {
+		return err
+	}
+
+	document.PdfBase64 = nil
+	document.StorageKey = &key
+	document.SizeBytes = &blob.size
+	document.Checksum = &blob.checksum
+
+	if err := store(&document); err != nil {
+		_ = d.blobStore.Delete(context.WithoutCancel(ctx), key)
+		return err
+	}
+
+	countUpload(blob.size)
+	return nil
+}
+
+// countUpload records a stored document of size bytes.
+func countUpload(size int64) {
+	metrics.DocumentsUploaded.Inc()
+	metrics.BytesStored.Add(float64(size))
+}
+
+// decodedLen returns the number of bytes encoded by the padded base64 string encoded.
+func decodedLen(encoded string) int64 {
+	return int64(len(encoded)/4*3 - (len(encoded) - len(strings.TrimRight(encoded, "="))))
+}
+
+// GetDocumentContent returns the document's details along with a seekable reader over its raw PDF bytes.
+// The caller is responsible for closing the returned reader.
+func (d documentRepository) GetDocumentContent(ctx context.Context, documentUid, ownerUid uuid.UUID) (models.Document, io.ReadSeekCloser, error) {
+	document := &models.Document{}
+	err := d.databaseManager.WithContext(ctx, "documentRepository.GetDocumentContent", getDocumentByDocumentUUIDFunction(documentUid, ownerUid, map[string]bool{}, func(data models.Document) {
+		*document = data
+	}))
+	if err != nil {
+		return models.Document{}, nil, err
+	}
+
+	if document.StorageKey != nil {
+		if d.blobStore == nil {
+			return models.Document{}, nil, errors.New("document content is held in a blob store, but none is configured")
 		}
 
-		if !excludes["timeCreated"] {
-			scanDestinations = append(scanDestinations, &document.TimeCreated)
+		content, err := d.blobStore.Open(ctx, *document.StorageKey)
+		if err != nil {
+			return models.Document{}, nil, err
 		}
 
-		if !excludes["ownerUUID"] {
-			scanDestinations = append(scanDestinations, &document.OwnerUUID)
+		return *document, content, nil
+	}
+
+	if document.PdfBase64 == nil {
+		return models.Document{}, nil, errors.New("document has no content")
+	}
+
+	content, err := base64.StdEncoding.DecodeString(*document.PdfBase64)
+	if err != nil {
+		return models.Document{}, nil, fmt.Errorf("failed to decode document content: %w", err)
+	}
+
+	document.PdfBase64 = nil
+	return *document, nopSeekCloser{bytes.NewReader(content)}, nil
+}
+
+// loadBase64FromBlobStore fills in PdfBase64 for documents whose content lives in the blob store,
+// so the JSON API keeps returning the same shape regardless of where the content is kept.
+func (d documentRepository) loadBase64FromBlobStore(ctx context.Context, document *models.Document) error {
+	if document.StorageKey == nil || document.PdfBase64 != nil || d.blobStore == nil {
+		return nil
+	}
+
+	content, err := d.blobStore.Open(ctx, *document.StorageKey)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	encoded := &strings.Builder{}
+	encoder := base64.NewEncoder(base64.StdEncoding, encoded)
+	if _, err := io.Copy(encoder, content); err != nil {
+		return err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	pdfBase64 := encoded.String()
+	document.PdfBase64 = &pdfBase64
+	return nil
+}
+
+// nopSeekCloser wraps an in-memory reader so it satisfies io.ReadSeekCloser.
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
+type storedBlob struct {
+	size     int64
+	checksum string
+}
+
+// putBlob streams content into the blob store under key, recording its size and SHA-256 checksum on the way through.
+func putBlob(ctx context.Context, store models.BlobStore, key string, content io.Reader) (storedBlob, error) {
+	hash := sha256.New()
+	counter := &countingWriter{}
+	err := store.Put(ctx, key, io.TeeReader(content, io.MultiWriter(hash, counter)), -1)
+	if err != nil {
+		return storedBlob{}, err
+	}
+
+	return storedBlob{size: counter.n, checksum: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+func documentStorageKey(documentUuid uuid.UUID) string {
+	return "documents/" + documentUuid.String() + ".pdf"
+}
+
+const documentSelectTemplate = `SELECT {{if .documentTitle }}{{else}}"Document_Title", {{end}}{{if .pdfBase64 }}{{else}}"Document_Base64", "Storage_Key", {{end}}{{if .timeCreated }}{{else}}"Time_Created", {{end}}{{if .ownerUUID }}{{else}}"Owner_UUID", {{end}}{{if .ownerType }}{{else}}"Owner_Type",{{end}} "Size_Bytes", "Checksum_SHA256", "Document_UUID" FROM document_table d`
+
+// documentScanDestinations returns the fields of document matching the columns selected by documentSelectTemplate.
+func documentScanDestinations(document *models.Document, excludes map[string]bool) []any {
+	scanDestinations := make([]any, 0)
+	if !excludes["documentTitle"] {
+		scanDestinations = append(scanDestinations, &document.DocumentTitle)
+	}
+
+	if !excludes["pdfBase64"] {
+		scanDestinations = append(scanDestinations, &document.PdfBase64, &document.StorageKey)
+	}
+
+	if !excludes["timeCreated"] {
+		scanDestinations = append(scanDestinations, &document.TimeCreated)
+	}
+
+	if !excludes["ownerUUID"] {
+		scanDestinations = append(scanDestinations, &document.OwnerUUID)
+	}
+
+	if !excludes["ownerType"] {
+		scanDestinations = append(scanDestinations, &document.OwnerType)
+	}
+
+	return append(scanDestinations, &document.SizeBytes, &document.Checksum, &document.Uuid)
+}
+
+func getDocumentByDocumentUUIDFunction(uid, ownerUid uuid.UUID, excludes map[string]bool, callback func(data models.Document)) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := documentSelectTemplate + ` WHERE "Document_UUID" = $1 and ` + documentAccessible("d", 2, readRoles)
+		templ, err := template.New("documentQuery").Parse(sqlStatement)
+		var buffer bytes.Buffer
+		err = templ.Execute(&buffer, excludes)
+		if err != nil {
+			fmt.Println(err)
 		}
 
-		if !excludes["ownerType"] {
-			scanDestinations = append(scanDestinations, &document.OwnerType)
+		generatedSQL := buffer.String()
+		rows := db.QueryRowContext(ctx, generatedSQL, uid.String(), ownerUid.String())
+		if rows.Err() != nil {
+			return rows.Err()
 		}
-		scanDestinations = append(scanDestinations, &document.Uuid)
 
-		err = rows.Scan(scanDestinations...)
+		document := models.Document{}
+		err = rows.Scan(documentScanDestinations(&document, excludes)...)
 		if err != nil {
 			return err
 		}
@@ -116,9 +386,16 @@ func getDocumentByDocumentUUIDFunction(uid, ownerUid uuid.UUID, excludes map[str
 	}
 }
 
-func getDocumentByOwnerUUIDFunction(uid uuid.UUID, limit int8, offset int8, excludes map[string]bool, callback func(data []models.Document)) func(db *sql.DB) error {
-	return func(db *sql.DB) error {
-		sqlStatement := `SELECT {{if .documentTitle }}{{else}}"Document_Title", {{end}}{{if .pdfBase64 }}{{else}}"Document_Base64", {{end}}{{if .timeCreated }}{{else}}"Time_Created", {{end}}{{if .ownerUUID }}{{else}}"Owner_UUID", {{end}}{{if .ownerType }}{{else}}"Owner_Type",{{end}} "Document_UUID" FROM document_table WHERE "Owner_UUID" = $1 order by "Time_Created" DESC limit $2 offset $3`
+// getDocumentByOwnerUUIDFunction lists the documents of an owner, newest first. With includeShared the documents shared
+// with the owner in any role are listed as well.
+func getDocumentByOwnerUUIDFunction(uid uuid.UUID, limit int8, offset int8, includeShared bool, excludes map[string]bool, callback func(data []models.Document)) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		condition := `"Owner_UUID" = $1`
+		if includeShared {
+			condition = documentAccessible("d", 1, readRoles)
+		}
+
+		sqlStatement := documentSelectTemplate + ` WHERE ` + condition + ` order by "Time_Created" DESC limit $2 offset $3`
 		templ, err := template.New("documentQuery").Parse(sqlStatement)
 		var buffer bytes.Buffer
 		err = templ.Execute(&buffer, excludes)
@@ -127,38 +404,16 @@ func getDocumentByOwnerUUIDFunction(uid uuid.UUID, limit int8, offset int8, excl
 		}
 
 		generatedSQL := buffer.String()
-		rows, err := db.Query(generatedSQL, uid, limit, offset)
+		rows, err := db.QueryContext(ctx, generatedSQL, uid, limit, offset)
 		if err != nil {
-			return rows.Err()
+			return err
 		}
+		defer rows.Close()
 
 		dd := make([]models.Document, 0)
 		for rows.Next() {
 			document := models.Document{}
-
-			scanDestinations := make([]any, 0)
-			if !excludes["documentTitle"] {
-				scanDestinations = append(scanDestinations, &document.DocumentTitle)
-			}
-
-			if !excludes["pdfBase64"] {
-				scanDestinations = append(scanDestinations, &document.PdfBase64)
-			}
-
-			if !excludes["timeCreated"] {
-				scanDestinations = append(scanDestinations, &document.TimeCreated)
-			}
-
-			if !excludes["ownerUUID"] {
-				scanDestinations = append(scanDestinations, &document.OwnerUUID)
-			}
-
-			if !excludes["ownerType"] {
-				scanDestinations = append(scanDestinations, &document.OwnerType)
-			}
-			scanDestinations = append(scanDestinations, &document.Uuid)
-
-			err = rows.Scan(scanDestinations...)
+			err = rows.Scan(documentScanDestinations(&document, excludes)...)
 			if err != nil {
 				return err
 			}
@@ -167,14 +422,14 @@ func getDocumentByOwnerUUIDFunction(uid uuid.UUID, limit int8, offset int8, excl
 		}
 
 		callback(dd)
-		return nil
+		return rows.Err()
 	}
 }
 
-func createDocumentFunction(document *models.Document) func(db *sql.DB) error {
-	return func(db *sql.DB) error {
-		sqlStatement := `insert into document_table("Document_UUID", "Document_Title", "Document_Base64", "Owner_UUID", "Owner_Type") values ($1, $2, $3, $4, $5) returning "Document_UUID"`
-		_, err := db.Exec(sqlStatement, document.Uuid, document.DocumentTitle, document.PdfBase64, document.OwnerUUID, document.OwnerType)
+func createDocumentFunction(document *models.Document) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `insert into document_table("Document_UUID", "Document_Title", "Document_Base64", "Owner_UUID", "Owner_Type", "Storage_Key", "Size_Bytes", "Checksum_SHA256") values ($1, $2, $3, $4, $5, $6, $7, $8) returning "Document_UUID"`
+		_, err := db.ExecContext(ctx, sqlStatement, document.Uuid, document.DocumentTitle, document.PdfBase64, document.OwnerUUID, document.OwnerType, document.StorageKey, document.SizeBytes, document.Checksum)
 
 		if err != nil {
 			return err
@@ -184,15 +439,35 @@ func createDocumentFunction(document *models.Document) func(db *sql.DB) error {
 	}
 }
 
-func deleteDocumentSqlDatabase(documentUuid, ownerUuid uuid.UUID) func(db *sql.DB) error {
-	return func(db *sql.DB) error {
-		sqlStatement := `DELETE FROM document_table where "Document_UUID" = $1 and "Owner_UUID" = $2`
-		_, err := db.Exec(sqlStatement, documentUuid.String(), ownerUuid.String())
-
+// deleteDocumentSqlDatabase deletes a document, passing the storage keys of its content and of its rendered page images
+// to callback so the blobs can be removed once the rows are gone.
+func deleteDocumentSqlDatabase(documentUuid, ownerUuid uuid.UUID, callback func(storageKeys []string)) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `WITH deleted AS (DELETE FROM document_table d where "Document_UUID" = $1 and ` + documentAccessible("d", 2, manageRoles) + ` returning "Document_UUID", "Storage_Key")
+SELECT "Storage_Key" FROM deleted WHERE "Storage_Key" IS NOT NULL
+UNION ALL
+SELECT i."Storage_Key" FROM document_page_image_table i JOIN deleted d ON d."Document_UUID" = i."Document_UUID"`
+		rows, err := db.QueryContext(ctx, sqlStatement, documentUuid.String(), ownerUuid.String())
 		if err != nil {
 			return err
 		}
+		defer rows.Close()
+
+		storageKeys := make([]string, 0)
+		for rows.Next() {
+			var storageKey string
+			if err := rows.Scan(&storageKey); err != nil {
+				return err
+			}
+
+			storageKeys = append(storageKeys, storageKey)
+		}
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
 
+		callback(storageKeys)
 		return nil
 	}
 }
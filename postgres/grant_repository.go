@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/google/uuid"
+	"pdf_service_api/models"
+	"strings"
+)
+
+// Roles that give each level of access to a document, in addition to owning it.
+var (
+	readRoles   = []string{models.RoleViewer, models.RoleEditor, models.RoleOwner}
+	writeRoles  = []string{models.RoleEditor, models.RoleOwner}
+	manageRoles = []string{models.RoleOwner}
+)
+
+// documentAccessible returns an SQL condition that holds when the caller in query parameter number param owns the
+// document_table row aliased as alias, or has been granted one of roles on it.
+func documentAccessible(alias string, param int, roles []string) string {
+	quoted := make([]string, 0, len(roles))
+	for _, role := range roles {
+		quoted = append(quoted, "'"+role+"'")
+	}
+
+	return fmt.Sprintf(`(%[1]s."Owner_UUID" = $%[2]d OR EXISTS (SELECT 1 FROM document_grant_table g WHERE g."Document_UUID" = %[1]s."Document_UUID" AND g."Grantee_UUID" = $%[2]d AND g."Role" IN (%[3]s)))`,
+		alias, param, strings.Join(quoted, ", "))
+}
+
+type grantRepository struct {
+	databaseManager DatabaseHandler
+}
+
+func NewGrantRepository(db DatabaseHandler) models.GrantRepository {
+	return grantRepository{databaseManager: db}
+}
+
+func (g grantRepository) GrantAccess(ctx context.Context, grant models.Grant, caller uuid.UUID) error {
+	err := g.databaseManager.WithContext(ctx, "grantRepository.GrantAccess", grantAccessFunction(grant, caller))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (g grantRepository) GetGrants(ctx context.Context, documentUid, caller uuid.UUID) ([]models.Grant, error) {
+	grants := make([]models.Grant, 0)
+	err := g.databaseManager.WithContext(ctx, "grantRepository.GetGrants", getGrantsFunction(documentUid, caller, func(data []models.Grant) {
+		grants = data
+	}))
+	if err != nil {
+		return grants, err
+	}
+
+	return grants, nil
+}
+
+func (g grantRepository) RevokeAccess(ctx context.Context, documentUid, granteeUid, caller uuid.UUID) error {
+	err := g.databaseManager.WithContext(ctx, "grantRepository.RevokeAccess", revokeAccessFunction(documentUid, granteeUid, caller))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func grantAccessFunction(grant models.Grant, caller uuid.UUID) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `INSERT INTO document_grant_table ("Document_UUID", "Grantee_UUID", "Grantee_Type", "Role")
+SELECT d."Document_UUID", $2::uuid, $3::smallint, $4::text FROM document_table d WHERE d."Document_UUID" = $1 AND ` + documentAccessible("d", 5, manageRoles) + `
+ON CONFLICT ("Document_UUID", "Grantee_UUID") DO UPDATE SET "Grantee_Type" = excluded."Grantee_Type", "Role" = excluded."Role"`
+
+		result, err := db.ExecContext(ctx, sqlStatement, grant.DocumentUUID, grant.GranteeUUID, grant.GranteeType, grant.Role, caller)
+		if err != nil {
+			return err
+		}
+
+		return requireAffectedRows(result)
+	}
+}
+
+// getGrantsFunction lists the grants of a document, returning sql.ErrNoRows when caller may not manage it.
+// A document that has not been shared gives an empty list.
+func getGrantsFunction(documentUid, caller uuid.UUID, callback func(data []models.Grant)) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		var manageable bool
+		manageableStatement := `SELECT EXISTS (SELECT 1 FROM document_table d WHERE d."Document_UUID" = $1 AND ` + documentAccessible("d", 2, manageRoles) + `)`
+		if err := db.QueryRowContext(ctx, manageableStatement, documentUid, caller).Scan(&manageable); err != nil {
+			return err
+		}
+
+		if !manageable {
+			return sql.ErrNoRows
+		}
+
+		sqlStatement := `SELECT "Document_UUID", "Grantee_UUID", "Grantee_Type", "Role", "Time_Created" FROM document_grant_table WHERE "Document_UUID" = $1 ORDER BY "Time_Created", "Grantee_UUID"`
+		rows, err := db.QueryContext(ctx, sqlStatement, documentUid)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		grants := make([]models.Grant, 0)
+		for rows.Next() {
+			grant := models.Grant{}
+			if err := rows.Scan(&grant.DocumentUUID, &grant.GranteeUUID, &grant.GranteeType, &grant.Role, &grant.TimeCreated); err != nil {
+				return err
+			}
+
+			grants = append(grants, grant)
+		}
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		callback(grants)
+		return nil
+	}
+}
+
+func revokeAccessFunction(documentUid, granteeUid, caller uuid.UUID) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `DELETE FROM document_grant_table r USING document_table d WHERE d."Document_UUID" = r."Document_UUID" AND r."Document_UUID" = $1 AND r."Grantee_UUID" = $2 AND ` + documentAccessible("d", 3, manageRoles)
+		result, err := db.ExecContext(ctx, sqlStatement, documentUid, granteeUid, caller)
+		if err != nil {
+			return err
+		}
+
+		return requireAffectedRows(result)
+	}
+}
@@ -1,7 +1,7 @@
 package postgres
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
 	"github.com/google/uuid"
 	"pdf_service_api/models"
@@ -15,93 +15,138 @@ func NewMetaRepository(db DatabaseHandler) models.MetaRepository {
 	return metaRepository{DatabaseHandler: db}
 }
 
-func (m metaRepository) AddMeta(data models.Meta) error {
-	if err := m.DatabaseHandler.WithConnection(addMetaDataFunction(data)); err != nil {
+func (m metaRepository) AddMeta(ctx context.Context, data models.Meta, ownerUid uuid.UUID) error {
+	if err := m.DatabaseHandler.WithContext(ctx, "metaRepository.AddMeta", addMetaDataFunction(data, ownerUid)); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (m metaRepository) DeleteMeta(data models.Meta) error {
-	if err := m.DatabaseHandler.WithConnection(removeMetaDataFunction(data)); err != nil {
+func (m metaRepository) DeleteMeta(ctx context.Context, data models.Meta, ownerUid uuid.UUID) error {
+	if err := m.DatabaseHandler.WithContext(ctx, "metaRepository.DeleteMeta", removeMetaDataFunction(data, ownerUid)); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (m metaRepository) UpdateMeta(uid uuid.UUID, data models.Meta) error {
-	if err := m.DatabaseHandler.WithConnection(updateMetaDataFunction(uid, data)); err != nil {
+func (m metaRepository) UpdateMeta(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID, data models.Meta) error {
+	if err := m.DatabaseHandler.WithContext(ctx, "metaRepository.UpdateMeta", updateMetaDataFunction(uid, ownerUid, data)); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (m metaRepository) GetMeta(uid uuid.UUID) (models.Meta, error) {
+func (m metaRepository) GetMeta(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID) (models.Meta, error) {
 	returnedData := &models.Meta{}
 	callbackFunction := func(data models.Meta) error {
 		*returnedData = data
 		return nil
 	}
 
-	if err := m.DatabaseHandler.WithConnection(getMetaDataFunction(uid, callbackFunction)); err != nil {
+	if err := m.DatabaseHandler.WithContext(ctx, "metaRepository.GetMeta", getMetaDataFunction(uid, ownerUid, callbackFunction)); err != nil {
 		return models.Meta{}, err
 	}
 
 	return *returnedData, nil
 }
 
-func addMetaDataFunction(data models.Meta) func(db *sql.DB) error {
-	return func(db *sql.DB) error {
-		SqlStatement := `INSERT INTO documentmeta_table ("Document_UUID", "Number_Of_Pages", "Height", "Width", "Images") values ($1, $2, $3, $4, $5)`
-		if _, err := db.Exec(SqlStatement, data.DocumentUUID, data.NumberOfPages, data.Height, data.Width, data.Images); err != nil {
+func addMetaDataFunction(data models.Meta, ownerUid uuid.UUID) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		SqlStatement := `INSERT INTO documentmeta_table ("Document_UUID", "Number_Of_Pages", "Height", "Width", "Images", "Pages") SELECT d."Document_UUID", $2::integer, $3::numeric, $4::numeric, $5::json, $6::json FROM document_table d WHERE d."Document_UUID" = $1 AND ` + documentAccessible("d", 7, writeRoles)
+		images, err := nullableJson(data.Images)
+		if err != nil {
 			return err
 		}
 
-		return nil
+		pages, err := nullableJson(data.Pages)
+		if err != nil {
+			return err
+		}
+
+		result, err := db.ExecContext(ctx, SqlStatement, data.DocumentUUID, data.NumberOfPages, data.Height, data.Width, images, pages, ownerUid)
+		if err != nil {
+			return err
+		}
+
+		return requireAffectedRows(result)
 	}
 }
 
-func removeMetaDataFunction(data models.Meta) func(db *sql.DB) error {
-	return func(db *sql.DB) error {
-		SqlStatement := `DELETE FROM documentmeta_table WHERE "Document_UUID" = $1`
-		if _, err := db.Exec(SqlStatement, data.DocumentUUID); err != nil {
+func removeMetaDataFunction(data models.Meta, ownerUid uuid.UUID) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		SqlStatement := `DELETE FROM documentmeta_table m USING document_table d WHERE d."Document_UUID" = m."Document_UUID" AND m."Document_UUID" = $1 AND ` + documentAccessible("d", 2, writeRoles)
+		result, err := db.ExecContext(ctx, SqlStatement, data.DocumentUUID, ownerUid)
+		if err != nil {
 			return err
 		}
 
-		return nil
+		return requireAffectedRows(result)
 	}
 }
 
-func updateMetaDataFunction(uid uuid.UUID, data models.Meta) func(db *sql.DB) error {
-	return func(db *sql.DB) error {
-		SqlStatement := `UPDATE documentmeta_table SET "Number_Of_Pages" = COALESCE($1, "Number_Of_Pages"), "Height" = COALESCE($2, "Height"), "Width" = COALESCE($3, "Width"), "Images" = COALESCE($4, "Images") where "Document_UUID" = $5`
-		bytes, err := json.Marshal(data.Images)
+func updateMetaDataFunction(uid uuid.UUID, ownerUid uuid.UUID, data models.Meta) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		SqlStatement := `UPDATE documentmeta_table m SET "Number_Of_Pages" = COALESCE($1, m."Number_Of_Pages"), "Height" = COALESCE($2, m."Height"), "Width" = COALESCE($3, m."Width"), "Images" = COALESCE($4, m."Images"), "Pages" = COALESCE($5, m."Pages") FROM document_table d where d."Document_UUID" = m."Document_UUID" AND m."Document_UUID" = $6 AND ` + documentAccessible("d", 7, writeRoles)
+		images, err := nullableJson(data.Images)
 		if err != nil {
 			return err
 		}
 
-		if _, err := db.Exec(SqlStatement, data.NumberOfPages, data.Height, data.Width, string(bytes), uid); err != nil {
+		pages, err := nullableJson(data.Pages)
+		if err != nil {
 			return err
 		}
 
-		return nil
+		result, err := db.ExecContext(ctx, SqlStatement, data.NumberOfPages, data.Height, data.Width, images, pages, uid, ownerUid)
+		if err != nil {
+			return err
+		}
+
+		return requireAffectedRows(result)
 	}
 }
 
-func getMetaDataFunction(uid uuid.UUID, callback func(data models.Meta) error) func(db *sql.DB) error {
-	return func(db *sql.DB) error {
+func getMetaDataFunction(uid uuid.UUID, ownerUid uuid.UUID, callback func(data models.Meta) error) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
 		meta := &models.Meta{}
-		SqlStatement := `SELECT "Document_UUID", "Number_Of_Pages", "Height", "Width", "Images" FROM documentmeta_table where "Document_UUID" = $1`
+		SqlStatement := `SELECT m."Document_UUID", m."Number_Of_Pages", m."Height", m."Width", m."Images", m."Pages" FROM documentmeta_table m JOIN document_table d ON d."Document_UUID" = m."Document_UUID" where m."Document_UUID" = $1 AND ` + documentAccessible("d", 2, readRoles)
 
-		row := db.QueryRow(SqlStatement, uid)
-		err := row.Scan(&meta.DocumentUUID, &meta.NumberOfPages, &meta.Height, &meta.Width, &meta.Images)
+		var images, pages []byte
+		row := db.QueryRowContext(ctx, SqlStatement, uid, ownerUid)
+		err := row.Scan(&meta.DocumentUUID, &meta.NumberOfPages, &meta.Height, &meta.Width, &images, &pages)
 		if err != nil {
 			return err
 		}
 
+		if images != nil {
+			if err := json.Unmarshal(images, &meta.Images); err != nil {
+				return err
+			}
+		}
+
+		if pages != nil {
+			if err := json.Unmarshal(pages, &meta.Pages); err != nil {
+				return err
+			}
+		}
+
 		return callback(*meta)
 	}
 }
+
+// nullableJson encodes value as JSON, returning nil for a nil pointer so the column is written as SQL NULL.
+func nullableJson[T any](value *T) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(bytes), nil
+}
@@ -0,0 +1,351 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// migrationFiles holds the schema migrations, named <version>_<name>.up.sql and <version>_<name>.down.sql.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey identifies the advisory lock held while migrations run, so replicas starting at the same time
+// apply every migration once.
+const migrationLockKey int64 = 0x7064667376636d67
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a numbered change to the schema. Up applies it and Down reverts it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied, and when. Migrations recorded in the database that
+// are unknown to this build, because a newer build applied them, are reported with only their version and name.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	Unknown   bool
+}
+
+// Migrations returns the migrations embedded in the binary, ordered by version.
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file %s is not named <version>_<name>.up.sql or <version>_<name>.down.sql", entry.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(migrationFiles, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d %s needs both an up and a down script", migration.Version, migration.Name)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	return migrations, nil
+}
+
+// MigrateUp applies the migrations that have not been applied yet, in order, and returns them. Every migration runs in
+// its own transaction together with its entry in schema_migrations, so a failed migration leaves no partial changes.
+func MigrateUp(ctx context.Context, databaseManager DatabaseHandler) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]Migration, 0)
+	err = withMigrationLock(ctx, databaseManager, func(conn *sql.Conn) error {
+		appliedAt, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if _, ok := appliedAt[migration.Version]; ok {
+				continue
+			}
+
+			err := runMigration(ctx, conn, migration.Up, `INSERT INTO schema_migrations ("Version", "Name") VALUES ($1, $2)`, migration.Version, migration.Name)
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %d %s: %w", migration.Version, migration.Name, err)
+			}
+
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// MigrateDown reverts the last steps applied migrations, newest first, and returns them.
+func MigrateDown(ctx context.Context, databaseManager DatabaseHandler, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, errors.New("the number of migrations to revert must be positive")
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	reverted := make([]Migration, 0)
+	err = withMigrationLock(ctx, databaseManager, func(conn *sql.Conn) error {
+		appliedAt, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		versions := make([]int, 0, len(appliedAt))
+		for version := range appliedAt {
+			versions = append(versions, version)
+		}
+		slices.Sort(versions)
+		slices.Reverse(versions)
+
+		for _, version := range versions[:min(steps, len(versions))] {
+			index := slices.IndexFunc(migrations, func(m Migration) bool { return m.Version == version })
+			if index < 0 {
+				return fmt.Errorf("migration %d was applied by a newer build and cannot be reverted by this one", version)
+			}
+
+			migration := migrations[index]
+			err := runMigration(ctx, conn, migration.Down, `DELETE FROM schema_migrations WHERE "Version" = $1`, migration.Version)
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %d %s: %w", migration.Version, migration.Name, err)
+			}
+
+			reverted = append(reverted, migration)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// GetMigrationStatus lists every migration known to this build or recorded in the database, ordered by version.
+func GetMigrationStatus(ctx context.Context, databaseManager DatabaseHandler) ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var recorded map[int]appliedMigration
+	err = withMigrationLock(ctx, databaseManager, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		recorded = applied
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if applied, ok := recorded[migration.Version]; ok {
+			status.AppliedAt = &applied.appliedAt
+			delete(recorded, migration.Version)
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	for version, applied := range recorded {
+		statuses = append(statuses, MigrationStatus{Version: version, Name: applied.name, AppliedAt: &applied.appliedAt, Unknown: true})
+	}
+
+	slices.SortFunc(statuses, func(a, b MigrationStatus) int { return a.Version - b.Version })
+	return statuses, nil
+}
+
+// PendingMigrations returns the migrations of this build that have not been applied yet. Unlike the other migration
+// functions it does not wait for the migration lock, so it answers while another replica is migrating. It backs the
+// readiness probe, so it runs on the pool directly instead of through WithContext and is not recorded as a query.
+func PendingMigrations(ctx context.Context, databaseManager DatabaseHandler) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := databaseManager.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]Migration, 0)
+	for _, migration := range migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// appliedVersions returns the versions recorded in schema_migrations, or none when the table has not been created yet.
+func appliedVersions(ctx context.Context, db Executor) (map[int]bool, error) {
+	applied := make(map[int]bool)
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil || !exists {
+		return applied, err
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT "Version" FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
+
+// withMigrationLock runs callback on a single connection holding the migration advisory lock, creating the
+// schema_migrations table first when it does not exist. Advisory locks belong to a session, so every statement of the
+// callback has to run on the connection it is given.
+func withMigrationLock(ctx context.Context, databaseManager DatabaseHandler, callback func(conn *sql.Conn) error) error {
+	db, err := databaseManager.DB()
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+	}()
+
+	sqlStatement := `create table if not exists schema_migrations
+(
+    "Version"    bigint    not null
+        constraint schema_migrations_pk
+            primary key,
+    "Name"       text      not null,
+    "Applied_At" timestamp not null default now()
+)`
+	if _, err := conn.ExecContext(ctx, sqlStatement); err != nil {
+		return err
+	}
+
+	return callback(conn)
+}
+
+type appliedMigration struct {
+	name      string
+	appliedAt time.Time
+}
+
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT "Version", "Name", "Applied_At" FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var (
+			version   int
+			migration appliedMigration
+		)
+		if err := rows.Scan(&version, &migration.name, &migration.appliedAt); err != nil {
+			return nil, err
+		}
+
+		applied[version] = migration
+	}
+
+	return applied, rows.Err()
+}
+
+// runMigration runs script and the statement recording it in one transaction.
+func runMigration(ctx context.Context, conn *sql.Conn, script string, record string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+
+	return tx.Commit()
+}
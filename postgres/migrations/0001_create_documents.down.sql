@@ -0,0 +1,3 @@
+drop table if exists selection_table;
+drop table if exists documentmeta_table;
+drop table if exists document_table;
@@ -37,4 +37,4 @@ create table if not exists selection_table
     "Settings"         json,
     "Selection_bounds" json,
     "Page_Words"       json
-);
\ No newline at end of file
+);
@@ -0,0 +1,5 @@
+-- Documents whose content is held in the blob store have no base64 content, so this fails until they are removed.
+alter table document_table alter column "Document_Base64" set not null;
+alter table document_table drop column if exists "Checksum_SHA256";
+alter table document_table drop column if exists "Size_Bytes";
+alter table document_table drop column if exists "Storage_Key";
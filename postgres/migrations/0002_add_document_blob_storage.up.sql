@@ -0,0 +1,4 @@
+alter table document_table add column if not exists "Storage_Key" text;
+alter table document_table add column if not exists "Size_Bytes" bigint;
+alter table document_table add column if not exists "Checksum_SHA256" text;
+alter table document_table alter column "Document_Base64" drop not null;
@@ -0,0 +1 @@
+alter table documentmeta_table drop column if exists "Pages";
@@ -0,0 +1 @@
+alter table documentmeta_table add column if not exists "Pages" json;
@@ -0,0 +1 @@
+alter table selection_table drop column if exists "Extracted_Content";
@@ -0,0 +1 @@
+alter table selection_table add column if not exists "Extracted_Content" json;
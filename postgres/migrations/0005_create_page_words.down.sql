@@ -0,0 +1,3 @@
+alter table selection_table add column if not exists "Page_Words" json;
+
+drop table if exists document_page_words_table;
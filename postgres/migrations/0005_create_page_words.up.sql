@@ -0,0 +1,13 @@
+create table if not exists document_page_words_table
+(
+    "Document_UUID" uuid    not null
+        constraint document_page_words_table_document_table_fk
+            references document_table
+            on delete cascade,
+    "Page_Number"   integer not null,
+    "Page_Words"    json    not null,
+    constraint document_page_words_table_pk
+        primary key ("Document_UUID", "Page_Number")
+);
+
+alter table selection_table drop column if exists "Page_Words";
@@ -0,0 +1,13 @@
+create table if not exists document_page_image_table
+(
+    "Document_UUID" uuid    not null
+        constraint document_page_image_table_document_table_fk
+            references document_table
+            on delete cascade,
+    "Page_Number"   integer not null,
+    "Dpi"           integer not null,
+    "Format"        text    not null,
+    "Storage_Key"   text    not null,
+    constraint document_page_image_table_pk
+        primary key ("Document_UUID", "Page_Number", "Dpi", "Format")
+);
@@ -0,0 +1,18 @@
+create table if not exists document_grant_table
+(
+    "Document_UUID" uuid      not null
+        constraint document_grant_table_document_table_fk
+            references document_table
+            on delete cascade,
+    "Grantee_UUID"  uuid      not null,
+    "Grantee_Type"  smallint,
+    "Role"          text      not null
+        constraint document_grant_table_role_check
+            check ("Role" in ('viewer', 'editor', 'owner')),
+    "Time_Created"  timestamp default now(),
+    constraint document_grant_table_pk
+        primary key ("Document_UUID", "Grantee_UUID")
+);
+
+create index if not exists document_grant_table_grantee_index
+    on document_grant_table ("Grantee_UUID");
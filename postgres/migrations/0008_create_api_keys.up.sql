@@ -0,0 +1,19 @@
+create table if not exists api_key_table
+(
+    "Key_UUID"     uuid      not null
+        constraint api_key_table_pk
+            primary key,
+    "Owner_UUID"   uuid      not null,
+    "Owner_Type"   smallint,
+    "Name"         text      not null,
+    "Key_Prefix"   text      not null,
+    "Key_Hash"     text      not null
+        constraint api_key_table_key_hash_unique
+            unique,
+    "Permissions"  text[]    not null,
+    "Time_Created" timestamp default now(),
+    "Last_Used"    timestamp
+);
+
+create index if not exists api_key_table_owner_index
+    on api_key_table ("Owner_UUID");
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"pdf_service_api/models"
+)
+
+type pageImageRepository struct {
+	databaseManager DatabaseHandler
+	blobStore       models.BlobStore
+}
+
+// NewPageImageRepository creates a repository that keeps rendered page images in blobStore,
+// recording them in document_page_image_table so they are removed along with their document.
+func NewPageImageRepository(databaseManager DatabaseHandler, blobStore models.BlobStore) models.PageImageRepository {
+	return pageImageRepository{databaseManager: databaseManager, blobStore: blobStore}
+}
+
+func (p pageImageRepository) GetPageImage(ctx context.Context, documentUid, ownerUid uuid.UUID, page, dpi int, format string) (models.PageImage, io.ReadSeekCloser, error) {
+	image := models.PageImage{DocumentUUID: documentUid, PageNumber: page, Dpi: dpi, Format: format}
+	err := p.databaseManager.WithContext(ctx, "pageImageRepository.GetPageImage", getPageImageKeyFunction(documentUid, ownerUid, page, dpi, format, func(key string) {
+		image.StorageKey = key
+	}))
+	if err != nil {
+		return models.PageImage{}, nil, err
+	}
+
+	content, err := p.blobStore.Open(ctx, image.StorageKey)
+	if errors.Is(err, models.ErrBlobNotFound) {
+		return models.PageImage{}, nil, sql.ErrNoRows
+	}
+
+	if err != nil {
+		return models.PageImage{}, nil, err
+	}
+
+	return image, content, nil
+}
+
+func (p pageImageRepository) SavePageImage(ctx context.Context, image models.PageImage, content io.Reader) error {
+	image.StorageKey = pageImageStorageKey(image)
+	if err := p.blobStore.Put(ctx, image.StorageKey, content, -1); err != nil {
+		return err
+	}
+
+	if err := p.databaseManager.WithContext(ctx, "pageImageRepository.SavePageImage", savePageImageFunction(image)); err != nil {
+		_ = p.blobStore.Delete(context.WithoutCancel(ctx), image.StorageKey)
+		return err
+	}
+
+	return nil
+}
+
+func pageImageStorageKey(image models.PageImage) string {
+	return fmt.Sprintf("renders/%s/%d-%d.%s", image.DocumentUUID, image.PageNumber, image.Dpi, image.Format)
+}
+
+func getPageImageKeyFunction(documentUid, ownerUid uuid.UUID, page, dpi int, format string, callback func(key string)) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `SELECT i."Storage_Key" FROM document_page_image_table i JOIN document_table d ON d."Document_UUID" = i."Document_UUID" WHERE i."Document_UUID" = $1 AND i."Page_Number" = $3 AND i."Dpi" = $4 AND i."Format" = $5 AND ` + documentAccessible("d", 2, readRoles)
+
+		var key string
+		if err := db.QueryRowContext(ctx, sqlStatement, documentUid, ownerUid, page, dpi, format).Scan(&key); err != nil {
+			return err
+		}
+
+		callback(key)
+		return nil
+	}
+}
+
+func savePageImageFunction(image models.PageImage) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `INSERT INTO document_page_image_table ("Document_UUID", "Page_Number", "Dpi", "Format", "Storage_Key") VALUES ($1, $2, $3, $4, $5) ON CONFLICT ("Document_UUID", "Page_Number", "Dpi", "Format") DO UPDATE SET "Storage_Key" = excluded."Storage_Key"`
+		if _, err := db.ExecContext(ctx, sqlStatement, image.DocumentUUID, image.PageNumber, image.Dpi, image.Format, image.StorageKey); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
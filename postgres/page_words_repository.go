@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"pdf_service_api/models"
+)
+
+type pageWordsRepository struct {
+	databaseManager DatabaseHandler
+}
+
+func NewPageWordsRepository(db DatabaseHandler) models.PageWordsRepository {
+	return pageWordsRepository{databaseManager: db}
+}
+
+func (p pageWordsRepository) GetPageWords(ctx context.Context, documentUid, ownerUid uuid.UUID, page int) (models.PageWords, error) {
+	returnedData := &models.PageWords{}
+	callbackFunction := func(data models.PageWords) {
+		*returnedData = data
+	}
+
+	err := p.databaseManager.WithContext(ctx, "pageWordsRepository.GetPageWords", getPageWordsFunction(documentUid, ownerUid, page, callbackFunction))
+	if err != nil {
+		return models.PageWords{}, err
+	}
+
+	return *returnedData, nil
+}
+
+func (p pageWordsRepository) SavePageWords(ctx context.Context, words models.PageWords) error {
+	err := p.databaseManager.WithContext(ctx, "pageWordsRepository.SavePageWords", savePageWordsFunction(words))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func getPageWordsFunction(documentUid, ownerUid uuid.UUID, page int, callback func(data models.PageWords)) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `SELECT w."Page_Words" FROM document_page_words_table w JOIN document_table d ON d."Document_UUID" = w."Document_UUID" WHERE w."Document_UUID" = $1 AND w."Page_Number" = $3 AND ` + documentAccessible("d", 2, readRoles)
+
+		var content []byte
+		if err := db.QueryRowContext(ctx, sqlStatement, documentUid, ownerUid, page).Scan(&content); err != nil {
+			return err
+		}
+
+		data := models.PageWords{DocumentUUID: documentUid, PageNumber: page}
+		if err := json.Unmarshal(content, &data.Words); err != nil {
+			return err
+		}
+
+		callback(data)
+		return nil
+	}
+}
+
+func savePageWordsFunction(words models.PageWords) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `INSERT INTO document_page_words_table ("Document_UUID", "Page_Number", "Page_Words") VALUES ($1, $2, $3) ON CONFLICT ("Document_UUID", "Page_Number") DO UPDATE SET "Page_Words" = excluded."Page_Words"`
+
+		content, err := json.Marshal(words.Words)
+		if err != nil {
+			return err
+		}
+
+		if _, err := db.ExecContext(ctx, sqlStatement, words.DocumentUUID, words.PageNumber, string(content)); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
@@ -1,10 +1,17 @@
 package postgres
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"pdf_service_api/metrics"
 	"pdf_service_api/models"
+	"strconv"
+	"text/template"
 )
 
 type selectionRepository struct {
@@ -15,22 +22,23 @@ func NewSelectionRepository(db DatabaseHandler) models.SelectionRepository {
 	return selectionRepository{databaseManager: db}
 }
 
-func (s selectionRepository) AddNewSelection(selection models.Selection) error {
-	err := s.databaseManager.WithConnection(AddNewSelectionFunction(selection))
+func (s selectionRepository) AddNewSelection(ctx context.Context, selection models.Selection, ownerUid uuid.UUID) error {
+	err := s.databaseManager.WithContext(ctx, "selectionRepository.AddNewSelection", AddNewSelectionFunction(selection, ownerUid))
 	if err != nil {
 		return err
 	}
 
+	metrics.SelectionsCreated.Inc()
 	return nil
 }
 
-func (s selectionRepository) GetSelectionsBySelectionUUID(uid uuid.UUID) ([]models.Selection, error) {
+func (s selectionRepository) GetSelectionsBySelectionUUID(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID, excludes map[string]bool) ([]models.Selection, error) {
 	var ss []models.Selection
-	getSelection := getSelectionBySelectionUUIDFunction(uid, func(data []models.Selection) {
+	getSelection := getSelectionBySelectionUUIDFunction(uid, ownerUid, excludes, func(data []models.Selection) {
 		ss = data
 	})
 
-	err := s.databaseManager.WithConnection(getSelection)
+	err := s.databaseManager.WithContext(ctx, "selectionRepository.GetSelectionsBySelectionUUID", getSelection)
 	if err != nil {
 		return ss, err
 	}
@@ -38,13 +46,13 @@ func (s selectionRepository) GetSelectionsBySelectionUUID(uid uuid.UUID) ([]mode
 	return ss, nil
 }
 
-func (s selectionRepository) GetSelectionsByDocumentUUID(uid uuid.UUID) ([]models.Selection, error) {
+func (s selectionRepository) GetSelectionsByDocumentUUID(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID, excludes map[string]bool) ([]models.Selection, error) {
 	ss := make([]models.Selection, 0)
-	getSelection := getSelectionByDocumentUUIDFunction(uid, func(data []models.Selection) {
+	getSelection := getSelectionByDocumentUUIDFunction(uid, ownerUid, excludes, func(data []models.Selection) {
 		ss = data
 	})
 
-	err := s.databaseManager.WithConnection(getSelection)
+	err := s.databaseManager.WithContext(ctx, "selectionRepository.GetSelectionsByDocumentUUID", getSelection)
 	if err != nil {
 		return ss, err
 	}
@@ -52,8 +60,8 @@ func (s selectionRepository) GetSelectionsByDocumentUUID(uid uuid.UUID) ([]model
 	return ss, nil
 }
 
-func (s selectionRepository) DeleteSelectionByDocumentUUID(uid uuid.UUID) error {
-	err := s.databaseManager.WithConnection(deleteSelectionByDocumentUUIDFunction(uid))
+func (s selectionRepository) SaveExtraction(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID, extraction models.SelectionExtraction) error {
+	err := s.databaseManager.WithContext(ctx, "selectionRepository.SaveExtraction", saveExtractionFunction(uid, ownerUid, extraction))
 	if err != nil {
 		return err
 	}
@@ -61,8 +69,8 @@ func (s selectionRepository) DeleteSelectionByDocumentUUID(uid uuid.UUID) error
 	return nil
 }
 
-func (s selectionRepository) DeleteSelectionBySelectionUUID(uid uuid.UUID) error {
-	err := s.databaseManager.WithConnection(deleteSelectionBySelectionUUIDFunction(uid))
+func (s selectionRepository) UpdateSelection(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID, update models.SelectionUpdate) error {
+	err := s.databaseManager.WithContext(ctx, "selectionRepository.UpdateSelection", updateSelectionFunction(uid, ownerUid, update))
 	if err != nil {
 		return err
 	}
@@ -70,9 +78,30 @@ func (s selectionRepository) DeleteSelectionBySelectionUUID(uid uuid.UUID) error
 	return nil
 }
 
-func AddNewSelectionFunction(selection models.Selection) func(db *sql.DB) error {
-	return func(db *sql.DB) error {
-		sqlStatement := `insert into selection_table ("Selection_UUID", "Document_UUID", "isCompleted", "Settings", "Selection_bounds") values ($1, $2, $3, $4, $5);`
+func (s selectionRepository) DeleteSelectionByDocumentUUID(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID) error {
+	err := s.databaseManager.WithContext(ctx, "selectionRepository.DeleteSelectionByDocumentUUID", deleteSelectionByDocumentUUIDFunction(uid, ownerUid))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s selectionRepository) DeleteSelectionBySelectionUUID(ctx context.Context, uid uuid.UUID, ownerUid uuid.UUID) error {
+	err := s.databaseManager.WithContext(ctx, "selectionRepository.DeleteSelectionBySelectionUUID", deleteSelectionBySelectionUUIDFunction(uid, ownerUid))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddNewSelectionFunction inserts the selection only when ownerUid may edit its document, returning sql.ErrNoRows
+// otherwise.
+func AddNewSelectionFunction(selection models.Selection, ownerUid uuid.UUID) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `insert into selection_table ("Selection_UUID", "Document_UUID", "isCompleted", "Settings", "Selection_bounds")
+select $1::uuid, d."Document_UUID", $3::boolean, $4::json, $5::json from document_table d where d."Document_UUID" = $2 and ` + documentAccessible("d", 6, writeRoles)
 
 		selUid := selection.Uuid
 		if selUid == uuid.Nil {
@@ -80,48 +109,144 @@ func AddNewSelectionFunction(selection models.Selection) func(db *sql.DB) error
 		}
 
 		docUid := selection.DocumentUUID
-		if *docUid == uuid.Nil {
-			return errors.New("selection uuid cannot be nil")
+		if docUid == nil || *docUid == uuid.Nil {
+			return errors.New("document uuid cannot be nil")
 		}
 
-		isComplete := selection.IsComplete
+		isComplete := selection.IsComplete != nil && *selection.IsComplete
 		settings := selection.Settings
 		if settings == nil || *settings == "" {
 			settings = func() *string { v := "{}"; return &v }()
 		}
 
-		selBounds := selection.SelectionBounds
-
-		_, err := db.Exec(sqlStatement, selUid, docUid, isComplete, settings, selBounds)
+		selBounds, err := nullableJson(selection.SelectionBounds)
+		if err != nil {
+			return err
+		}
 
+		result, err := db.ExecContext(ctx, sqlStatement, selUid, docUid, isComplete, settings, selBounds, ownerUid)
 		if err != nil {
 			return err
 		}
 
-		return nil
+		return requireAffectedRows(result)
 	}
 }
 
-func getSelectionByDocumentUUIDFunction(uid uuid.UUID, callback func(data []models.Selection)) func(db *sql.DB) error {
-	return func(db *sql.DB) error {
-		sqlStatement := `SELECT "Selection_UUID", "Document_UUID", "Selection_bounds" FROM selection_table where "Document_UUID" = $1`
+const selectionSelectTemplate = `SELECT s."Selection_UUID"{{if .documentUUID }}{{else}}, s."Document_UUID"{{end}}{{if .isComplete }}{{else}}, s."isCompleted"{{end}}{{if .settings }}{{else}}, s."Settings"{{end}}{{if .selectionBounds }}{{else}}, s."Selection_bounds"{{end}}{{if .extraction }}{{else}}, s."Extracted_Content"{{end}} FROM selection_table s JOIN document_table d ON d."Document_UUID" = s."Document_UUID"`
+
+// querySelections runs selectionSelectTemplate with the given condition, leaving out the excluded columns.
+// The condition can refer to the selection as s and to its document as d.
+func querySelections(ctx context.Context, db Executor, condition string, excludes map[string]bool, args ...any) ([]models.Selection, error) {
+	templ, err := template.New("selectionQuery").Parse(selectionSelectTemplate + ` WHERE ` + condition)
+	if err != nil {
+		return nil, err
+	}
 
-		rows, err := db.Query(sqlStatement, uid.String())
+	var buffer bytes.Buffer
+	if err := templ.Execute(&buffer, excludes); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, buffer.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ss := make([]models.Selection, 0)
+	for rows.Next() {
+		data, err := scanSelection(rows, excludes)
 		if err != nil {
+			return nil, err
+		}
+
+		ss = append(ss, data)
+	}
+
+	return ss, rows.Err()
+}
+
+// scanSelection reads a row selected by selectionSelectTemplate, decoding its JSON columns.
+func scanSelection(rows *sql.Rows, excludes map[string]bool) (models.Selection, error) {
+	data := models.Selection{}
+	var selBounds, extraction []byte
+
+	scanDestinations := []any{&data.Uuid}
+	if !excludes["documentUUID"] {
+		scanDestinations = append(scanDestinations, &data.DocumentUUID)
+	}
+
+	if !excludes["isComplete"] {
+		scanDestinations = append(scanDestinations, &data.IsComplete)
+	}
+
+	if !excludes["settings"] {
+		scanDestinations = append(scanDestinations, &data.Settings)
+	}
+
+	if !excludes["selectionBounds"] {
+		scanDestinations = append(scanDestinations, &selBounds)
+	}
+
+	if !excludes["extraction"] {
+		scanDestinations = append(scanDestinations, &extraction)
+	}
+
+	if err := rows.Scan(scanDestinations...); err != nil {
+		return models.Selection{}, err
+	}
+
+	if selBounds != nil {
+		if err := json.Unmarshal(selBounds, &data.SelectionBounds); err != nil {
+			return models.Selection{}, err
+		}
+	}
+
+	if extraction != nil {
+		if err := json.Unmarshal(extraction, &data.Extraction); err != nil {
+			return models.Selection{}, err
+		}
+	}
+
+	return data, nil
+}
+
+// getSelectionByDocumentUUIDFunction returns the selections of a document, or sql.ErrNoRows when ownerUid may not read
+// the document. A readable document without selections gives an empty list.
+func getSelectionByDocumentUUIDFunction(uid uuid.UUID, ownerUid uuid.UUID, excludes map[string]bool, callback func(data []models.Selection)) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		var readable bool
+		readableStatement := `SELECT EXISTS (SELECT 1 FROM document_table d WHERE d."Document_UUID" = $1 AND ` + documentAccessible("d", 2, readRoles) + `)`
+		if err := db.QueryRowContext(ctx, readableStatement, uid, ownerUid).Scan(&readable); err != nil {
 			return err
+		}
 
+		if !readable {
+			return sql.ErrNoRows
 		}
 
-		//var ss []models.Selection
-		ss := make([]models.Selection, 0)
-		for rows.Next() {
-			data := models.Selection{}
-			err := rows.Scan(&data.Uuid, &data.DocumentUUID, &data.SelectionBounds)
-			if err != nil {
-				return err
-			}
+		ss, err := querySelections(ctx, db, `s."Document_UUID" = $1 AND `+documentAccessible("d", 2, readRoles), excludes, uid.String(), ownerUid.String())
+		if err != nil {
+			return err
+		}
+
+		callback(ss)
+		return nil
+	}
+}
+
+// getSelectionBySelectionUUIDFunction returns the selection, or sql.ErrNoRows when it does not exist or ownerUid may not
+// read its document.
+func getSelectionBySelectionUUIDFunction(uid uuid.UUID, ownerUid uuid.UUID, excludes map[string]bool, callback func(data []models.Selection)) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		ss, err := querySelections(ctx, db, `s."Selection_UUID" = $1 AND `+documentAccessible("d", 2, readRoles), excludes, uid.String(), ownerUid.String())
+		if err != nil {
+			return err
+		}
 
-			ss = append(ss, data)
+		if len(ss) == 0 {
+			return sql.ErrNoRows
 		}
 
 		callback(ss)
@@ -129,49 +254,85 @@ func getSelectionByDocumentUUIDFunction(uid uuid.UUID, callback func(data []mode
 	}
 }
 
-func getSelectionBySelectionUUIDFunction(uid uuid.UUID, callback func(data []models.Selection)) func(db *sql.DB) error {
-	return func(db *sql.DB) error {
-		sqlStatement := `SELECT "Selection_UUID", "Document_UUID", "Selection_bounds" FROM selection_table where "Selection_UUID" = $1`
+func saveExtractionFunction(uid uuid.UUID, ownerUid uuid.UUID, extraction models.SelectionExtraction) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `UPDATE selection_table s SET "Extracted_Content" = $1 FROM document_table d WHERE d."Document_UUID" = s."Document_UUID" AND s."Selection_UUID" = $2 AND ` + documentAccessible("d", 3, writeRoles)
 
-		rows, err := db.Query(sqlStatement, uid.String())
+		content, err := json.Marshal(extraction)
 		if err != nil {
 			return err
+		}
 
+		result, err := db.ExecContext(ctx, sqlStatement, string(content), uid, ownerUid)
+		if err != nil {
+			return err
 		}
 
-		var ss []models.Selection
-		for rows.Next() {
-			data := models.Selection{}
-			err := rows.Scan(&data.Uuid, &data.DocumentUUID, &data.SelectionBounds)
-			if err != nil {
-				return err
+		return requireAffectedRows(result)
+	}
+}
+
+// updateSelectionFunction applies a partial update in a single statement, merging the changed pages into the stored
+// bounds so concurrent updates of different pages do not overwrite each other. Stored extraction results are cleared
+// when the bounds change, as they no longer describe the selection.
+func updateSelectionFunction(uid uuid.UUID, ownerUid uuid.UUID, update models.SelectionUpdate) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `UPDATE selection_table s SET
+    "isCompleted" = COALESCE($1, s."isCompleted"),
+    "Settings" = COALESCE($2::json, s."Settings"),
+    "Selection_bounds" = CASE WHEN $5 THEN ((COALESCE(s."Selection_bounds"::jsonb, '{}'::jsonb) || $3::jsonb) - $4::text[])::json ELSE s."Selection_bounds" END,
+    "Extracted_Content" = CASE WHEN $5 THEN NULL ELSE s."Extracted_Content" END
+FROM document_table d
+WHERE d."Document_UUID" = s."Document_UUID" AND s."Selection_UUID" = $6 AND ` + documentAccessible("d", 7, writeRoles)
+
+		replaced := make(map[int][]models.SelectionBounds)
+		removed := make([]string, 0)
+		for page, bounds := range update.SelectionBounds {
+			if bounds == nil || len(*bounds) == 0 {
+				removed = append(removed, strconv.Itoa(page))
+				continue
 			}
 
-			ss = append(ss, data)
+			replaced[page] = *bounds
 		}
 
-		callback(ss)
-		return nil
+		replacedJson, err := json.Marshal(replaced)
+		if err != nil {
+			return err
+		}
+
+		boundsChanged := len(update.SelectionBounds) > 0
+		result, err := db.ExecContext(ctx, sqlStatement, update.IsComplete, update.Settings, string(replacedJson), pq.Array(removed), boundsChanged, uid, ownerUid)
+		if err != nil {
+			return err
+		}
+
+		return requireAffectedRows(result)
 	}
 }
 
-func deleteSelectionBySelectionUUIDFunction(uid uuid.UUID) func(db *sql.DB) error {
-	return func(db *sql.DB) error {
-		sqlStatement := `DELETE FROM selection_table WHERE "Selection_UUID" = $1`
-		_, err := db.Exec(sqlStatement, uid)
+func deleteSelectionBySelectionUUIDFunction(uid uuid.UUID, ownerUid uuid.UUID) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `DELETE FROM selection_table s USING document_table d WHERE d."Document_UUID" = s."Document_UUID" AND s."Selection_UUID" = $1 AND ` + documentAccessible("d", 2, writeRoles)
+		result, err := db.ExecContext(ctx, sqlStatement, uid, ownerUid)
 		if err != nil {
 			return err
 		}
 
-		return nil
+		return requireAffectedRows(result)
 	}
 }
 
-func deleteSelectionByDocumentUUIDFunction(uid uuid.UUID) func(db *sql.DB) error {
-	return func(db *sql.DB) error {
-		sqlStatement := `DELETE FROM selection_table WHERE "Document_UUID" = $1`
-		_, err := db.Exec(sqlStatement, uid)
-		if err != nil {
+// deleteSelectionByDocumentUUIDFunction deletes every selection of a document, returning sql.ErrNoRows when ownerUid
+// may not edit the document. Deleting from an editable document without selections succeeds.
+func deleteSelectionByDocumentUUIDFunction(uid uuid.UUID, ownerUid uuid.UUID) func(ctx context.Context, db Executor) error {
+	return func(ctx context.Context, db Executor) error {
+		sqlStatement := `WITH owned AS (SELECT d."Document_UUID" FROM document_table d WHERE d."Document_UUID" = $1 AND ` + documentAccessible("d", 2, writeRoles) + `),
+     deleted AS (DELETE FROM selection_table WHERE "Document_UUID" IN (SELECT "Document_UUID" FROM owned))
+SELECT "Document_UUID" FROM owned`
+
+		var documentUid uuid.UUID
+		if err := db.QueryRowContext(ctx, sqlStatement, uid, ownerUid).Scan(&documentUid); err != nil {
 			return err
 		}
 
@@ -0,0 +1,75 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pdf_service_api/models"
+	"pdf_service_api/postgres"
+	"pdf_service_api/testutil"
+	"testing"
+)
+
+func TestAPIKeyRepositoryIntegration(t *testing.T) {
+	t.Parallel()
+	t.Run("Create, authenticate and revoke an API key", createAuthenticateAndRevokeAPIKey)
+}
+
+func createAuthenticateAndRevokeAPIKey(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, dbUser, dbPassword)
+	require.NoError(t, err)
+	t.Cleanup(testutil.CleanUp(ctx, *ctr))
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	repository := postgres.NewAPIKeyRepository(dbHandle)
+	owner := uuid.New()
+	key := models.APIKey{
+		Uuid:        uuid.New(),
+		OwnerUUID:   owner,
+		Name:        "pipeline",
+		Prefix:      "pdfsvc_abcd",
+		Permissions: []string{models.PermissionDocumentsRead, models.PermissionSelectionsWrite},
+	}
+	require.NoError(t, repository.CreateAPIKey(ctx, key, "hash"))
+
+	authenticated, err := repository.AuthenticateAPIKey(ctx, "hash")
+	require.NoError(t, err)
+	assert.Equal(t, key.Uuid, authenticated.Uuid)
+	assert.Equal(t, key.Permissions, authenticated.Permissions)
+	require.NotNil(t, authenticated.LastUsed)
+
+	again, err := repository.AuthenticateAPIKey(ctx, "hash")
+	require.NoError(t, err)
+	assert.Equal(t, authenticated.LastUsed, again.LastUsed, "a recent last use is not written again")
+
+	err = dbHandle.WithConnection(func(db *sql.DB) error {
+		_, err := db.Exec(`UPDATE api_key_table SET "Last_Used" = "Last_Used" - interval '2 minutes' WHERE "Key_UUID" = $1`, key.Uuid)
+		return err
+	})
+	require.NoError(t, err)
+
+	again, err = repository.AuthenticateAPIKey(ctx, "hash")
+	require.NoError(t, err)
+	require.NotNil(t, again.LastUsed)
+	assert.True(t, again.LastUsed.After(*authenticated.LastUsed), "a stale last use is written again")
+
+	_, err = repository.AuthenticateAPIKey(ctx, "other hash")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	keys, err := repository.GetAPIKeys(ctx, owner)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "pdfsvc_abcd", keys[0].Prefix)
+
+	assert.ErrorIs(t, repository.RevokeAPIKey(ctx, key.Uuid, uuid.New()), sql.ErrNoRows)
+	require.NoError(t, repository.RevokeAPIKey(ctx, key.Uuid, owner))
+
+	_, err = repository.AuthenticateAPIKey(ctx, "hash")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
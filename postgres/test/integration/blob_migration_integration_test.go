@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"pdf_service_api/postgres"
+	"pdf_service_api/storage"
+	"pdf_service_api/testutil"
+	"testing"
+)
+
+var dbUser = "user"
+var dbPassword = "password"
+
+func TestBlobMigrationIntegration(t *testing.T) {
+	t.Parallel()
+	t.Run("Migrate legacy base64 documents into the blob store", migrateLegacyDocuments)
+}
+
+func migrateLegacyDocuments(t *testing.T) {
+	t.Parallel()
+	validDocument := uuid.MustParse("b66fd223-515f-4503-80cc-2bdaa50ef474")
+	invalidDocument := uuid.MustParse("b5b7f18e-aed3-4eb7-aca8-79bcedf03d1b")
+
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, dbUser, dbPassword)
+	require.NoError(t, err)
+	t.Cleanup(testutil.CleanUp(ctx, *ctr))
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+
+	err = dbHandle.WithConnection(func(db *sql.DB) error {
+		_, err := db.Exec(`insert into document_table ("Document_UUID", "Document_Base64") values ($1, $2), ($3, $4)`,
+			validDocument, "JVBERi0xLjQgVEhJUyBJUyBBIFRFU1QgRE9DVU1FTlQ=",
+			invalidDocument, "Not base64 at all")
+		return err
+	})
+	require.NoError(t, err)
+
+	blobStore, err := storage.NewFilesystemBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	result, err := postgres.MigrateDocumentsToBlobStore(dbHandle, blobStore)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Migrated)
+	assert.Contains(t, result.Failed, invalidDocument)
+
+	err = dbHandle.WithConnection(func(db *sql.DB) error {
+		var (
+			pdfBase64  *string
+			storageKey string
+			size       int64
+			checksum   string
+		)
+
+		row := db.QueryRow(`SELECT "Document_Base64", "Storage_Key", "Size_Bytes", "Checksum_SHA256" FROM document_table WHERE "Document_UUID" = $1`, validDocument)
+		if err := row.Scan(&pdfBase64, &storageKey, &size, &checksum); err != nil {
+			return err
+		}
+
+		assert.Nil(t, pdfBase64)
+		assert.Equal(t, "documents/"+validDocument.String()+".pdf", storageKey)
+		assert.EqualValues(t, 32, size)
+		assert.Len(t, checksum, 64)
+
+		content, err := blobStore.Open(ctx, storageKey)
+		require.NoError(t, err)
+		defer content.Close()
+
+		data, err := io.ReadAll(content)
+		require.NoError(t, err)
+		assert.Equal(t, "%PDF-1.4 THIS IS A TEST DOCUMENT", string(data))
+		return nil
+	})
+	require.NoError(t, err)
+
+	result, err = postgres.MigrateDocumentsToBlobStore(dbHandle, blobStore)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Migrated, "Already migrated documents should not be migrated again")
+}
@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pdf_service_api/postgres"
+	"pdf_service_api/testutil"
+	"testing"
+	"time"
+)
+
+func TestContextIntegration(t *testing.T) {
+	t.Parallel()
+	t.Run("A cancelled context stops a running query", cancelledContextStopsQuery)
+	t.Run("The statement timeout stops a running query", statementTimeoutStopsQuery)
+	t.Run("A repository call with a cancelled context fails", repositoryCallWithCancelledContext)
+}
+
+func setupContextDatabase(t *testing.T) postgres.DatabaseHandler {
+	ctx := context.Background()
+	ctr, err := testutil.CreateTestContainerPostgres(ctx, dbUser, dbPassword)
+	require.NoError(t, err)
+	t.Cleanup(testutil.CleanUp(ctx, *ctr))
+
+	dbHandle, err := testutil.CreateDatabaseHandlerFromPostgresInfo(ctx, *ctr)
+	require.NoError(t, err)
+	return dbHandle
+}
+
+func sleepQuery(ctx context.Context, db postgres.Executor) error {
+	_, err := db.ExecContext(ctx, `SELECT pg_sleep(30)`)
+	return err
+}
+
+// assertNoQueryRunning checks that Postgres stopped the sleeping query rather than the client only giving up on it.
+func assertNoQueryRunning(t *testing.T, dbHandle postgres.DatabaseHandler) {
+	assert.Eventually(t, func() bool {
+		var running int
+		err := dbHandle.WithConnection(func(db *sql.DB) error {
+			return db.QueryRow(`SELECT count(*) FROM pg_stat_activity WHERE query = 'SELECT pg_sleep(30)' AND state = 'active'`).Scan(&running)
+		})
+		return err == nil && running == 0
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func cancelledContextStopsQuery(t *testing.T) {
+	t.Parallel()
+	dbHandle := setupContextDatabase(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	started := time.Now()
+	err := dbHandle.WithContext(ctx, "test.sleepQuery", sleepQuery)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(started), 10*time.Second)
+	assertNoQueryRunning(t, dbHandle)
+}
+
+func statementTimeoutStopsQuery(t *testing.T) {
+	t.Parallel()
+	dbHandle := setupContextDatabase(t)
+	dbHandle.StatementTimeout = 200 * time.Millisecond
+
+	started := time.Now()
+	err := dbHandle.WithContext(context.Background(), "test.sleepQuery", sleepQuery)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(started), 10*time.Second)
+	assertNoQueryRunning(t, dbHandle)
+}
+
+func repositoryCallWithCancelledContext(t *testing.T) {
+	t.Parallel()
+	dbHandle := setupContextDatabase(t)
+	repository := postgres.NewDocumentRepository(dbHandle)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repository.GetDocumentByOwnerUUID(ctx, uuid.New(), 10, 0, false, map[string]bool{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
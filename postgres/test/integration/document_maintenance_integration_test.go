@@ -0,0 +1,48 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pdf_service_api/postgres"
+	"testing"
+)
+
+func TestDocumentMaintenanceIntegration(t *testing.T) {
+	t.Parallel()
+	t.Run("Visit every document, or those of one owner", forEachDocument)
+}
+
+func forEachDocument(t *testing.T) {
+	t.Parallel()
+	dbHandle := setupContextDatabase(t)
+	owner, other := uuid.New(), uuid.New()
+
+	// More documents than are read in one batch, so the paging is exercised.
+	err := dbHandle.WithConnection(func(db *sql.DB) error {
+		_, err := db.Exec(`insert into document_table ("Document_UUID", "Document_Base64", "Owner_UUID")
+select gen_random_uuid(), '', case when n <= 60 then $1::uuid else $2::uuid end from generate_series(1, 80) n`, owner, other)
+		return err
+	})
+	require.NoError(t, err)
+
+	visited := make(map[uuid.UUID]bool)
+	err = postgres.ForEachDocument(context.Background(), dbHandle, nil, func(document postgres.DocumentReference) error {
+		assert.False(t, visited[document.Uuid], "every document is visited once")
+		visited[document.Uuid] = true
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, visited, 80)
+
+	owned := 0
+	err = postgres.ForEachDocument(context.Background(), dbHandle, &owner, func(document postgres.DocumentReference) error {
+		assert.Equal(t, owner, *document.OwnerUUID)
+		owned++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 60, owned)
+}
@@ -0,0 +1,80 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pdf_service_api/models"
+	"pdf_service_api/postgres"
+	"pdf_service_api/storage"
+	"sync"
+	"testing"
+)
+
+func TestDocumentRepositoryIntegration(t *testing.T) {
+	t.Parallel()
+	t.Run("Deleting a document removes every blob it can", deleteDocumentRemovesRemainingBlobs)
+}
+
+// failingDeleteStore fails to delete the blobs under failing, and records every key it was asked to delete.
+type failingDeleteStore struct {
+	models.BlobStore
+	failing map[string]bool
+
+	mutex   sync.Mutex
+	deleted []string
+}
+
+func (s *failingDeleteStore) Delete(ctx context.Context, key string) error {
+	s.mutex.Lock()
+	s.deleted = append(s.deleted, key)
+	s.mutex.Unlock()
+
+	if s.failing[key] {
+		return errors.New("the blob store is unavailable")
+	}
+
+	return s.BlobStore.Delete(ctx, key)
+}
+
+func deleteDocumentRemovesRemainingBlobs(t *testing.T) {
+	t.Parallel()
+	dbHandle := setupContextDatabase(t)
+	ctx := context.Background()
+	owner := uuid.New()
+	document := models.Document{Uuid: uuid.New(), OwnerUUID: &owner}
+
+	filesystemStore, err := storage.NewFilesystemBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	documentKey := "documents/" + document.Uuid.String() + ".pdf"
+	blobStore := &failingDeleteStore{BlobStore: filesystemStore, failing: map[string]bool{documentKey: true}}
+	repository := postgres.NewDocumentRepositoryWithBlobStore(dbHandle, blobStore)
+	pageImageRepository := postgres.NewPageImageRepository(dbHandle, blobStore)
+
+	require.NoError(t, repository.UploadDocumentFromReader(ctx, document, bytes.NewReader([]byte("%PDF-1.4 THIS IS A TEST DOCUMENT"))))
+	for _, page := range []int{1, 2} {
+		image := models.PageImage{DocumentUUID: document.Uuid, PageNumber: page, Dpi: 72, Format: "png"}
+		require.NoError(t, pageImageRepository.SavePageImage(ctx, image, bytes.NewReader([]byte("image"))))
+	}
+
+	err = repository.DeleteDocumentById(ctx, document.Uuid, owner)
+	require.NoError(t, err, "the document is deleted even though one of its blobs is not")
+
+	_, err = repository.GetDocumentByDocumentUUID(ctx, document.Uuid, owner, map[string]bool{})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.Len(t, blobStore.deleted, 3, "every blob is deleted, not only the ones before the failure")
+	for _, key := range blobStore.deleted {
+		_, err := filesystemStore.Open(ctx, key)
+		if key == documentKey {
+			assert.NoError(t, err, "the blob that failed to be deleted is left behind")
+		} else {
+			assert.ErrorIs(t, err, models.ErrBlobNotFound, key)
+		}
+	}
+}
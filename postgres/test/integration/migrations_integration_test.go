@@ -0,0 +1,126 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pdf_service_api/postgres"
+	"sync"
+	"testing"
+)
+
+func TestMigrationsIntegration(t *testing.T) {
+	t.Parallel()
+	t.Run("Migrate down and up again", migrateDownAndUp)
+	t.Run("Replicas migrating at the same time apply every migration once", migrateConcurrently)
+	t.Run("Pending migrations are listed without the migration lock", pendingMigrations)
+}
+
+func tableExists(t *testing.T, dbHandle postgres.DatabaseHandler, table string) bool {
+	var exists bool
+	err := dbHandle.WithConnection(func(db *sql.DB) error {
+		return db.QueryRow(`SELECT to_regclass($1) IS NOT NULL`, table).Scan(&exists)
+	})
+	require.NoError(t, err)
+	return exists
+}
+
+func migrateDownAndUp(t *testing.T) {
+	t.Parallel()
+	dbHandle := setupContextDatabase(t)
+	ctx := context.Background()
+	migrations, err := postgres.Migrations()
+	require.NoError(t, err)
+
+	statuses, err := postgres.GetMigrationStatus(ctx, dbHandle)
+	require.NoError(t, err)
+	require.Len(t, statuses, len(migrations))
+	for _, status := range statuses {
+		assert.NotNil(t, status.AppliedAt, "migration %d is applied when the test database is set up", status.Version)
+	}
+
+	applied, err := postgres.MigrateUp(ctx, dbHandle)
+	require.NoError(t, err)
+	assert.Empty(t, applied, "applied migrations are not run again")
+
+	reverted, err := postgres.MigrateDown(ctx, dbHandle, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, migrations[len(migrations)-1].Version, reverted[0].Version)
+
+	statuses, err = postgres.GetMigrationStatus(ctx, dbHandle)
+	require.NoError(t, err)
+	assert.Nil(t, statuses[len(statuses)-1].AppliedAt)
+
+	reverted, err = postgres.MigrateDown(ctx, dbHandle, len(migrations))
+	require.NoError(t, err)
+	assert.Len(t, reverted, len(migrations)-1)
+	assert.False(t, tableExists(t, dbHandle, "document_table"))
+
+	applied, err = postgres.MigrateUp(ctx, dbHandle)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrations))
+	assert.True(t, tableExists(t, dbHandle, "api_key_table"))
+}
+
+func migrateConcurrently(t *testing.T) {
+	t.Parallel()
+	dbHandle := setupContextDatabase(t)
+	ctx := context.Background()
+	migrations, err := postgres.Migrations()
+	require.NoError(t, err)
+
+	_, err = postgres.MigrateDown(ctx, dbHandle, len(migrations))
+	require.NoError(t, err)
+
+	const replicas = 4
+	results := make([][]postgres.Migration, replicas)
+	errs := make([]error, replicas)
+	var wg sync.WaitGroup
+	for i := range replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = postgres.MigrateUp(ctx, dbHandle)
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for i := range replicas {
+		require.NoError(t, errs[i])
+		total += len(results[i])
+	}
+	assert.Equal(t, len(migrations), total)
+}
+
+func pendingMigrations(t *testing.T) {
+	t.Parallel()
+	dbHandle := setupContextDatabase(t)
+	ctx := context.Background()
+	migrations, err := postgres.Migrations()
+	require.NoError(t, err)
+
+	pending, err := postgres.PendingMigrations(ctx, dbHandle)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	_, err = postgres.MigrateDown(ctx, dbHandle, 2)
+	require.NoError(t, err)
+
+	pending, err = postgres.PendingMigrations(ctx, dbHandle)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, migrations[len(migrations)-2].Version, pending[0].Version)
+
+	err = dbHandle.WithConnection(func(db *sql.DB) error {
+		_, err := db.Exec(`DROP TABLE schema_migrations`)
+		return err
+	})
+	require.NoError(t, err)
+
+	pending, err = postgres.PendingMigrations(ctx, dbHandle)
+	require.NoError(t, err)
+	assert.Len(t, pending, len(migrations), "every migration is pending before schema_migrations exists")
+}
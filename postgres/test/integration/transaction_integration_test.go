@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"pdf_service_api/models"
+	"pdf_service_api/postgres"
+	"pdf_service_api/storage"
+	"pdf_service_api/testutil"
+	"testing"
+)
+
+func TestTransactionIntegration(t *testing.T) {
+	t.Parallel()
+	t.Run("A transaction is rolled back when its callback fails", transactionRolledBackOnError)
+	t.Run("Upload a document with meta and selections", uploadDocumentWithDetails)
+	t.Run("Upload a document with an invalid selection creates nothing", uploadDocumentWithDetailsRollsBack)
+}
+
+func countRows(t *testing.T, dbHandle postgres.DatabaseHandler, statement string, args ...any) int {
+	var count int
+	err := dbHandle.WithConnection(func(db *sql.DB) error {
+		return db.QueryRow(statement, args...).Scan(&count)
+	})
+	require.NoError(t, err)
+	return count
+}
+
+func transactionRolledBackOnError(t *testing.T) {
+	t.Parallel()
+	dbHandle := setupContextDatabase(t)
+	documentUUID := uuid.New()
+	failure := errors.New("failure after the insert")
+
+	err := dbHandle.WithTransaction(context.Background(), "test.transaction", func(ctx context.Context, db postgres.Executor) error {
+		if _, err := db.ExecContext(ctx, `insert into document_table ("Document_UUID") values ($1)`, documentUUID); err != nil {
+			return err
+		}
+
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+	assert.Zero(t, countRows(t, dbHandle, `SELECT count(*) FROM document_table WHERE "Document_UUID" = $1`, documentUUID))
+
+	err = dbHandle.WithTransaction(context.Background(), "test.transaction", func(ctx context.Context, db postgres.Executor) error {
+		_, err := db.ExecContext(ctx, `insert into document_table ("Document_UUID") values ($1)`, documentUUID)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, countRows(t, dbHandle, `SELECT count(*) FROM document_table WHERE "Document_UUID" = $1`, documentUUID))
+}
+
+func uploadDocumentWithDetails(t *testing.T) {
+	t.Parallel()
+	dbHandle := setupContextDatabase(t)
+	repository := postgres.NewDocumentRepository(dbHandle)
+	owner := uuid.New()
+
+	numberOfPages := uint32(1)
+	document := models.Document{Uuid: uuid.New(), OwnerUUID: &owner}
+	selections := []models.Selection{{Uuid: uuid.New()}, {Uuid: uuid.New()}}
+	content := testutil.BuildTestPDF(testutil.LetterPage())
+
+	err := repository.UploadDocumentWithDetails(context.Background(), document, bytes.NewReader(content), &models.Meta{NumberOfPages: &numberOfPages}, selections)
+	require.NoError(t, err)
+
+	meta, err := postgres.NewMetaRepository(dbHandle).GetMeta(context.Background(), document.Uuid, owner)
+	require.NoError(t, err)
+	assert.Equal(t, numberOfPages, *meta.NumberOfPages)
+
+	stored, err := postgres.NewSelectionRepository(dbHandle).GetSelectionsByDocumentUUID(context.Background(), document.Uuid, owner, map[string]bool{})
+	require.NoError(t, err)
+	assert.Len(t, stored, 2)
+}
+
+func uploadDocumentWithDetailsRollsBack(t *testing.T) {
+	t.Parallel()
+	dbHandle := setupContextDatabase(t)
+	root := t.TempDir()
+	blobStore, err := storage.NewFilesystemBlobStore(root)
+	require.NoError(t, err)
+	repository := postgres.NewDocumentRepositoryWithBlobStore(dbHandle, blobStore)
+	owner := uuid.New()
+
+	numberOfPages := uint32(1)
+	invalidSettings := "not json"
+	document := models.Document{Uuid: uuid.New(), OwnerUUID: &owner}
+	selections := []models.Selection{{Uuid: uuid.New()}, {Uuid: uuid.New(), Settings: &invalidSettings}}
+	content := testutil.BuildTestPDF(testutil.LetterPage())
+
+	err = repository.UploadDocumentWithDetails(context.Background(), document, bytes.NewReader(content), &models.Meta{NumberOfPages: &numberOfPages}, selections)
+	require.Error(t, err)
+
+	assert.Zero(t, countRows(t, dbHandle, `SELECT count(*) FROM document_table WHERE "Document_UUID" = $1`, document.Uuid))
+	assert.Zero(t, countRows(t, dbHandle, `SELECT count(*) FROM documentmeta_table WHERE "Document_UUID" = $1`, document.Uuid))
+	assert.Zero(t, countRows(t, dbHandle, `SELECT count(*) FROM selection_table WHERE "Document_UUID" = $1`, document.Uuid))
+
+	blobs, err := filepath.Glob(filepath.Join(root, "documents", "*"))
+	require.NoError(t, err)
+	assert.Empty(t, blobs, "the blob of a document that was not created must be removed")
+}
@@ -0,0 +1,55 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pdf_service_api/metrics"
+	pg "pdf_service_api/postgres"
+	"testing"
+)
+
+// querySeries returns the sample count of every query duration series, keyed by repository and method.
+func querySeries(t *testing.T) map[string]uint64 {
+	families, err := metrics.Registry.Gather()
+	require.NoError(t, err)
+
+	series := make(map[string]uint64)
+	for _, family := range families {
+		if family.GetName() != "pdf_service_db_query_duration_seconds" {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			series[fmt.Sprintf("%s.%s", labels["repository"], labels["method"])] = metric.GetHistogram().GetSampleCount()
+		}
+	}
+
+	return series
+}
+
+func TestQueriesAreTimedPerRepositoryMethod(t *testing.T) {
+	// Without a database the statements fail straight away, but the time taken is still recorded.
+	handler := pg.DatabaseHandler{DbConfig: pg.ConfigForDatabase{}}
+	repository := pg.NewSelectionRepository(handler)
+
+	for range 2 {
+		_, err := repository.GetSelectionsByDocumentUUID(context.Background(), uuid.New(), uuid.New(), map[string]bool{})
+		assert.Error(t, err)
+	}
+	_, err := pg.PendingMigrations(context.Background(), handler)
+	assert.Error(t, err)
+	_, err = pg.MigrateDocumentsToBlobStore(handler, nil)
+	assert.Error(t, err)
+
+	series := querySeries(t)
+	assert.Equal(t, uint64(2), series["selectionRepository.GetSelectionsByDocumentUUID"])
+	assert.NotContains(t, series, "postgres.PendingMigrations", "the readiness probe is not a query to record")
+	assert.Equal(t, uint64(1), series["postgres.MigrateDocumentsToBlobStore"], "functions that are not methods are labelled with their package")
+}
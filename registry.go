@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"pdf_service_api/config"
+	"pdf_service_api/consul"
+	"pdf_service_api/eureka"
+	"pdf_service_api/models"
+	"strconv"
+	"time"
+)
+
+// deregisterTimeout bounds how long shutdown waits for the registry to remove the instance.
+const deregisterTimeout = 10 * time.Second
+
+// createServiceRegistry creates the configured service registry. The instance is reported unhealthy while healthCheck
+// returns an error.
+func createServiceRegistry(cfg config.Config, healthCheck func(ctx context.Context) error) (models.ServiceRegistry, error) {
+	switch cfg.RegistryType() {
+	case "eureka":
+		return createEurekaClient(cfg, healthCheck)
+	case "consul":
+		return createConsulClient(cfg, healthCheck)
+	case "none":
+		return noopRegistry{}, nil
+	default:
+		return nil, fmt.Errorf("unknown service registry %q", cfg.Registry.Type)
+	}
+}
+
+func createEurekaClient(cfg config.Config, healthCheck func(ctx context.Context) error) (*eureka.Client, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	port := cfg.EurekaPort()
+	return eureka.NewClient(eureka.Config{
+		ServiceURLs:     cfg.Eureka.ServiceURLs,
+		AppName:         cfg.Eureka.AppName,
+		InstanceID:      cfg.Eureka.InstanceID,
+		HostName:        hostname,
+		IPAddr:          cfg.Eureka.AppIP,
+		Port:            port,
+		ManagementPort:  cfg.Eureka.ManagementPort,
+		Version:         version,
+		HealthCheckURL:  fmt.Sprintf("http://%s:%d/readyz", cfg.Eureka.AppIP, port),
+		StatusPageURL:   fmt.Sprintf("http://%s:%d/healthz", cfg.Eureka.AppIP, port),
+		RenewalInterval: time.Duration(cfg.Eureka.RenewalInterval),
+		LeaseDuration:   time.Duration(cfg.Eureka.LeaseDuration),
+		HealthCheck: func(ctx context.Context) eureka.Status {
+			if err := healthCheck(ctx); err != nil {
+				fmt.Printf("Reporting DOWN to eureka: %s\n", err)
+				return eureka.StatusDown
+			}
+
+			return eureka.StatusUp
+		},
+	})
+}
+
+func createConsulClient(cfg config.Config, healthCheck func(ctx context.Context) error) (*consul.Client, error) {
+	return consul.NewClient(consul.Config{
+		Address:        cfg.Consul.Address,
+		Token:          cfg.Consul.Token,
+		ServiceName:    cfg.Consul.ServiceName,
+		ServiceID:      cfg.Consul.ServiceID,
+		ServiceAddress: cfg.Consul.ServiceAddress,
+		ServicePort:    cfg.ConsulPort(),
+		Tags:           cfg.Consul.Tags,
+		Meta: map[string]string{
+			"version":         version,
+			"management_port": strconv.Itoa(cfg.ConsulPort()),
+		},
+		CheckTTL:                       time.Duration(cfg.Consul.CheckTTL),
+		DeregisterCriticalServiceAfter: time.Duration(cfg.Consul.DeregisterCriticalServiceAfter),
+		HealthCheck:                    healthCheck,
+	})
+}
+
+// noopRegistry is used when the instance is not registered with any service registry.
+type noopRegistry struct{}
+
+func (noopRegistry) Run(ctx context.Context) {}
+
+func (noopRegistry) Deregister(ctx context.Context) error {
+	return nil
+}
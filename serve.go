@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"os"
+	"os/signal"
+	"pdf_service_api/config"
+	v1 "pdf_service_api/controller/v1"
+	"pdf_service_api/metrics"
+	"pdf_service_api/models"
+	"pdf_service_api/pdf"
+	pg "pdf_service_api/postgres"
+	"syscall"
+	"time"
+)
+
+// serve migrates the database and the legacy documents, unless turned off by the feature toggles, and runs the HTTP
+// server on the configured listen address.
+func serve(ctx context.Context, cfg config.Config, dbHandler pg.DatabaseHandler, args []string) error {
+	if err := flag.NewFlagSet("serve", flag.ExitOnError).Parse(args); err != nil {
+		return err
+	}
+
+	fmt.Println(os.Hostname())
+
+	if cfg.Features.AutoMigrate {
+		applied, err := pg.MigrateUp(ctx, dbHandler)
+		if err != nil {
+			return fmt.Errorf("failed to migrate the database: %w", err)
+		}
+
+		for _, migration := range applied {
+			fmt.Printf("Applied migration %d %s\n", migration.Version, migration.Name)
+		}
+	}
+
+	blobStore, err := createBlobStore(cfg.BlobStore)
+	if err != nil {
+		return fmt.Errorf("failed to create blob store: %w", err)
+	}
+
+	if cfg.Features.MigrateBlobsOnStart {
+		migrationResult, err := pg.MigrateDocumentsToBlobStore(dbHandler, blobStore)
+		if err != nil {
+			return fmt.Errorf("failed to migrate documents to the blob store: %w", err)
+		}
+
+		if migrationResult.Migrated > 0 || len(migrationResult.Failed) > 0 {
+			fmt.Printf("Migrated %d document(s) to the blob store, %d failed\n", migrationResult.Migrated, len(migrationResult.Failed))
+			for documentUuid, err := range migrationResult.Failed {
+				fmt.Printf("Failed to migrate document %s: %s\n", documentUuid, err)
+			}
+		}
+	}
+
+	metaRepository := pg.NewMetaRepository(dbHandler)
+	documentRepository := pg.NewDocumentRepositoryWithBlobStore(dbHandler, blobStore)
+	documentCtrl := &v1.DocumentController{
+		DocumentRepository:  documentRepository,
+		PageWordsRepository: pg.NewPageWordsRepository(dbHandler),
+		PageImageRepository: pg.NewPageImageRepository(dbHandler, blobStore),
+		Renderer: pdf.NewRenderer(pdf.RendererConfig{
+			Command:       cfg.Render.Command,
+			MaxConcurrent: cfg.Render.MaxConcurrent,
+			MaxPixels:     cfg.Render.MaxPixels,
+		}),
+	}
+	if cfg.Features.ExtractMetaOnUpload {
+		documentCtrl.MetaRepository = metaRepository
+	}
+	if cfg.Features.DocumentSharing {
+		documentCtrl.GrantRepository = pg.NewGrantRepository(dbHandler)
+	}
+	selectionCtrl := &v1.SelectionController{SelectionRepository: pg.NewSelectionRepository(dbHandler), DocumentRepository: documentRepository}
+	metaCtrl := &v1.MetaController{MetaRepository: metaRepository}
+	apiKeyRepository := pg.NewAPIKeyRepository(dbHandler)
+	apiKeyCtrl := &v1.APIKeyController{APIKeyRepository: apiKeyRepository}
+
+	middleware, err := createAuthMiddleware(cfg.Auth, apiKeyRepository)
+	if err != nil {
+		return fmt.Errorf("failed to configure authentication: %w", err)
+	}
+
+	if cfg.Limits.MaxUploadBytes > 0 {
+		middleware = append([]gin.HandlerFunc{v1.LimitRequestBody(cfg.Limits.MaxUploadBytes)}, middleware...)
+	}
+
+	if cfg.Limits.RequestTimeout > 0 {
+		middleware = append([]gin.HandlerFunc{v1.RequestTimeout(time.Duration(cfg.Limits.RequestTimeout))}, middleware...)
+	}
+
+	// The metrics middleware comes first, so requests rejected by the other middleware are counted too. Only the API
+	// routes are counted, not /ping, the probes or /metrics itself.
+	if cfg.Features.Metrics {
+		middleware = append([]gin.HandlerFunc{metrics.Middleware()}, middleware...)
+	}
+
+	router := v1.SetupRouter(documentCtrl, selectionCtrl, metaCtrl, apiKeyCtrl, middleware...)
+
+	healthCtrl := &v1.HealthController{Checks: readinessChecks(dbHandler, blobStore)}
+	healthCtrl.SetupRouter(&router.RouterGroup)
+
+	if cfg.Features.Metrics {
+		if err := metrics.Registry.Register(metrics.NewDBStatsCollector(dbHandler.Stats)); err != nil {
+			return fmt.Errorf("failed to register the database metrics: %w", err)
+		}
+
+		router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
+
+	registry, err := createServiceRegistry(cfg, healthCtrl.Ready)
+	if err != nil {
+		return fmt.Errorf("failed to configure the service registry: %w", err)
+	}
+
+	registryCtx, stopRegistry := context.WithCancel(ctx)
+	registryDone := make(chan struct{})
+	go func() {
+		defer close(registryDone)
+		registry.Run(registryCtx)
+	}()
+
+	leave := func() error {
+		healthCtrl.MarkNotReady()
+		stopRegistry()
+		<-registryDone
+
+		deregisterCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deregisterTimeout)
+		defer cancel()
+		return registry.Deregister(deregisterCtx)
+	}
+
+	server := &http.Server{Addr: cfg.Server.ListenAddress, Handler: router}
+	return runServer(ctx, server, time.Duration(cfg.Server.ShutdownDelay), time.Duration(cfg.Server.ShutdownTimeout), leave)
+}
+
+// readinessChecks returns the checks the instance must pass to be ready: the database can be reached, its
+// migrations are applied and the blob store can be reached.
+func readinessChecks(dbHandler pg.DatabaseHandler, blobStore models.BlobStore) []v1.ReadinessCheck {
+	return []v1.ReadinessCheck{
+		{Name: "database", Check: func(ctx context.Context) error {
+			db, err := dbHandler.DB()
+			if err != nil {
+				return err
+			}
+
+			return db.PingContext(ctx)
+		}},
+		{Name: "migrations", Check: func(ctx context.Context) error {
+			pending, err := pg.PendingMigrations(ctx, dbHandler)
+			if err != nil {
+				return err
+			}
+
+			if len(pending) > 0 {
+				return fmt.Errorf("%d migration(s) pending, starting with %d %s", len(pending), pending[0].Version, pending[0].Name)
+			}
+
+			return nil
+		}},
+		{Name: "blobStore", Check: blobStore.Ping},
+	}
+}
+
+// runServer serves until the server fails or the process receives SIGINT or SIGTERM. On a signal it calls leave, so
+// the instance stops being routed to, and keeps accepting connections for shutdownDelay, giving load balancers polling
+// the readiness endpoint time to stop routing to it. It then stops accepting connections and waits up to
+// shutdownTimeout for the requests in flight to finish. When the server fails, such as when its address is in use,
+// leave is called without the delay. The database pool is closed by main once serve returns.
+func runServer(ctx context.Context, server *http.Server, shutdownDelay, shutdownTimeout time.Duration, leave func() error) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return errors.Join(err, leave())
+	case <-ctx.Done():
+	}
+
+	stop()
+	fmt.Println("Shutting down, draining requests in flight")
+
+	if err := leave(); err != nil {
+		fmt.Println(err.Error())
+	}
+	time.Sleep(shutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to drain requests in flight: %w", err)
+	}
+
+	if err := <-serverErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	fmt.Println("Server stopped")
+	return nil
+}
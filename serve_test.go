@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startServer runs runServer with a slow endpoint at /slow, which waits for release to be closed before it answers,
+// and a fast one at /ping. It returns the base URL, once the server answers, and the result of runServer.
+func startServer(t *testing.T, ctx context.Context, release <-chan struct{}, shutdownDelay, shutdownTimeout time.Duration, leave func() error) (string, <-chan error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "pong")
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		_, _ = io.WriteString(w, "done")
+	})
+
+	result := make(chan error, 1)
+	go func() {
+		result <- runServer(ctx, &http.Server{Addr: address, Handler: mux}, shutdownDelay, shutdownTimeout, leave)
+	}()
+
+	baseURL := "http://" + address
+	require.Eventually(t, func() bool {
+		response, err := http.Get(baseURL + "/ping")
+		if err != nil {
+			return false
+		}
+		_ = response.Body.Close()
+		return response.StatusCode == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return baseURL, result
+}
+
+// getAsync requests url in the background, returning its body or the error.
+func getAsync(url string) <-chan string {
+	body := make(chan string, 1)
+	go func() {
+		response, err := http.Get(url)
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer response.Body.Close()
+
+		read, err := io.ReadAll(response.Body)
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		body <- string(read)
+	}()
+	return body
+}
+
+func TestRunServerDrainsRequestsInFlight(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mutex sync.Mutex
+	events := make([]string, 0)
+	record := func(event string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		events = append(events, event)
+	}
+
+	release := make(chan struct{})
+	var baseURL string
+	leave := func() error {
+		// The instance still accepts connections while it deregisters.
+		response, err := http.Get(baseURL + "/ping")
+		if err == nil {
+			_ = response.Body.Close()
+			record("connection accepted while leaving")
+		}
+
+		record("left")
+
+		// Answer the request in flight once the server has stopped accepting connections and is draining.
+		time.AfterFunc(200*time.Millisecond, func() { close(release) })
+		return nil
+	}
+
+	baseURL, result := startServer(t, ctx, release, 50*time.Millisecond, 5*time.Second, leave)
+
+	slow := getAsync(baseURL + "/slow")
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	assert.Equal(t, "done", <-slow, "the request in flight is answered")
+	require.NoError(t, <-result)
+
+	_, err := http.Get(baseURL + "/ping")
+	assert.Error(t, err, "the server stopped accepting connections")
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, []string{"connection accepted while leaving", "left"}, events)
+}
+
+func TestRunServerHonoursShutdownTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	baseURL, result := startServer(t, ctx, release, 0, 200*time.Millisecond, func() error { return nil })
+
+	getAsync(baseURL + "/slow")
+	time.Sleep(50 * time.Millisecond)
+
+	started := time.Now()
+	cancel()
+
+	select {
+	case err := <-result:
+		assert.ErrorIs(t, err, context.DeadlineExceeded, "the request that never finishes is given up on")
+		assert.Less(t, time.Since(started), 2*time.Second)
+	case <-time.After(5 * time.Second):
+		t.Fatal("runServer did not return after the shutdown timeout")
+	}
+}
+
+func TestRunServerStartupFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	left := 0
+	leave := func() error {
+		left++
+		return errors.New("not registered")
+	}
+
+	started := time.Now()
+	server := &http.Server{Addr: listener.Addr().String(), Handler: http.NewServeMux()}
+	err = runServer(context.Background(), server, time.Hour, time.Hour, leave)
+
+	assert.Error(t, err, "the address is in use")
+	assert.ErrorContains(t, err, "not registered")
+	assert.Equal(t, 1, left, "the instance deregisters in case it was registered")
+	assert.Less(t, time.Since(started), 5*time.Second, "the shutdown delay is skipped")
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"pdf_service_api/models"
+	"strings"
+)
+
+type filesystemBlobStore struct {
+	root string
+}
+
+// NewFilesystemBlobStore creates a BlobStore that keeps each blob as a file below root.
+// The root directory is created if it does not already exist.
+func NewFilesystemBlobStore(root string) (models.BlobStore, error) {
+	if root == "" {
+		return nil, errors.New("filesystem blob store root must not be empty")
+	}
+
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create blob store root: %w", err)
+	}
+
+	return filesystemBlobStore{root: root}, nil
+}
+
+func (f filesystemBlobStore) Put(_ context.Context, key string, content io.Reader, _ int64) error {
+	path, err := f.pathForKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	// Write into a temporary file first so readers never observe a partially written blob.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (f filesystemBlobStore) Open(_ context.Context, key string) (io.ReadSeekCloser, error) {
+	path, err := f.pathForKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, models.ErrBlobNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func (f filesystemBlobStore) Delete(_ context.Context, key string) error {
+	path, err := f.pathForKey(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (f filesystemBlobStore) Ping(_ context.Context) error {
+	info, err := os.Stat(f.root)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("the blob store root %s is not a directory", f.root)
+	}
+
+	return nil
+}
+
+// pathForKey maps a storage key onto a file below the store's root, rejecting keys that would escape it.
+func (f filesystemBlobStore) pathForKey(key string) (string, error) {
+	if key == "" || !filepath.IsLocal(filepath.FromSlash(key)) || strings.HasSuffix(key, "/") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+
+	return filepath.Join(f.root, filepath.FromSlash(key)), nil
+}
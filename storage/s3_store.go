@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"io"
+	"net/http"
+	"pdf_service_api/models"
+)
+
+// ConfigForS3 holds the connection details for an S3 compatible object store such as AWS S3 or MinIO.
+type ConfigForS3 struct {
+	Endpoint  string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Region    string
+	UseSSL    bool
+}
+
+type s3BlobStore struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3BlobStore creates a BlobStore backed by a bucket in an S3 compatible object store.
+// The bucket is created if it does not already exist.
+func NewS3BlobStore(config ConfigForS3) (models.BlobStore, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, errors.New("s3 blob store requires an endpoint and a bucket")
+	}
+
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure: config.UseSSL,
+		Region: config.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %w", err)
+	}
+
+	ctx := context.Background()
+	exists, err := client.BucketExists(ctx, config.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check s3 bucket: %w", err)
+	}
+
+	if !exists {
+		err = client.MakeBucket(ctx, config.Bucket, minio.MakeBucketOptions{Region: config.Region})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create s3 bucket: %w", err)
+		}
+	}
+
+	return s3BlobStore{client: client, bucket: config.Bucket}, nil
+}
+
+func (s s3BlobStore) Put(ctx context.Context, key string, content io.Reader, size int64) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, content, size, minio.PutObjectOptions{ContentType: "application/pdf"})
+	return err
+}
+
+func (s s3BlobStore) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, translateS3Error(err)
+	}
+
+	// GetObject is lazy, so stat the object to surface a missing key before handing the reader out.
+	if _, err := object.Stat(); err != nil {
+		_ = object.Close()
+		return nil, translateS3Error(err)
+	}
+
+	return object, nil
+}
+
+func (s s3BlobStore) Delete(ctx context.Context, key string) error {
+	return translateS3Error(s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}))
+}
+
+func (s s3BlobStore) Ping(ctx context.Context) error {
+	exists, err := s.client.BucketExists(ctx, s.bucket)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return fmt.Errorf("the s3 bucket %s does not exist", s.bucket)
+	}
+
+	return nil
+}
+
+func translateS3Error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+		return models.ErrBlobNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,90 @@
+package integration
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/modules/minio"
+	"io"
+	"pdf_service_api/models"
+	"pdf_service_api/storage"
+	"strings"
+	"testing"
+)
+
+func TestS3BlobStoreIntegration(t *testing.T) {
+	t.Parallel()
+	t.Run("Put, open and seek a blob", s3PutAndOpen)
+	t.Run("Open a missing blob", s3OpenMissing)
+	t.Run("Delete a blob", s3Delete)
+	t.Run("Ping the bucket", s3Ping)
+}
+
+func createS3BlobStore(t *testing.T) models.BlobStore {
+	ctx := context.Background()
+	ctr, err := minio.Run(ctx, "minio/minio:RELEASE.2024-01-16T16-07-38Z", minio.WithUsername("minioadmin"), minio.WithPassword("minioadmin"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = ctr.Terminate(ctx)
+	})
+
+	endpoint, err := ctr.ConnectionString(ctx)
+	require.NoError(t, err)
+
+	store, err := storage.NewS3BlobStore(storage.ConfigForS3{
+		Endpoint:  endpoint,
+		Bucket:    "documents",
+		AccessKey: ctr.Username,
+		SecretKey: ctr.Password,
+	})
+	require.NoError(t, err)
+
+	return store
+}
+
+func s3PutAndOpen(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store := createS3BlobStore(t)
+
+	err := store.Put(ctx, "documents/test.pdf", strings.NewReader("%PDF-1.4 THIS IS A TEST DOCUMENT"), -1)
+	require.NoError(t, err)
+
+	content, err := store.Open(ctx, "documents/test.pdf")
+	require.NoError(t, err)
+	defer content.Close()
+
+	_, err = content.Seek(9, io.SeekStart)
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	assert.Equal(t, "THIS IS A TEST DOCUMENT", string(data))
+}
+
+func s3OpenMissing(t *testing.T) {
+	t.Parallel()
+	store := createS3BlobStore(t)
+
+	_, err := store.Open(context.Background(), "documents/missing.pdf")
+	assert.ErrorIs(t, err, models.ErrBlobNotFound)
+}
+
+func s3Delete(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store := createS3BlobStore(t)
+
+	require.NoError(t, store.Put(ctx, "documents/test.pdf", strings.NewReader("content"), 7))
+	require.NoError(t, store.Delete(ctx, "documents/test.pdf"))
+
+	_, err := store.Open(ctx, "documents/test.pdf")
+	assert.ErrorIs(t, err, models.ErrBlobNotFound)
+}
+
+func s3Ping(t *testing.T) {
+	t.Parallel()
+	store := createS3BlobStore(t)
+
+	assert.NoError(t, store.Ping(context.Background()))
+}
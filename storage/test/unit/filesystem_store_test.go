@@ -0,0 +1,97 @@
+package unit
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"pdf_service_api/models"
+	"pdf_service_api/storage"
+	"strings"
+	"testing"
+)
+
+func TestFilesystemBlobStoreEmptyRoot(t *testing.T) {
+	_, err := storage.NewFilesystemBlobStore("")
+	assert.Error(t, err)
+}
+
+func TestFilesystemBlobStorePutAndOpen(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewFilesystemBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	err = store.Put(ctx, "documents/test.pdf", strings.NewReader("%PDF-1.4 THIS IS A TEST DOCUMENT"), -1)
+	require.NoError(t, err)
+
+	content, err := store.Open(ctx, "documents/test.pdf")
+	require.NoError(t, err)
+	defer content.Close()
+
+	_, err = content.Seek(9, io.SeekStart)
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	assert.Equal(t, "THIS IS A TEST DOCUMENT", string(data))
+}
+
+func TestFilesystemBlobStorePutReplaces(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewFilesystemBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, "documents/test.pdf", strings.NewReader("first"), -1))
+	require.NoError(t, store.Put(ctx, "documents/test.pdf", strings.NewReader("second"), -1))
+
+	content, err := store.Open(ctx, "documents/test.pdf")
+	require.NoError(t, err)
+	defer content.Close()
+
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+}
+
+func TestFilesystemBlobStoreOpenMissing(t *testing.T) {
+	store, err := storage.NewFilesystemBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = store.Open(context.Background(), "documents/missing.pdf")
+	assert.ErrorIs(t, err, models.ErrBlobNotFound)
+}
+
+func TestFilesystemBlobStoreDelete(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewFilesystemBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, "documents/test.pdf", strings.NewReader("content"), -1))
+	require.NoError(t, store.Delete(ctx, "documents/test.pdf"))
+
+	_, err = store.Open(ctx, "documents/test.pdf")
+	assert.ErrorIs(t, err, models.ErrBlobNotFound)
+
+	assert.NoError(t, store.Delete(ctx, "documents/test.pdf"), "Deleting a missing blob should not fail")
+}
+
+func TestFilesystemBlobStoreRejectsEscapingKeys(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewFilesystemBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../outside.pdf", "/etc/passwd", "documents/../../outside.pdf"} {
+		assert.Error(t, store.Put(ctx, key, strings.NewReader("content"), -1), "Key %q should be rejected", key)
+	}
+}
+
+func TestFilesystemBlobStorePing(t *testing.T) {
+	root := t.TempDir()
+	store, err := storage.NewFilesystemBlobStore(root)
+	require.NoError(t, err)
+	assert.NoError(t, store.Ping(context.Background()))
+
+	require.NoError(t, os.RemoveAll(root))
+	assert.Error(t, store.Ping(context.Background()), "a removed root cannot be reached")
+}
@@ -24,7 +24,7 @@ func CreateDatabaseHandlerFromPostgresInfo(ctx context.Context, ctr postgres.Pos
 			ConUrl: connectionString,
 		}}
 
-	err = dbConfig.RunInitScript()
+	_, err = pg.MigrateUp(ctx, dbConfig)
 	if err != nil {
 		fmt.Println(err.Error())
 		panic(err)
@@ -73,11 +73,6 @@ func CreateTestContainerPostgresWithInitFileName(ctx context.Context, dbUser str
 		return nil, err
 	}
 
-	err = databaseHandler.RunInitScript()
-	if err != nil {
-		return nil, err
-	}
-
 	if initScript == "" {
 		return ctr, nil
 	}
@@ -0,0 +1,101 @@
+package testutil
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// TestPage describes a single page of a PDF generated by BuildTestPDF.
+type TestPage struct {
+	MediaBox [4]float64
+	CropBox  *[4]float64
+	Rotate   int
+	UserUnit float64
+	Text     []TestText
+}
+
+// TestText is a run of text drawn with a fixed width font, where every character advances by half of Size.
+type TestText struct {
+	X    float64
+	Y    float64
+	Size float64
+	S    string
+}
+
+// LetterPage returns a portrait US Letter page.
+func LetterPage(text ...TestText) TestPage {
+	return TestPage{MediaBox: [4]float64{0, 0, 612, 792}, Text: text}
+}
+
+// BuildTestPDF generates a small, valid PDF document containing the given pages.
+// The font used for text has a fixed advance width of 500/1000 em, so text positions are predictable in tests.
+func BuildTestPDF(pages ...TestPage) []byte {
+	objects := make([]string, 0)
+	addObject := func(body string) int {
+		objects = append(objects, body)
+		return len(objects)
+	}
+
+	catalog := addObject("")
+	pagesObject := addObject("")
+
+	widths := strings.TrimSpace(strings.Repeat("500 ", 126-32+1))
+	font := addObject(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /FirstChar 32 /LastChar 126 /Widths [%s] /Encoding /WinAnsiEncoding >>", widths))
+
+	kids := make([]string, 0, len(pages))
+	for _, page := range pages {
+		content := &strings.Builder{}
+		for _, text := range page.Text {
+			fmt.Fprintf(content, "BT /F1 %g Tf %g %g Td (%s) Tj ET\n", text.Size, text.X, text.Y, escapePDFString(text.S))
+		}
+
+		stream := addObject(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+
+		dict := &strings.Builder{}
+		fmt.Fprintf(dict, "<< /Type /Page /Parent %d 0 R /MediaBox %s", pagesObject, pdfRect(page.MediaBox))
+		if page.CropBox != nil {
+			fmt.Fprintf(dict, " /CropBox %s", pdfRect(*page.CropBox))
+		}
+
+		if page.Rotate != 0 {
+			fmt.Fprintf(dict, " /Rotate %d", page.Rotate)
+		}
+
+		if page.UserUnit != 0 {
+			fmt.Fprintf(dict, " /UserUnit %g", page.UserUnit)
+		}
+
+		fmt.Fprintf(dict, " /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>", font, stream)
+		kids = append(kids, fmt.Sprintf("%d 0 R", addObject(dict.String())))
+	}
+
+	objects[catalog-1] = fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObject)
+	objects[pagesObject-1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	buffer := &bytes.Buffer{}
+	buffer.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, body := range objects {
+		offsets[i] = buffer.Len()
+		fmt.Fprintf(buffer, "%d 0 obj\n%s\nendobj\n", i+1, body)
+	}
+
+	xref := buffer.Len()
+	fmt.Fprintf(buffer, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(buffer, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(buffer, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, catalog, xref)
+	return buffer.Bytes()
+}
+
+func pdfRect(rect [4]float64) string {
+	return fmt.Sprintf("[%g %g %g %g]", rect[0], rect[1], rect[2], rect[3])
+}
+
+func escapePDFString(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+}